@@ -18,23 +18,30 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"github.com/datadog/extendeddaemonset/pkg/apis"
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 	edsconfig "github.com/datadog/extendeddaemonset/pkg/config"
 	"github.com/datadog/extendeddaemonset/pkg/controller"
 	"github.com/datadog/extendeddaemonset/pkg/controller/debug"
+	"github.com/datadog/extendeddaemonset/pkg/controller/dogstatsd"
+	"github.com/datadog/extendeddaemonset/pkg/controller/election"
 	"github.com/datadog/extendeddaemonset/pkg/controller/httpserver"
 	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
+	"github.com/datadog/extendeddaemonset/pkg/controller/notifier"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/filteredcache"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/namespaces"
 	"github.com/datadog/extendeddaemonset/version"
 
 	"github.com/heptiolabs/healthcheck"
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
-	"github.com/operator-framework/operator-sdk/pkg/leader"
 	"github.com/operator-framework/operator-sdk/pkg/log/zap"
 
 	"github.com/spf13/pflag"
 
 	"github.com/blang/semver"
+	"k8s.io/apimachinery/pkg/labels"
 	kversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
 
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -42,13 +49,22 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
 )
 
-// Change below variables to serve metrics on different host or port.
 var (
-	bindHost              = "0.0.0.0"
-	bindPort        int32 = 8383
 	printVersionArg bool
+	configFile      string
 	pprofActive     bool
 
+	dogstatsdAddr      string
+	dogstatsdNamespace = dogstatsd.DefaultNamespace
+	dogstatsdTags      []string
+
+	notificationWebhookURLs []string
+	notificationSlackURLs   []string
+	notificationTemplate    string
+	notificationOptions     = notifier.DefaultOptions()
+
+	operatorConfig = edsconfig.NewDefaultOperatorConfiguration()
+
 	log = logf.Log.WithName("cmd")
 )
 
@@ -62,6 +78,18 @@ func main() {
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.BoolVarP(&printVersionArg, "version", "v", printVersionArg, "print version")
 	pflag.BoolVarP(&pprofActive, "pprof", "", false, "enable pprof endpoint")
+	pflag.StringVarP(&dogstatsdAddr, "dogstatsd-addr", "", "", "DogStatsD address where the controller metrics and the rollout events are pushed: \"host:port\" for UDP or \"unix:///path/to/dsd.socket\" for UDS (disabled if empty)")
+	pflag.StringVarP(&dogstatsdNamespace, "dogstatsd-namespace", "", dogstatsdNamespace, "prefix of the metrics pushed to DogStatsD")
+	pflag.StringSliceVarP(&dogstatsdTags, "dogstatsd-tags", "", nil, "tags added to the metrics and events pushed to DogStatsD")
+	pflag.StringSliceVarP(&notificationWebhookURLs, "notification-webhook-urls", "", nil, "webhooks notified of the state transitions of all the ExtendedDaemonSets")
+	pflag.StringSliceVarP(&notificationSlackURLs, "notification-slack-urls", "", nil, "Slack-compatible incoming webhooks notified of the state transitions of all the ExtendedDaemonSets")
+	pflag.StringVarP(&notificationTemplate, "notification-template", "", "", "go template of the messages sent to the global notification sinks")
+	pflag.DurationVarP(&notificationOptions.DedupWindow, "notification-dedup-window", "", notificationOptions.DedupWindow, "duration during which an identical notification is not sent again to a sink")
+	pflag.DurationVarP(&notificationOptions.RateLimitInterval, "notification-rate-limit-interval", "", notificationOptions.RateLimitInterval, "once the burst is reached, a sink receives one notification by ExtendedDaemonSet every interval")
+	pflag.IntVarP(&notificationOptions.RateLimitBurst, "notification-rate-limit-burst", "", notificationOptions.RateLimitBurst, "maximum number of notifications sent in a row to a sink for an ExtendedDaemonSet")
+
+	pflag.StringVarP(&configFile, "config", "", "", "operator configuration file, the flags explicitly set take precedence over its values")
+	edsconfig.AddFlags(pflag.CommandLine, operatorConfig)
 
 	pflag.Parse()
 
@@ -82,11 +110,27 @@ func main() {
 
 	version.PrintVersionLogs(log)
 
-	namespace, err := k8sutil.GetWatchNamespace()
+	if configFile != "" {
+		var err error
+		if operatorConfig, err = edsconfig.LoadOperatorConfiguration(configFile, pflag.CommandLine); err != nil {
+			log.Error(err, "Failed to load the operator configuration", "path", configFile)
+			os.Exit(1)
+		}
+	} else if err := operatorConfig.Validate(); err != nil {
+		log.Error(err, "Invalid operator configuration")
+		os.Exit(1)
+	}
+	tuning := edsconfig.NewTuningProvider(operatorConfig.Controllers.ExtendedDaemonSetReplicaSet.Tuning)
+	operatorConfig.ApplyReloadable(tuning)
+	metricsPort, _ := operatorConfig.MetricsPort()
+
+	// WATCH_NAMESPACE is a comma-separated list of namespaces, all the namespaces if empty
+	watchNamespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
 		os.Exit(1)
 	}
+	watchNamespaces := namespaces.Parse(watchNamespace)
 
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
@@ -104,7 +148,7 @@ func main() {
 			os.Exit(1)
 		}
 		var minServerVersion semver.Version
-		if minServerVersion, err = semver.Make("1.12.0"); err != nil {
+		if minServerVersion, err = semver.Make(operatorConfig.NodeAffinityMatchMinVersion); err != nil {
 			log.Error(err, "")
 			os.Exit(1)
 		}
@@ -122,33 +166,92 @@ func main() {
 		}
 	}
 
-	ctx := context.TODO()
+	// The signal handler can only be set up once, it stops the election wait and the manager
+	stop := signals.SetupSignalHandler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientset := kubernetes.NewForConfigOrDie(cfg)
+
+	// The namespaces matching the selector are resolved once, the caches are scoped to them
+	namespaceSelector, _ := operatorConfig.GetWatchNamespaceSelector()
+	selectedNamespaces, err := namespaces.Select(clientset, watchNamespaces, namespaceSelector)
+	if err != nil {
+		log.Error(err, "Failed to select the watched namespaces")
+		os.Exit(1)
+	}
+	if namespaceSelector != nil && len(selectedNamespaces) == 0 {
+		log.Error(nil, "No namespace matches the watch namespace selector", "selector", namespaceSelector.String())
+		os.Exit(1)
+	}
+	var managerNamespace string
+	if len(selectedNamespaces) == 1 {
+		managerNamespace = selectedNamespaces[0]
+	}
+	log.Info("Watched namespaces", "namespaces", selectedNamespaces)
 
 	// Serve /live and /ready before leader election
 	health := healthcheck.NewHandler()
 
 	// Add a liveness check to detect go routine leaks. If this fails, we want to be restarted.
-	health.AddLivenessCheck("goroutine-threshold", healthcheck.GoroutineCountCheck(200))
+	health.AddLivenessCheck("goroutine-threshold", healthcheck.GoroutineCountCheck(operatorConfig.Health.GoroutineThreshold))
 
 	go func() {
-		log.Error(http.ListenAndServe(":8080", health),
+		log.Error(http.ListenAndServe(operatorConfig.Health.BindAddress, health),
 			"Failed to listen on the probe endpoint. We’ll be killed by k8s.")
 	}()
 
+	// Each shard has its own lock, so the replicas of different shards run concurrently
+	shardOptions, err := operatorConfig.GetShard()
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	lockName := "extendeddaemonset-lock"
+	if id := shardOptions.ID(); id != "" {
+		log.Info("Sharding enabled", "shard", id)
+		lockName += "-" + id
+	}
+
 	// Become the leader before proceeding
-	err = leader.Become(ctx, "extendeddaemonset-lock")
+	elected := make(chan struct{})
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-elected:
+		}
+	}()
+	leaderDone, err := election.Become(ctx, clientset, operatorConfig.GetLeaderElectionOptions(lockName))
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
+	close(elected)
+
+	// Exit if the Lease can't be renewed, another replica may already run the controllers
+	go func() {
+		<-leaderDone
+		if ctx.Err() == nil {
+			log.Error(nil, "Leader election lost")
+			os.Exit(1)
+		}
+	}()
 
 	// Now that we’re a leader, the availability of /metrics becomes part of liveness check
-	health.AddLivenessCheck("metrics", healthcheck.Async(healthcheck.HTTPGetCheck(fmt.Sprintf("http://localhost:%d/metrics", bindPort), 5*time.Second), 10*time.Second))
+	health.AddLivenessCheck("metrics", healthcheck.Async(healthcheck.HTTPGetCheck(fmt.Sprintf("http://localhost:%d/metrics", metricsPort), 5*time.Second), 10*time.Second))
+
+	// Only the ExtendedDaemonSet pods and the trimmed Nodes are cached, to reduce the memory on large clusters
+	podSelector, err := labels.Parse(datadoghqv1alpha1.ExtendedDaemonSetNameLabelKey)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
 
 	// Create a new Cmd to provide shared dependencies and start components
 	mgr, err := manager.New(cfg, manager.Options{
-		Namespace:          namespace,
+		Namespace:          managerNamespace,
 		MetricsBindAddress: "0",
+		NewCache:           filteredcache.NewCacheFunc(filteredcache.Options{PodLabelSelector: podSelector, TrimNodes: true, Namespaces: selectedNamespaces}),
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -163,14 +266,28 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup the notifications, used by the controllers
+	for _, url := range notificationWebhookURLs {
+		notificationOptions.Sinks = append(notificationOptions.Sinks, notifier.Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeWebhook, URL: url, Template: notificationTemplate})
+	}
+	for _, url := range notificationSlackURLs {
+		notificationOptions.Sinks = append(notificationOptions.Sinks, notifier.Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: url, Template: notificationTemplate})
+	}
+	notif := notifier.New(notificationOptions)
+	notifier.SetDefault(notif)
+	if err = mgr.Add(notif); err != nil {
+		log.Error(err, "Notifier registration error")
+		os.Exit(1)
+	}
+
 	// Setup all Controllers
-	if err = controller.AddToManager(mgr); err != nil {
+	if err = controller.AddToManager(mgr, operatorConfig.GetOptions(shardOptions, tuning)); err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
 
 	// Create HttpServer handler
-	srv := httpserver.New(httpserver.Options{BindAddress: fmt.Sprintf("%s:%d", bindHost, bindPort)})
+	srv := httpserver.New(httpserver.Options{BindAddress: operatorConfig.Metrics.BindAddress})
 
 	// configure the metrics handler
 	metricsHandler := metrics.NewHandler(srv)
@@ -182,15 +299,58 @@ func main() {
 	if pprofActive {
 		debug.Register(srv, debug.DefaultOptions())
 	}
+
+	if dogstatsdAddr != "" {
+		var dsdClient *dogstatsd.Client
+		dsdClient, err = dogstatsd.New(dogstatsd.Options{Address: dogstatsdAddr, Namespace: dogstatsdNamespace, Tags: dogstatsdTags})
+		if err != nil {
+			// the metrics are still exposed to Prometheus, don't stop the operator for a metrics sink
+			log.Error(err, "DogStatsD client creation error, the metrics and events are not sent to DogStatsD")
+		} else {
+			metrics.SetForwarder(dsdClient)
+		}
+	}
 	if err = mgr.Add(srv); err != nil {
 		log.Error(err, "HTTP server registration error")
 		os.Exit(1)
 	}
 
+	// Reload the Tuning and the ExtendedDaemonSet defaults when the configuration file changes
+	if configFile != "" {
+		var watcher *edsconfig.Watcher
+		if watcher, err = edsconfig.NewWatcher(configFile, pflag.CommandLine, operatorConfig, tuning); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+		if err = mgr.Add(watcher); err != nil {
+			log.Error(err, "Configuration watcher registration error")
+			os.Exit(1)
+		}
+	}
+
+	// Restart when the namespaces matching the selector change, to scope again the caches
+	if namespaceSelector != nil {
+		if err = mgr.Add(namespaces.NewWatcher(clientset, watchNamespaces, namespaceSelector, selectedNamespaces)); err != nil {
+			log.Error(err, "Namespaces watcher registration error")
+			os.Exit(1)
+		}
+	}
+
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	err = mgr.Start(stop)
+
+	// The controllers are stopped, release the Lease
+	cancel()
+	<-leaderDone
+
+	if err == namespaces.ErrNamespacesChanged {
+		// not a failure: exit cleanly so the container is restarted with the caches scoped to the new namespaces
+		log.Info("Restarting the operator", "reason", err.Error())
+		os.Exit(0)
+	}
+	if err != nil {
 		log.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
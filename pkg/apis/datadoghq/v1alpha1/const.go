@@ -10,8 +10,19 @@ const (
 	ExtendedDaemonSetNameLabelKey = "extendeddaemonset.datadoghq.com/name"
 	// ExtendedDaemonSetReplicaSetNameLabelKey label key use to link a Pod to a ExtendedDaemonSetReplicaSet
 	ExtendedDaemonSetReplicaSetNameLabelKey = "extendeddaemonsetreplicaset.datadoghq.com/name"
+	// ExtendedDaemonSetReplicaSetCanaryLabelKey label key set to "true" on the pods of the canary ExtendedDaemonSetReplicaSet,
+	// removed when the canary deployment ends
+	ExtendedDaemonSetReplicaSetCanaryLabelKey = "extendeddaemonsetreplicaset.datadoghq.com/canary"
+	// ExtendedDaemonSetHookLabelKey label key use to identify the lifecycle hook of a Job
+	ExtendedDaemonSetHookLabelKey = "extendeddaemonset.datadoghq.com/hook"
 	// MD5ExtendedDaemonSetAnnotationKey annotation key use on Pods in order to identify which PodTemplateSpec have been used to generate it.
 	MD5ExtendedDaemonSetAnnotationKey = "extendeddaemonset.datadoghq.com/templatehash"
+	// ExtendedDaemonSetReplicaSetTemplateChangesAnnotationKey annotation key used on ExtendedDaemonSetReplicaSet to list the pod template fields
+	// that changed compared to the active ExtendedDaemonSetReplicaSet when it was created.
+	ExtendedDaemonSetReplicaSetTemplateChangesAnnotationKey = "extendeddaemonset.datadoghq.com/template-changes"
+	// ExtendedDaemonSetReplicaSetChangeCauseAnnotationKey annotation key used on ExtendedDaemonSetReplicaSet to summarize the changes
+	// (images, env, resources, other fields) compared to the active ExtendedDaemonSetReplicaSet when it was created.
+	ExtendedDaemonSetReplicaSetChangeCauseAnnotationKey = "extendeddaemonset.datadoghq.com/change-cause"
 	// ExtendedDaemonSetCanaryValidAnnotationKey annotation key used on Pods in order to detect if a canary deployment is considered valid.
 	ExtendedDaemonSetCanaryValidAnnotationKey = "extendeddaemonset.datadoghq.com/canary-valid"
 	// ExtendedDaemonSetCanaryPausedAnnotationKey annotation key used on ExtendedDaemonset in order to detect if a canary deployment is paused.
@@ -20,6 +31,9 @@ const (
 	ExtendedDaemonSetCanaryPausedReasonAnnotationKey = "extendeddaemonset.datadoghq.com/canary-paused-reason"
 	// ExtendedDaemonSetCanaryFailedAnnotationKey annotation key used on ExtendedDaemonset in order to detect if a canary deployment has failed.
 	ExtendedDaemonSetCanaryFailedAnnotationKey = "extendeddaemonset.datadoghq.com/canary-failed"
+	// ExtendedDaemonSetRollingUpdatePausedAnnotationKey annotation key used on ExtendedDaemonset to pause the rolling update of an ExtendedDaemonSetReplicaSet.
+	// The value is the name of the paused ExtendedDaemonSetReplicaSet, the rolling update is resumed when the annotation is removed.
+	ExtendedDaemonSetRollingUpdatePausedAnnotationKey = "extendeddaemonset.datadoghq.com/rolling-update-paused"
 	// ExtendedDaemonSetOldDaemonsetAnnotationKey annotation key used on ExtendedDaemonset in order to inform the controller that old Daemonset's pod.
 	// should be taken into consideration during the initial rolling-update.
 	ExtendedDaemonSetOldDaemonsetAnnotationKey = "extendeddaemonset.datadoghq.com/old-daemonset"
@@ -28,4 +42,16 @@ const (
 	ExtendedDaemonSetRessourceNodeAnnotationKey = "resources.extendeddaemonset.datadoghq.com/%s.%s.%s"
 	// MD5NodeExtendedDaemonSetAnnotationKey annotation key use on Pods in order to identify which Node Resources Overwride have been used to generate it.
 	MD5NodeExtendedDaemonSetAnnotationKey = "extendeddaemonset.datadoghq.com/nodehash"
+	// ResourcesProfileAnnotationKey annotation key use on Pods in order to identify which resources profile have been used to generate it.
+	ResourcesProfileAnnotationKey = "extendeddaemonset.datadoghq.com/resources-profile"
+	// ExtendedDaemonSetActionAnnotationKey annotation key used on ExtendedDaemonset to record the last canary action requested: CanaryPaused, CanaryValidated...
+	// The actor and reason annotations are only used for the history entry of this action on the action replicaset.
+	ExtendedDaemonSetActionAnnotationKey = "extendeddaemonset.datadoghq.com/action"
+	// ExtendedDaemonSetActionReplicaSetAnnotationKey annotation key used on ExtendedDaemonset to record the ExtendedDaemonSetReplicaSet targeted by the last canary action.
+	ExtendedDaemonSetActionReplicaSetAnnotationKey = "extendeddaemonset.datadoghq.com/action-replicaset"
+	// ExtendedDaemonSetActionActorAnnotationKey annotation key used on ExtendedDaemonset to record who requested the last canary action.
+	// It is informative: the kubectl plugin sets the kubeconfig AuthInfo name or the OS user, it is not an authenticated identity.
+	ExtendedDaemonSetActionActorAnnotationKey = "extendeddaemonset.datadoghq.com/action-actor"
+	// ExtendedDaemonSetActionReasonAnnotationKey annotation key used on ExtendedDaemonset to record why the last canary action was requested.
+	ExtendedDaemonSetActionReasonAnnotationKey = "extendeddaemonset.datadoghq.com/action-reason"
 )
@@ -6,6 +6,8 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,8 +20,126 @@ const (
 	defaultSlowStartIntervalDuration = 1
 	defaultMaxParallelPodCreation    = 250
 	defaultReconcileFrequency        = 10 * time.Second
+	defaultOOMKilledMinRestartCount  = 3
+	defaultOOMKilledMemoryIncrease   = 50
+	defaultUnschedulableDuration     = 5 * time.Minute
+	defaultProfileRetryInterval      = time.Hour
+	defaultMaxFailedPods             = "10%"
+	defaultUnavailableDeadline       = 10 * time.Minute
+	defaultMaxRestarts               = 5
 )
 
+// ExtendedDaemonSetDefaults contains the default values of the ExtendedDaemonSet fields not set by the user.
+// They can be changed cluster-wide in the operator configuration.
+type ExtendedDaemonSetDefaults struct {
+	CanaryReplicas                 intstr.IntOrString `json:"canaryReplicas,omitempty"`
+	CanaryDuration                 metav1.Duration    `json:"canaryDuration,omitempty"`
+	MaxUnavailable                 intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	MaxParallelPodCreation         int32              `json:"maxParallelPodCreation,omitempty"`
+	MaxPodSchedulerFailure         intstr.IntOrString `json:"maxPodSchedulerFailure,omitempty"`
+	SlowStartIntervalDuration      metav1.Duration    `json:"slowStartIntervalDuration,omitempty"`
+	SlowStartAdditiveIncrease      intstr.IntOrString `json:"slowStartAdditiveIncrease,omitempty"`
+	ReconcileFrequency             metav1.Duration    `json:"reconcileFrequency,omitempty"`
+	MaxFailedPods                  intstr.IntOrString `json:"maxFailedPods,omitempty"`
+	UnavailableDeadline            metav1.Duration    `json:"unavailableDeadline,omitempty"`
+	MaxRestarts                    int32              `json:"maxRestarts,omitempty"`
+	OOMKilledMinRestartCount       int32              `json:"oomKilledMinRestartCount,omitempty"`
+	OOMKilledMemoryIncreasePercent int32              `json:"oomKilledMemoryIncreasePercent,omitempty"`
+	UnschedulableDuration          metav1.Duration    `json:"unschedulableDuration,omitempty"`
+	ProfileRetryInterval           metav1.Duration    `json:"profileRetryInterval,omitempty"`
+}
+
+// NewExtendedDaemonSetDefaults returns the built-in ExtendedDaemonSet default values
+func NewExtendedDaemonSetDefaults() ExtendedDaemonSetDefaults {
+	return ExtendedDaemonSetDefaults{
+		CanaryReplicas:                 intstr.FromInt(defaultCanaryReplica),
+		CanaryDuration:                 metav1.Duration{Duration: defaultCanaryDuration * time.Minute},
+		MaxUnavailable:                 intstr.FromInt(1),
+		MaxParallelPodCreation:         defaultMaxParallelPodCreation,
+		MaxPodSchedulerFailure:         intstr.FromInt(0),
+		SlowStartIntervalDuration:      metav1.Duration{Duration: defaultSlowStartIntervalDuration * time.Minute},
+		SlowStartAdditiveIncrease:      intstr.FromInt(1),
+		ReconcileFrequency:             metav1.Duration{Duration: defaultReconcileFrequency},
+		MaxFailedPods:                  intstr.FromString(defaultMaxFailedPods),
+		UnavailableDeadline:            metav1.Duration{Duration: defaultUnavailableDeadline},
+		MaxRestarts:                    defaultMaxRestarts,
+		OOMKilledMinRestartCount:       defaultOOMKilledMinRestartCount,
+		OOMKilledMemoryIncreasePercent: defaultOOMKilledMemoryIncrease,
+		UnschedulableDuration:          metav1.Duration{Duration: defaultUnschedulableDuration},
+		ProfileRetryInterval:           metav1.Duration{Duration: defaultProfileRetryInterval},
+	}
+}
+
+// Validate returns an error if one of the default values is invalid
+func (d ExtendedDaemonSetDefaults) Validate() error {
+	intOrPercents := map[string]intstr.IntOrString{
+		"canaryReplicas":            d.CanaryReplicas,
+		"maxUnavailable":            d.MaxUnavailable,
+		"maxPodSchedulerFailure":    d.MaxPodSchedulerFailure,
+		"slowStartAdditiveIncrease": d.SlowStartAdditiveIncrease,
+		"maxFailedPods":             d.MaxFailedPods,
+	}
+	for name, value := range intOrPercents {
+		value := value
+		nb, err := intstr.GetValueFromIntOrPercent(&value, 100, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %v", name, value.String(), err)
+		}
+		if nb < 0 {
+			return fmt.Errorf("invalid %s %q: must not be negative", name, value.String())
+		}
+	}
+
+	durations := map[string]metav1.Duration{
+		"canaryDuration":            d.CanaryDuration,
+		"slowStartIntervalDuration": d.SlowStartIntervalDuration,
+		"reconcileFrequency":        d.ReconcileFrequency,
+		"unavailableDeadline":       d.UnavailableDeadline,
+		"unschedulableDuration":     d.UnschedulableDuration,
+		"profileRetryInterval":      d.ProfileRetryInterval,
+	}
+	for name, value := range durations {
+		if value.Duration <= 0 {
+			return fmt.Errorf("invalid %s %s: must be positive", name, value.Duration)
+		}
+	}
+
+	if d.MaxParallelPodCreation <= 0 {
+		return fmt.Errorf("invalid maxParallelPodCreation %d: must be positive", d.MaxParallelPodCreation)
+	}
+	counts := map[string]int32{
+		"maxRestarts":                    d.MaxRestarts,
+		"oomKilledMinRestartCount":       d.OOMKilledMinRestartCount,
+		"oomKilledMemoryIncreasePercent": d.OOMKilledMemoryIncreasePercent,
+	}
+	for name, value := range counts {
+		if value < 0 {
+			return fmt.Errorf("invalid %s %d: must not be negative", name, value)
+		}
+	}
+	return nil
+}
+
+var (
+	extendedDaemonSetDefaults      = NewExtendedDaemonSetDefaults()
+	extendedDaemonSetDefaultsMutex sync.RWMutex
+)
+
+// SetExtendedDaemonSetDefaults changes the default values used by the DefaultExtendedDaemonSet functions.
+// The ExtendedDaemonSets already defaulted keep their values.
+func SetExtendedDaemonSetDefaults(defaults ExtendedDaemonSetDefaults) {
+	extendedDaemonSetDefaultsMutex.Lock()
+	defer extendedDaemonSetDefaultsMutex.Unlock()
+	extendedDaemonSetDefaults = defaults
+}
+
+// GetExtendedDaemonSetDefaults returns the default values used by the DefaultExtendedDaemonSet functions
+func GetExtendedDaemonSetDefaults() ExtendedDaemonSetDefaults {
+	extendedDaemonSetDefaultsMutex.RLock()
+	defer extendedDaemonSetDefaultsMutex.RUnlock()
+	return extendedDaemonSetDefaults
+}
+
 // IsDefaultedExtendedDaemonSet used to know if a ExtendedDaemonSet is already defaulted
 // returns true if yes, else no
 func IsDefaultedExtendedDaemonSet(dd *ExtendedDaemonSet) bool {
@@ -37,6 +157,24 @@ func IsDefaultedExtendedDaemonSet(dd *ExtendedDaemonSet) bool {
 		return false
 	}
 
+	if dd.Spec.Strategy.Hooks != nil {
+		if defaulted := IsDefaultedExtendedDaemonSetSpecStrategyHooks(dd.Spec.Strategy.Hooks); !defaulted {
+			return false
+		}
+	}
+
+	if dd.Spec.OOMKilledPolicy != nil {
+		if defaulted := IsDefaultedExtendedDaemonSetSpecOOMKilledPolicy(dd.Spec.OOMKilledPolicy); !defaulted {
+			return false
+		}
+	}
+
+	if dd.Spec.ResourcesProfiles != nil {
+		if defaulted := IsDefaultedExtendedDaemonSetSpecResourcesProfiles(dd.Spec.ResourcesProfiles); !defaulted {
+			return false
+		}
+	}
+
 	if dd.Spec.Template.Name != "" {
 		// this field needs to be cleaned up as we can't deploy multiple
 		// pods with the same name
@@ -69,6 +207,35 @@ func IsDefaultedExtendedDaemonSetSpecStrategyRollingUpdate(rollingupdate *Extend
 		return false
 	}
 
+	if rollingupdate.DeletionOrder == "" {
+		return false
+	}
+
+	if rollingupdate.DeletionMode == "" {
+		return false
+	}
+
+	if rollingupdate.FailurePolicy != nil {
+		if defaulted := IsDefaultedExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy(rollingupdate.FailurePolicy); !defaulted {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsDefaultedExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy used to know if a ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy is already defaulted
+// returns true if yes, else no
+func IsDefaultedExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy(policy *ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy) bool {
+	if policy.MaxFailedPods == nil {
+		return false
+	}
+	if policy.UnavailableDeadline == nil {
+		return false
+	}
+	if policy.MaxRestarts == nil {
+		return false
+	}
 	return true
 }
 
@@ -88,6 +255,41 @@ func IsDefaultedExtendedDaemonSetSpecStrategyCanary(canary *ExtendedDaemonSetSpe
 	return true
 }
 
+// IsDefaultedExtendedDaemonSetSpecStrategyHooks used to know if a ExtendedDaemonSetSpecStrategyHooks is already defaulted
+// returns true if yes, else no
+func IsDefaultedExtendedDaemonSetSpecStrategyHooks(hooks *ExtendedDaemonSetSpecStrategyHooks) bool {
+	for _, hook := range []*ExtendedDaemonSetHook{hooks.PreCanary, hooks.CanaryVerification, hooks.PreRollout, hooks.PostRollout} {
+		if hook != nil && hook.FailurePolicy == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// IsDefaultedExtendedDaemonSetSpecOOMKilledPolicy used to know if a ExtendedDaemonSetSpecOOMKilledPolicy is already defaulted
+// returns true if yes, else no
+func IsDefaultedExtendedDaemonSetSpecOOMKilledPolicy(policy *ExtendedDaemonSetSpecOOMKilledPolicy) bool {
+	if policy.MinRestartCount == nil {
+		return false
+	}
+	if policy.MemoryIncreasePercent == nil {
+		return false
+	}
+	return true
+}
+
+// IsDefaultedExtendedDaemonSetSpecResourcesProfiles used to know if a ExtendedDaemonSetSpecResourcesProfiles is already defaulted
+// returns true if yes, else no
+func IsDefaultedExtendedDaemonSetSpecResourcesProfiles(profiles *ExtendedDaemonSetSpecResourcesProfiles) bool {
+	if profiles.UnschedulableDuration == nil {
+		return false
+	}
+	if profiles.RetryInterval == nil {
+		return false
+	}
+	return true
+}
+
 // DefaultExtendedDaemonSet used to default an ExtendedDaemonSet
 // return a list of errors in case of unvalid fields.
 func DefaultExtendedDaemonSet(dd *ExtendedDaemonSet) *ExtendedDaemonSet {
@@ -108,7 +310,19 @@ func DefaultExtendedDaemonSetSpec(spec *ExtendedDaemonSetSpec) *ExtendedDaemonSe
 	}
 
 	if spec.Strategy.ReconcileFrequency == nil {
-		spec.Strategy.ReconcileFrequency = &metav1.Duration{Duration: defaultReconcileFrequency}
+		spec.Strategy.ReconcileFrequency = &metav1.Duration{Duration: GetExtendedDaemonSetDefaults().ReconcileFrequency.Duration}
+	}
+
+	if spec.Strategy.Hooks != nil {
+		DefaultExtendedDaemonSetSpecStrategyHooks(spec.Strategy.Hooks)
+	}
+
+	if spec.OOMKilledPolicy != nil {
+		DefaultExtendedDaemonSetSpecOOMKilledPolicy(spec.OOMKilledPolicy)
+	}
+
+	if spec.ResourcesProfiles != nil {
+		DefaultExtendedDaemonSetSpecResourcesProfiles(spec.ResourcesProfiles)
 	}
 
 	return spec
@@ -116,13 +330,14 @@ func DefaultExtendedDaemonSetSpec(spec *ExtendedDaemonSetSpec) *ExtendedDaemonSe
 
 // DefaultExtendedDaemonSetSpecStrategyCanary used to default an ExtendedDaemonSetSpecStrategyCanary
 func DefaultExtendedDaemonSetSpecStrategyCanary(c *ExtendedDaemonSetSpecStrategyCanary) *ExtendedDaemonSetSpecStrategyCanary {
+	defaults := GetExtendedDaemonSetDefaults()
 	if c.Duration == nil {
 		c.Duration = &metav1.Duration{
-			Duration: defaultCanaryDuration * time.Minute,
+			Duration: defaults.CanaryDuration.Duration,
 		}
 	}
 	if c.Replicas == nil {
-		replicas := intstr.FromInt(defaultCanaryReplica)
+		replicas := defaults.CanaryReplicas
 		c.Replicas = &replicas
 	}
 	if c.NodeSelector == nil {
@@ -135,20 +350,80 @@ func DefaultExtendedDaemonSetSpecStrategyCanary(c *ExtendedDaemonSetSpecStrategy
 
 // DefaultExtendedDaemonSetSpecStrategyRollingUpdate used to default an ExtendedDaemonSetSpecStrategyRollingUpdate
 func DefaultExtendedDaemonSetSpecStrategyRollingUpdate(rollingupdate *ExtendedDaemonSetSpecStrategyRollingUpdate) *ExtendedDaemonSetSpecStrategyRollingUpdate {
-	rollingupdate.MaxUnavailable = intstr.ValueOrDefault(rollingupdate.MaxUnavailable, intstr.FromInt(1))
+	defaults := GetExtendedDaemonSetDefaults()
+	rollingupdate.MaxUnavailable = intstr.ValueOrDefault(rollingupdate.MaxUnavailable, defaults.MaxUnavailable)
 
 	if rollingupdate.MaxParallelPodCreation == nil {
-		rollingupdate.MaxParallelPodCreation = NewInt32(defaultMaxParallelPodCreation)
+		rollingupdate.MaxParallelPodCreation = NewInt32(defaults.MaxParallelPodCreation)
 	}
 
-	rollingupdate.MaxPodSchedulerFailure = intstr.ValueOrDefault(rollingupdate.MaxPodSchedulerFailure, intstr.FromInt(0))
+	rollingupdate.MaxPodSchedulerFailure = intstr.ValueOrDefault(rollingupdate.MaxPodSchedulerFailure, defaults.MaxPodSchedulerFailure)
 
 	if rollingupdate.SlowStartIntervalDuration == nil {
 		rollingupdate.SlowStartIntervalDuration = &metav1.Duration{
-			Duration: defaultSlowStartIntervalDuration * time.Minute,
+			Duration: defaults.SlowStartIntervalDuration.Duration,
 		}
 	}
 
-	rollingupdate.SlowStartAdditiveIncrease = intstr.ValueOrDefault(rollingupdate.SlowStartAdditiveIncrease, intstr.FromInt(1))
+	rollingupdate.SlowStartAdditiveIncrease = intstr.ValueOrDefault(rollingupdate.SlowStartAdditiveIncrease, defaults.SlowStartAdditiveIncrease)
+
+	if rollingupdate.DeletionOrder == "" {
+		rollingupdate.DeletionOrder = ExtendedDaemonSetDeletionOrderOldestPod
+	}
+
+	if rollingupdate.DeletionMode == "" {
+		rollingupdate.DeletionMode = ExtendedDaemonSetDeletionModeDelete
+	}
+
+	if rollingupdate.FailurePolicy != nil {
+		DefaultExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy(rollingupdate.FailurePolicy)
+	}
 	return rollingupdate
 }
+
+// DefaultExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy used to default an ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy
+func DefaultExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy(policy *ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy) *ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy {
+	defaults := GetExtendedDaemonSetDefaults()
+	policy.MaxFailedPods = intstr.ValueOrDefault(policy.MaxFailedPods, defaults.MaxFailedPods)
+	if policy.UnavailableDeadline == nil {
+		policy.UnavailableDeadline = &metav1.Duration{Duration: defaults.UnavailableDeadline.Duration}
+	}
+	if policy.MaxRestarts == nil {
+		policy.MaxRestarts = NewInt32(defaults.MaxRestarts)
+	}
+	return policy
+}
+
+// DefaultExtendedDaemonSetSpecStrategyHooks used to default an ExtendedDaemonSetSpecStrategyHooks
+func DefaultExtendedDaemonSetSpecStrategyHooks(hooks *ExtendedDaemonSetSpecStrategyHooks) *ExtendedDaemonSetSpecStrategyHooks {
+	for _, hook := range []*ExtendedDaemonSetHook{hooks.PreCanary, hooks.CanaryVerification, hooks.PreRollout, hooks.PostRollout} {
+		if hook != nil && hook.FailurePolicy == "" {
+			hook.FailurePolicy = ExtendedDaemonSetHookFailurePolicyAbort
+		}
+	}
+	return hooks
+}
+
+// DefaultExtendedDaemonSetSpecOOMKilledPolicy used to default an ExtendedDaemonSetSpecOOMKilledPolicy
+func DefaultExtendedDaemonSetSpecOOMKilledPolicy(policy *ExtendedDaemonSetSpecOOMKilledPolicy) *ExtendedDaemonSetSpecOOMKilledPolicy {
+	defaults := GetExtendedDaemonSetDefaults()
+	if policy.MinRestartCount == nil {
+		policy.MinRestartCount = NewInt32(defaults.OOMKilledMinRestartCount)
+	}
+	if policy.MemoryIncreasePercent == nil {
+		policy.MemoryIncreasePercent = NewInt32(defaults.OOMKilledMemoryIncreasePercent)
+	}
+	return policy
+}
+
+// DefaultExtendedDaemonSetSpecResourcesProfiles used to default an ExtendedDaemonSetSpecResourcesProfiles
+func DefaultExtendedDaemonSetSpecResourcesProfiles(profiles *ExtendedDaemonSetSpecResourcesProfiles) *ExtendedDaemonSetSpecResourcesProfiles {
+	defaults := GetExtendedDaemonSetDefaults()
+	if profiles.UnschedulableDuration == nil {
+		profiles.UnschedulableDuration = &metav1.Duration{Duration: defaults.UnschedulableDuration.Duration}
+	}
+	if profiles.RetryInterval == nil {
+		profiles.RetryInterval = &metav1.Duration{Duration: defaults.ProfileRetryInterval.Duration}
+	}
+	return profiles
+}
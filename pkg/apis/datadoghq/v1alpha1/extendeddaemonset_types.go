@@ -6,6 +6,8 @@
 package v1alpha1
 
 import (
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -31,6 +33,95 @@ type ExtendedDaemonSetSpec struct {
 
 	// Daemonset deployment strategy
 	Strategy ExtendedDaemonSetSpecStrategy `json:"strategy"`
+
+	// OOMKilledPolicy configures the automatic memory increase of a container
+	// that is repeatedly OOMKilled on a Node. Disabled if not set.
+	// +optional
+	OOMKilledPolicy *ExtendedDaemonSetSpecOOMKilledPolicy `json:"oomKilledPolicy,omitempty"`
+
+	// ResourcesProfiles configures the fallback container resources used on the Nodes
+	// where the pod doesn't fit due to resource constraints.
+	// +optional
+	ResourcesProfiles *ExtendedDaemonSetSpecResourcesProfiles `json:"resourcesProfiles,omitempty"`
+
+	// Notifications list of the sinks notified of the ExtendedDaemonSet state transitions,
+	// in addition to the sinks configured on the controller.
+	// +optional
+	// +listType=atomic
+	Notifications []ExtendedDaemonSetSpecNotification `json:"notifications,omitempty"`
+}
+
+// ExtendedDaemonSetNotificationType type representing the type of a notification sink
+type ExtendedDaemonSetNotificationType string
+
+const (
+	// ExtendedDaemonSetNotificationTypeWebhook generic webhook: the message and the event are posted in JSON
+	ExtendedDaemonSetNotificationTypeWebhook ExtendedDaemonSetNotificationType = "Webhook"
+	// ExtendedDaemonSetNotificationTypeSlack Slack-compatible incoming webhook
+	ExtendedDaemonSetNotificationTypeSlack ExtendedDaemonSetNotificationType = "Slack"
+)
+
+// ExtendedDaemonSetSpecNotification defines a sink notified of the ExtendedDaemonSet state transitions
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetSpecNotification struct {
+	// Type of the sink: Webhook or Slack
+	Type ExtendedDaemonSetNotificationType `json:"type"`
+	// URL of the webhook
+	// +optional
+	URL string `json:"url,omitempty"`
+	// URLSecretRef reference to the Secret key containing the URL of the webhook.
+	// Used when URL is empty.
+	// +optional
+	URLSecretRef *corev1.SecretKeySelector `json:"urlSecretRef,omitempty"`
+	// Template go template of the message, executed with the notification event.
+	// Default value is "ExtendedDaemonSet {{ .Namespace }}/{{ .Name }}: {{ .Summary }}".
+	// +optional
+	Template string `json:"template,omitempty"`
+}
+
+// ExtendedDaemonSetSpecOOMKilledPolicy defines how the memory of OOMKilled containers is increased.
+// The new resources are stored in the Node resources annotation, and the pod is replaced
+// with the rolling update strategy.
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetSpecOOMKilledPolicy struct {
+	// MinRestartCount the minimum number of restarts of a container, with its last
+	// termination due to OOMKilled, before increasing its memory.
+	// Default value is 3.
+	MinRestartCount *int32 `json:"minRestartCount,omitempty"`
+	// MemoryIncreasePercent the percentage added to the container memory request and limit.
+	// Default value is 50.
+	MemoryIncreasePercent *int32 `json:"memoryIncreasePercent,omitempty"`
+	// MaxMemory the maximum value of the container memory limit, should be greater than 0.
+	MaxMemory *resource.Quantity `json:"maxMemory"`
+}
+
+// ExtendedDaemonSetSpecResourcesProfiles defines the fallback resources profiles of ExtendedDaemonSet.
+// When a pod stays unschedulable on a Node due to resource constraints, it is recreated on this Node
+// with the next profile of the list.
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetSpecResourcesProfiles struct {
+	// Profiles ordered list of resources profiles, from the largest to the smallest.
+	// +listType=map
+	// +listMapKey=name
+	Profiles []ExtendedDaemonSetResourcesProfile `json:"profiles"`
+	// UnschedulableDuration the duration a pod stays unschedulable on a Node due to resource constraints
+	// before being recreated with the next profile.
+	// Default value is 5min.
+	UnschedulableDuration *metav1.Duration `json:"unschedulableDuration,omitempty"`
+	// RetryInterval the duration a Node uses a profile before trying again the previous (larger) profile.
+	// Default value is 1h.
+	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
+}
+
+// ExtendedDaemonSetResourcesProfile defines a containers resources override
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetResourcesProfile struct {
+	// Name of the profile.
+	Name string `json:"name"`
+	// Containers contains a list of container spec override.
+	// +listType=map
+	// +listMapKey=name
+	Containers []ExtendedDaemonsetSettingContainerSpec `json:"containers"`
 }
 
 // ExtendedDaemonSetSpecStrategy defines the deployment strategy of ExtendedDaemonSet
@@ -41,8 +132,75 @@ type ExtendedDaemonSetSpecStrategy struct {
 	Canary *ExtendedDaemonSetSpecStrategyCanary `json:"canary,omitempty"`
 	// ReconcileFrequency use to configure how often the ExtendedDeamonset will be fully reconcile, default is 10sec
 	ReconcileFrequency *metav1.Duration `json:"reconcileFrequency,omitempty"`
+	// ProgressDeadlineSeconds the maximum duration in seconds without an additional pod of the deployed
+	// ExtendedDaemonSetReplicaSet becoming available. Once exceeded, the Progressing condition is set to False
+	// with the ProgressDeadlineExceeded reason. Disabled if not set.
+	// +optional
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	// ProgressDeadlineAction the action executed when the progress deadline is exceeded: None, Pause or Rollback.
+	// Default value is None.
+	// +optional
+	ProgressDeadlineAction ExtendedDaemonSetProgressDeadlineAction `json:"progressDeadlineAction,omitempty"`
+	// Hooks Jobs executed around the rollout of a new ExtendedDaemonSetReplicaSet.
+	// +optional
+	Hooks *ExtendedDaemonSetSpecStrategyHooks `json:"hooks,omitempty"`
 }
 
+// ExtendedDaemonSetSpecStrategyHooks defines the lifecycle hooks of an ExtendedDaemonSet rollout.
+// Each hook creates Jobs from its template, the rollout continues only once they succeeded.
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetSpecStrategyHooks struct {
+	// PreCanary Job executed before the canary pods are created.
+	// +optional
+	PreCanary *ExtendedDaemonSetHook `json:"preCanary,omitempty"`
+	// CanaryVerification Job executed on each canary Node once the canary pods are available.
+	// The canary deployment is validated only once all the Jobs succeeded.
+	// +optional
+	CanaryVerification *ExtendedDaemonSetHook `json:"canaryVerification,omitempty"`
+	// PreRollout Job executed before the new version is deployed on all the Nodes.
+	// +optional
+	PreRollout *ExtendedDaemonSetHook `json:"preRollout,omitempty"`
+	// PostRollout Job executed once the new version is available on all the Nodes.
+	// +optional
+	PostRollout *ExtendedDaemonSetHook `json:"postRollout,omitempty"`
+}
+
+// ExtendedDaemonSetHookFailurePolicy type representing what happens when a hook Job fails
+type ExtendedDaemonSetHookFailurePolicy string
+
+const (
+	// ExtendedDaemonSetHookFailurePolicyAbort the rollout is aborted: the canary deployment is failed,
+	// or the new version is not deployed on all the Nodes
+	ExtendedDaemonSetHookFailurePolicyAbort ExtendedDaemonSetHookFailurePolicy = "Abort"
+	// ExtendedDaemonSetHookFailurePolicyIgnore the rollout continues
+	ExtendedDaemonSetHookFailurePolicyIgnore ExtendedDaemonSetHookFailurePolicy = "Ignore"
+)
+
+// ExtendedDaemonSetHook defines a lifecycle hook of an ExtendedDaemonSet rollout
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetHook struct {
+	// Template of the Job created for the hook.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template batchv1beta1.JobTemplateSpec `json:"template"`
+	// FailurePolicy what happens when a Job of the hook fails: Abort or Ignore.
+	// Default value is Abort.
+	// +optional
+	FailurePolicy ExtendedDaemonSetHookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// ExtendedDaemonSetProgressDeadlineAction type representing the action executed when the progress deadline is exceeded
+type ExtendedDaemonSetProgressDeadlineAction string
+
+const (
+	// ExtendedDaemonSetProgressDeadlineActionNone only the Progressing condition is updated
+	ExtendedDaemonSetProgressDeadlineActionNone ExtendedDaemonSetProgressDeadlineAction = "None"
+	// ExtendedDaemonSetProgressDeadlineActionPause the canary deployment or the rolling update is paused until it is resumed
+	ExtendedDaemonSetProgressDeadlineActionPause ExtendedDaemonSetProgressDeadlineAction = "Pause"
+	// ExtendedDaemonSetProgressDeadlineActionRollback the canary deployment is failed, the rolling update is halted
+	// and the previous ExtendedDaemonSetReplicaSet is deployed again
+	ExtendedDaemonSetProgressDeadlineActionRollback ExtendedDaemonSetProgressDeadlineAction = "Rollback"
+)
+
 // ExtendedDaemonSetSpecStrategyRollingUpdate defines the rolling update deployment strategy of ExtendedDaemonSet
 // +k8s:openapi-gen=true
 type ExtendedDaemonSetSpecStrategyRollingUpdate struct {
@@ -68,6 +226,74 @@ type ExtendedDaemonSetSpecStrategyRollingUpdate struct {
 	// number of DaemonSet pods at the start of the update (ex: 10%).
 	// Default value is 5.
 	SlowStartAdditiveIncrease *intstr.IntOrString `json:"slowStartAdditiveIncrease,omitempty"`
+	// FailurePolicy configures the detection of a failing rolling update, after the canary phase.
+	// Disabled if not set.
+	// +optional
+	FailurePolicy *ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy `json:"failurePolicy,omitempty"`
+	// DeletionOrder the order the pods of the previous version are replaced: OldestPod or NodeLabel.
+	// The pods already unavailable or in CrashLoopBackOff are always replaced first, they don't
+	// consume MaxUnavailable.
+	// Default value is OldestPod.
+	// +optional
+	DeletionOrder ExtendedDaemonSetDeletionOrder `json:"deletionOrder,omitempty"`
+	// DeletionOrderNodeLabelKey the Node label used by the NodeLabel deletion order: the pods are replaced
+	// by ascending label value, compared as numbers when possible. The Nodes without the label come last.
+	// +optional
+	DeletionOrderNodeLabelKey string `json:"deletionOrderNodeLabelKey,omitempty"`
+	// DeletionMode how the pods of the previous version and the pods cleaned up are removed: Delete or Evict.
+	// With Evict, the pods are removed with the Eviction API that honours the PodDisruptionBudgets,
+	// the evictions rejected by a PodDisruptionBudget are retried later.
+	// Default value is Delete.
+	// +optional
+	DeletionMode ExtendedDaemonSetDeletionMode `json:"deletionMode,omitempty"`
+	// TerminationGracePeriodSeconds overrides the termination grace period of the pods of the previous version
+	// and of the pods cleaned up. If not set, the pod termination grace period is used.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+}
+
+// ExtendedDaemonSetDeletionMode type representing how the pods of the previous version are removed
+type ExtendedDaemonSetDeletionMode string
+
+const (
+	// ExtendedDaemonSetDeletionModeDelete the pods are deleted
+	ExtendedDaemonSetDeletionModeDelete ExtendedDaemonSetDeletionMode = "Delete"
+	// ExtendedDaemonSetDeletionModeEvict the pods are evicted with the Eviction API
+	ExtendedDaemonSetDeletionModeEvict ExtendedDaemonSetDeletionMode = "Evict"
+)
+
+// ExtendedDaemonSetDeletionOrder type representing the order the pods of the previous version are replaced
+type ExtendedDaemonSetDeletionOrder string
+
+const (
+	// ExtendedDaemonSetDeletionOrderOldestPod the oldest pods are replaced first
+	ExtendedDaemonSetDeletionOrderOldestPod ExtendedDaemonSetDeletionOrder = "OldestPod"
+	// ExtendedDaemonSetDeletionOrderNodeLabel the pods are replaced by ascending value of a Node label
+	ExtendedDaemonSetDeletionOrderNodeLabel ExtendedDaemonSetDeletionOrder = "NodeLabel"
+)
+
+// ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy defines when a rolling update is considered as failed.
+// A failed rolling update is halted: the remaining pods are not replaced.
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy struct {
+	// MaxFailedPods the maximum number of failed pods of the new version before the rolling update
+	// is considered as failed. A pod is failed if it is not available after UnavailableDeadline,
+	// or if its containers restarted more than MaxRestarts times.
+	// Value can be an absolute number (ex: 5) or a percentage of total
+	// number of DaemonSet pods (ex: 10%). Absolute number is calculated from percentage by rounding up.
+	// Default value is 10%.
+	MaxFailedPods *intstr.IntOrString `json:"maxFailedPods,omitempty"`
+	// UnavailableDeadline the duration after its creation a pod of the new version can stay unavailable.
+	// Default value is 10min.
+	UnavailableDeadline *metav1.Duration `json:"unavailableDeadline,omitempty"`
+	// MaxRestarts the maximum number of restarts of the containers of a pod of the new version.
+	// Default value is 5.
+	MaxRestarts *int32 `json:"maxRestarts,omitempty"`
+	// Rollback if true, the ExtendedDaemonSet template is reverted to the template of the previously
+	// active ExtendedDaemonSetReplicaSet when the rolling update fails. The previous version is then
+	// deployed with the rolling update strategy, without canary phase.
+	// +optional
+	Rollback bool `json:"rollback,omitempty"`
 }
 
 // ExtendedDaemonSetSpecStrategyCanary defines the canary deployment strategy of ExtendedDaemonSet
@@ -78,6 +304,10 @@ type ExtendedDaemonSetSpecStrategyCanary struct {
 	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
 	// +listType=set
 	NodeAntiAffinityKeys []string `json:"nodeAntiAffinityKeys,omitempty"`
+	// SettingsChanges if true, the changes of the ExtendedDaemonsetSettings and of the Node resources overwrite annotations
+	// are first deployed on a canary subset of the Nodes they apply to, with the same pause/validate/fail controls.
+	// +optional
+	SettingsChanges bool `json:"settingsChanges,omitempty"`
 }
 
 // ExtendedDaemonSetStatusState type representing the ExtendedDaemonSet state
@@ -92,6 +322,10 @@ const (
 	ExtendedDaemonSetStatusStateCanaryPaused ExtendedDaemonSetStatusState = "Canary Paused"
 	// ExtendedDaemonSetStatusStateCanaryFailed the Canary deployment of the ExtendedDaemonSet is considered as Failing
 	ExtendedDaemonSetStatusStateCanaryFailed ExtendedDaemonSetStatusState = "Canary Failed"
+	// ExtendedDaemonSetStatusStateRollingUpdateFailed the rolling update of the ExtendedDaemonSet is halted by the failure policy
+	ExtendedDaemonSetStatusStateRollingUpdateFailed ExtendedDaemonSetStatusState = "Rolling Update Failed"
+	// ExtendedDaemonSetStatusStateRollingUpdatePaused the rolling update of the ExtendedDaemonSet is paused
+	ExtendedDaemonSetStatusStateRollingUpdatePaused ExtendedDaemonSetStatusState = "Rolling Update Paused"
 )
 
 // ExtendedDaemonSetStatusReason type represents the reason for a ExtendedDaemonSet status state
@@ -104,6 +338,12 @@ const (
 	ExtendedDaemonSetStatusReasonOOM ExtendedDaemonSetStatusReason = "OOMKilled"
 	// ExtendedDaemonSetStatusReasonUnknown represents an Unknown reason for the status state
 	ExtendedDaemonSetStatusReasonUnknown ExtendedDaemonSetStatusReason = "Unknown"
+	// ExtendedDaemonSetStatusReasonPodsUnavailable represents too many pods of the new version unavailable after the failure policy deadline
+	ExtendedDaemonSetStatusReasonPodsUnavailable ExtendedDaemonSetStatusReason = "PodsUnavailable"
+	// ExtendedDaemonSetStatusReasonPodsRestarting represents too many pods of the new version restarting more than the failure policy limit
+	ExtendedDaemonSetStatusReasonPodsRestarting ExtendedDaemonSetStatusReason = "PodsRestarting"
+	// ExtendedDaemonSetStatusReasonProgressDeadlineExceeded represents no additional pod available within the progress deadline
+	ExtendedDaemonSetStatusReasonProgressDeadlineExceeded ExtendedDaemonSetStatusReason = "ProgressDeadlineExceeded"
 )
 
 // ExtendedDaemonSetStatus defines the observed state of ExtendedDaemonSet
@@ -120,9 +360,217 @@ type ExtendedDaemonSetStatus struct {
 	ActiveReplicaSet string                         `json:"activeReplicaSet"`
 	Canary           *ExtendedDaemonSetStatusCanary `json:"canary,omitempty"`
 
-	// Reason provides an explanation for canary deployment autopause
+	// Reason provides an explanation for canary deployment autopause, or for the rolling update failure
 	// +optional
 	Reason ExtendedDaemonSetStatusReason `json:"reason,omitempty"`
+
+	// Conditions Represents the latest available observations of the ExtendedDaemonSet rollout.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []ExtendedDaemonSetCondition `json:"conditions,omitempty"`
+
+	// Rollout progress of the latest ExtendedDaemonSetReplicaSet deployment
+	// +optional
+	Rollout *ExtendedDaemonSetStatusRollout `json:"rollout,omitempty"`
+
+	// Hooks status of the lifecycle hooks of the latest rollout
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Hooks []ExtendedDaemonSetStatusHook `json:"hooks,omitempty"`
+
+	// History of the last rollout transitions, from the oldest to the newest
+	// +optional
+	// +listType=atomic
+	History []ExtendedDaemonSetStatusHistoryEntry `json:"history,omitempty"`
+
+	// NodesResourcesProfile the resources profile used on the Nodes, mirrored from the active ExtendedDaemonSetReplicaSet.
+	// A new ExtendedDaemonSetReplicaSet starts from these profiles.
+	// +optional
+	// +listType=map
+	// +listMapKey=nodeName
+	NodesResourcesProfile []ExtendedDaemonSetReplicaSetNodeResourcesProfile `json:"nodesResourcesProfile,omitempty"`
+
+	// CollisionCount count of hash collisions of the pod template. It is used to compute the template hash
+	// of a new ExtendedDaemonSetReplicaSet when it collides with the hash of an existing one.
+	// +optional
+	CollisionCount *int32 `json:"collisionCount,omitempty"`
+}
+
+// ExtendedDaemonSetConditionType type use to represent an ExtendedDaemonSet condition
+type ExtendedDaemonSetConditionType string
+
+const (
+	// ExtendedDaemonSetConditionTypeProgressing the ExtendedDaemonSetReplicaSet deployed is making progress,
+	// mirrored from the condition of the ExtendedDaemonSetReplicaSet
+	ExtendedDaemonSetConditionTypeProgressing ExtendedDaemonSetConditionType = "Progressing"
+)
+
+// ExtendedDaemonSetCondition describes the state of an ExtendedDaemonSet at a certain point.
+type ExtendedDaemonSetCondition struct {
+	// Type of ExtendedDaemonSet condition.
+	Type ExtendedDaemonSetConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Last time the condition was updated.
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// The reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human readable message indicating details about the transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ExtendedDaemonSetHookType type representing a lifecycle hook of an ExtendedDaemonSet rollout
+type ExtendedDaemonSetHookType string
+
+const (
+	// ExtendedDaemonSetHookTypePreCanary hook executed before the canary deployment
+	ExtendedDaemonSetHookTypePreCanary ExtendedDaemonSetHookType = "PreCanary"
+	// ExtendedDaemonSetHookTypeCanaryVerification hook executed on the canary Nodes
+	ExtendedDaemonSetHookTypeCanaryVerification ExtendedDaemonSetHookType = "CanaryVerification"
+	// ExtendedDaemonSetHookTypePreRollout hook executed before the rolling update
+	ExtendedDaemonSetHookTypePreRollout ExtendedDaemonSetHookType = "PreRollout"
+	// ExtendedDaemonSetHookTypePostRollout hook executed once the rollout is complete
+	ExtendedDaemonSetHookTypePostRollout ExtendedDaemonSetHookType = "PostRollout"
+)
+
+// ExtendedDaemonSetHookPhase type representing the phase of a lifecycle hook
+type ExtendedDaemonSetHookPhase string
+
+const (
+	// ExtendedDaemonSetHookPhaseRunning the hook Jobs are running
+	ExtendedDaemonSetHookPhaseRunning ExtendedDaemonSetHookPhase = "Running"
+	// ExtendedDaemonSetHookPhaseSucceeded all the hook Jobs succeeded
+	ExtendedDaemonSetHookPhaseSucceeded ExtendedDaemonSetHookPhase = "Succeeded"
+	// ExtendedDaemonSetHookPhaseFailed a hook Job failed
+	ExtendedDaemonSetHookPhaseFailed ExtendedDaemonSetHookPhase = "Failed"
+)
+
+// ExtendedDaemonSetStatusHook defines the observed state of a lifecycle hook of an ExtendedDaemonSet rollout
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetStatusHook struct {
+	// Type of the hook: PreCanary, CanaryVerification, PreRollout or PostRollout
+	Type ExtendedDaemonSetHookType `json:"type"`
+	// ReplicaSet name of the ExtendedDaemonSetReplicaSet deployed by the rollout
+	ReplicaSet string `json:"replicaSet"`
+	// Phase of the hook: Running, Succeeded or Failed
+	Phase ExtendedDaemonSetHookPhase `json:"phase"`
+	// Jobs names of the Jobs created for the hook
+	// +optional
+	// +listType=set
+	Jobs []string `json:"jobs,omitempty"`
+	// StartTime time the hook Jobs were created
+	StartTime metav1.Time `json:"startTime"`
+	// CompletionTime time the hook succeeded or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Message details about the hook failure
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ExtendedDaemonSetHistoryAction type representing a rollout transition recorded in the ExtendedDaemonSet history
+type ExtendedDaemonSetHistoryAction string
+
+const (
+	// ExtendedDaemonSetHistoryActionRolloutStarted the rollout of a new ExtendedDaemonSetReplicaSet started
+	ExtendedDaemonSetHistoryActionRolloutStarted ExtendedDaemonSetHistoryAction = "RolloutStarted"
+	// ExtendedDaemonSetHistoryActionCanaryStarted the canary deployment of a new ExtendedDaemonSetReplicaSet started
+	ExtendedDaemonSetHistoryActionCanaryStarted ExtendedDaemonSetHistoryAction = "CanaryStarted"
+	// ExtendedDaemonSetHistoryActionCanaryPaused the canary deployment was paused
+	ExtendedDaemonSetHistoryActionCanaryPaused ExtendedDaemonSetHistoryAction = "CanaryPaused"
+	// ExtendedDaemonSetHistoryActionCanaryUnpaused the canary deployment was unpaused
+	ExtendedDaemonSetHistoryActionCanaryUnpaused ExtendedDaemonSetHistoryAction = "CanaryUnpaused"
+	// ExtendedDaemonSetHistoryActionCanaryValidated the canary deployment was validated
+	ExtendedDaemonSetHistoryActionCanaryValidated ExtendedDaemonSetHistoryAction = "CanaryValidated"
+	// ExtendedDaemonSetHistoryActionCanaryFailed the canary deployment was set to failed
+	ExtendedDaemonSetHistoryActionCanaryFailed ExtendedDaemonSetHistoryAction = "CanaryFailed"
+	// ExtendedDaemonSetHistoryActionCanaryReset the failed status of the canary deployment was reset
+	ExtendedDaemonSetHistoryActionCanaryReset ExtendedDaemonSetHistoryAction = "CanaryReset"
+	// ExtendedDaemonSetHistoryActionRolloutComplete the new version is available on all the nodes
+	ExtendedDaemonSetHistoryActionRolloutComplete ExtendedDaemonSetHistoryAction = "RolloutComplete"
+	// ExtendedDaemonSetHistoryActionRollingUpdateFailed the rolling update was halted by the failure policy
+	ExtendedDaemonSetHistoryActionRollingUpdateFailed ExtendedDaemonSetHistoryAction = "RollingUpdateFailed"
+	// ExtendedDaemonSetHistoryActionRollingUpdatePaused the rolling update was paused
+	ExtendedDaemonSetHistoryActionRollingUpdatePaused ExtendedDaemonSetHistoryAction = "RollingUpdatePaused"
+	// ExtendedDaemonSetHistoryActionRollingUpdateResumed the paused rolling update was resumed
+	ExtendedDaemonSetHistoryActionRollingUpdateResumed ExtendedDaemonSetHistoryAction = "RollingUpdateResumed"
+	// ExtendedDaemonSetHistoryActionRolledBack the previous ExtendedDaemonSetReplicaSet is deployed again
+	ExtendedDaemonSetHistoryActionRolledBack ExtendedDaemonSetHistoryAction = "RolledBack"
+
+	// ExtendedDaemonSetHistoryActorController actor of the transitions done automatically by the controller
+	ExtendedDaemonSetHistoryActorController = "controller"
+)
+
+// ExtendedDaemonSetStatusHistoryEntry defines a rollout transition of an ExtendedDaemonSet
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetStatusHistoryEntry struct {
+	// Time of the transition
+	Time metav1.Time `json:"time"`
+	// Action the rollout transition
+	Action ExtendedDaemonSetHistoryAction `json:"action"`
+	// ActiveReplicaSet name of the active ExtendedDaemonSetReplicaSet
+	// +optional
+	ActiveReplicaSet string `json:"activeReplicaSet,omitempty"`
+	// CanaryReplicaSet name of the canary ExtendedDaemonSetReplicaSet
+	// +optional
+	CanaryReplicaSet string `json:"canaryReplicaSet,omitempty"`
+	// ReplicaSet name of the ExtendedDaemonSetReplicaSet deployed by the rollout, set on the RolloutStarted entries
+	// +optional
+	ReplicaSet string `json:"replicaSet,omitempty"`
+	// ChangeCause summary of the changes deployed by the rollout, set on the RolloutStarted entries
+	// +optional
+	ChangeCause string `json:"changeCause,omitempty"`
+	// Reason of the transition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Actor who requested the transition: a user, "controller" for the automatic transitions,
+	// or empty if unknown
+	// +optional
+	Actor string `json:"actor,omitempty"`
+}
+
+// ExtendedDaemonSetStatusRolloutPhase type representing the phase of an ExtendedDaemonSet rollout
+type ExtendedDaemonSetStatusRolloutPhase string
+
+const (
+	// ExtendedDaemonSetStatusRolloutPhaseCanary the new version is deployed on the canary nodes
+	ExtendedDaemonSetStatusRolloutPhaseCanary ExtendedDaemonSetStatusRolloutPhase = "Canary"
+	// ExtendedDaemonSetStatusRolloutPhaseRolling the new version is deployed on all the nodes with the rolling update strategy
+	ExtendedDaemonSetStatusRolloutPhaseRolling ExtendedDaemonSetStatusRolloutPhase = "Rolling"
+	// ExtendedDaemonSetStatusRolloutPhaseComplete the new version is available on all the nodes
+	ExtendedDaemonSetStatusRolloutPhaseComplete ExtendedDaemonSetStatusRolloutPhase = "Complete"
+)
+
+// ExtendedDaemonSetStatusRollout defines the observed progress of an ExtendedDaemonSet rollout
+// +k8s:openapi-gen=true
+type ExtendedDaemonSetStatusRollout struct {
+	// ReplicaSet name of the ExtendedDaemonSetReplicaSet deployed
+	ReplicaSet string `json:"replicaSet"`
+	// PreviousReplicaSet name of the ExtendedDaemonSetReplicaSet active before the rollout,
+	// used by the rolling update failure policy to roll back
+	// +optional
+	PreviousReplicaSet string `json:"previousReplicaSet,omitempty"`
+	// Phase of the rollout: Canary, Rolling or Complete
+	Phase ExtendedDaemonSetStatusRolloutPhase `json:"phase"`
+	// StartTime time the rollout started
+	StartTime metav1.Time `json:"startTime"`
+	// CompletionTime time the new version became available on all the nodes
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// PercentComplete percentage of the nodes running an available pod of the new version
+	PercentComplete int32 `json:"percentComplete"`
+	// EstimatedCompletionTime estimated end of the Rolling phase, computed from the slow start parameters
+	// and the observed pod readiness rate. Only updated when it moves by more than a slow start interval
+	// +optional
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
 }
 
 // ExtendedDaemonSetStatusCanary defines the observed state of ExtendedDaemonSet canary deployment
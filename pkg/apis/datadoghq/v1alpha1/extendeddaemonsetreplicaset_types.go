@@ -51,6 +51,58 @@ type ExtendedDaemonSetReplicaSetStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []ExtendedDaemonSetReplicaSetCondition `json:"conditions,omitempty"`
+
+	// NodesResourcesProfile the resources profile used on the Nodes where the pod doesn't fit with the default resources.
+	// +listType=map
+	// +listMapKey=nodeName
+	NodesResourcesProfile []ExtendedDaemonSetReplicaSetNodeResourcesProfile `json:"nodesResourcesProfile,omitempty"`
+
+	// SettingsCanary the canary deployment of the last ExtendedDaemonsetSettings and Node resources overwrite changes.
+	// +optional
+	SettingsCanary *ExtendedDaemonSetReplicaSetSettingsCanary `json:"settingsCanary,omitempty"`
+}
+
+// ExtendedDaemonSetReplicaSetSettingsCanaryState type representing the state of a settings canary deployment
+type ExtendedDaemonSetReplicaSetSettingsCanaryState string
+
+const (
+	// ExtendedDaemonSetReplicaSetSettingsCanaryStateRunning the settings change is deployed only on the canary Nodes
+	ExtendedDaemonSetReplicaSetSettingsCanaryStateRunning ExtendedDaemonSetReplicaSetSettingsCanaryState = "Running"
+	// ExtendedDaemonSetReplicaSetSettingsCanaryStatePaused the settings canary deployment is paused
+	ExtendedDaemonSetReplicaSetSettingsCanaryStatePaused ExtendedDaemonSetReplicaSetSettingsCanaryState = "Paused"
+	// ExtendedDaemonSetReplicaSetSettingsCanaryStateFailed the settings canary deployment failed, the settings change is not deployed on the other Nodes
+	ExtendedDaemonSetReplicaSetSettingsCanaryStateFailed ExtendedDaemonSetReplicaSetSettingsCanaryState = "Failed"
+	// ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated the settings canary deployment is validated, the settings change is deployed on all the Nodes
+	ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated ExtendedDaemonSetReplicaSetSettingsCanaryState = "Validated"
+)
+
+// ExtendedDaemonSetReplicaSetSettingsCanary describes the canary deployment of a settings change.
+type ExtendedDaemonSetReplicaSetSettingsCanary struct {
+	// Hash of the settings deployed, it is the value expected by the canary-valid annotation.
+	Hash string `json:"hash"`
+	// State of the settings canary deployment.
+	State ExtendedDaemonSetReplicaSetSettingsCanaryState `json:"state"`
+	// Nodes where the settings change is deployed first.
+	// +listType=set
+	Nodes []string `json:"nodes,omitempty"`
+	// StartTime of the settings canary deployment.
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// ExtendedDaemonSetReplicaSetNodeResourcesProfile describes the resources profile used on a Node.
+type ExtendedDaemonSetReplicaSetNodeResourcesProfile struct {
+	// NodeName name of the Node.
+	NodeName string `json:"nodeName"`
+	// Profile name of the resources profile, empty for the default resources restored by a retry.
+	Profile string `json:"profile"`
+	// Last time the profile changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// Retry true if the profile has been selected by a retry of the previous profile, until the pod created with this profile runs.
+	// +optional
+	Retry bool `json:"retry,omitempty"`
+	// FailedRetries number of retries of the previous profile that failed.
+	// +optional
+	FailedRetries int32 `json:"failedRetries,omitempty"`
 }
 
 // ExtendedDaemonSetReplicaSetCondition describes the state of a ExtendedDaemonSetReplicaSet at a certain point.
@@ -89,10 +141,18 @@ const (
 	ConditionTypePodsCleanupDone ExtendedDaemonSetReplicaSetConditionType = "PodsCleanupDone"
 	// ConditionTypePodCreation Pod(s) creation condition
 	ConditionTypePodCreation ExtendedDaemonSetReplicaSetConditionType = "PodCreation"
+	// ConditionTypePodCreationFailed some pods couldn't be created, the reason is the most frequent failure: QuotaExceeded, Forbidden, Invalid, PodTemplateError...
+	ConditionTypePodCreationFailed ExtendedDaemonSetReplicaSetConditionType = "PodCreationFailed"
 	// ConditionTypePodDeletion Pod(s) deletion condition
 	ConditionTypePodDeletion ExtendedDaemonSetReplicaSetConditionType = "PodDeletion"
 	// ConditionTypeLastFullSync last time the ExtendedDaemonSetReplicaSet sync when to the end of the reconcile function
 	ConditionTypeLastFullSync ExtendedDaemonSetReplicaSetConditionType = "LastFullSync"
+	// ConditionTypeRollingUpdateFailed the rolling update of the ExtendedDaemonSetReplicaSet was halted by the failure policy
+	ConditionTypeRollingUpdateFailed ExtendedDaemonSetReplicaSetConditionType = "RollingUpdateFailed"
+	// ConditionTypeRollingUpdatePaused the rolling update of the ExtendedDaemonSetReplicaSet is paused until it is resumed
+	ConditionTypeRollingUpdatePaused ExtendedDaemonSetReplicaSetConditionType = "RollingUpdatePaused"
+	// ConditionTypeProgressing an additional pod of the ExtendedDaemonSetReplicaSet became available within the progress deadline
+	ConditionTypeProgressing ExtendedDaemonSetReplicaSetConditionType = "Progressing"
 )
 
 // +genclient
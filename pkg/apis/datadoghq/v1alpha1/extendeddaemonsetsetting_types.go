@@ -8,6 +8,7 @@ package v1alpha1
 import (
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -29,8 +30,60 @@ type ExtendedDaemonsetSettingSpec struct {
 type ExtendedDaemonsetSettingContainerSpec struct {
 	Name      string                      `json:"name"`
 	Resources corev1.ResourceRequirements `json:"resources"`
+	// ResourcesFormulas used to compute the container resources from the Node properties.
+	// A computed value takes precedence over the value defined in Resources.
+	// +optional
+	ResourcesFormulas *ExtendedDaemonsetSettingResourcesFormulas `json:"resourcesFormulas,omitempty"`
 }
 
+// ExtendedDaemonsetSettingResourcesFormulas defines the formulas used to compute the container resources requests and limits.
+type ExtendedDaemonsetSettingResourcesFormulas struct {
+	// Requests formulas, one per resource name.
+	// +listType=map
+	// +listMapKey=name
+	Requests []ExtendedDaemonsetSettingResourceFormula `json:"requests,omitempty"`
+	// Limits formulas, one per resource name.
+	// +listType=map
+	// +listMapKey=name
+	Limits []ExtendedDaemonsetSettingResourceFormula `json:"limits,omitempty"`
+}
+
+// ExtendedDaemonsetSettingResourceFormula defines how a resource quantity is computed from the Node properties:
+// base + perCPU * (Node cpu) + perMaxPods * (Node max pods), clamped between min and max.
+type ExtendedDaemonsetSettingResourceFormula struct {
+	// Name of the resource (cpu, memory, ...).
+	Name corev1.ResourceName `json:"name"`
+	// Base quantity.
+	// +optional
+	Base *resource.Quantity `json:"base,omitempty"`
+	// PerCPU quantity added for each cpu of the Node.
+	// +optional
+	PerCPU *resource.Quantity `json:"perCPU,omitempty"`
+	// PerMaxPods quantity added for each pod that can be scheduled on the Node.
+	// +optional
+	PerMaxPods *resource.Quantity `json:"perMaxPods,omitempty"`
+	// Min is the lower bound of the computed quantity.
+	// +optional
+	Min *resource.Quantity `json:"min,omitempty"`
+	// Max is the upper bound of the computed quantity.
+	// +optional
+	Max *resource.Quantity `json:"max,omitempty"`
+	// NodeResourceSource is the Node status field used to retrieve the Node cpu and max pods: Allocatable or Capacity.
+	// Default value is Allocatable.
+	// +optional
+	NodeResourceSource NodeResourceSource `json:"nodeResourceSource,omitempty"`
+}
+
+// NodeResourceSource defines which Node status field is used to evaluate a resource formula
+type NodeResourceSource string
+
+const (
+	// NodeResourceSourceAllocatable uses the Node status allocatable resources
+	NodeResourceSourceAllocatable NodeResourceSource = "Allocatable"
+	// NodeResourceSourceCapacity uses the Node status capacity resources
+	NodeResourceSourceCapacity NodeResourceSource = "Capacity"
+)
+
 // ExtendedDaemonsetSettingStatusStatus defines the readable status in ExtendedDaemonsetSettingStatus
 type ExtendedDaemonsetSettingStatusStatus string
 
@@ -45,6 +98,8 @@ const (
 type ExtendedDaemonsetSettingStatus struct {
 	Status ExtendedDaemonsetSettingStatusStatus `json:"status"`
 	Error  string                               `json:"error,omitempty"`
+	// MatchingNodes number of Nodes selected by the NodeSelector
+	MatchingNodes int32 `json:"matchingNodes,omitempty"`
 }
 
 // +genclient
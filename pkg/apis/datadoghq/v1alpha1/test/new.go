@@ -137,6 +137,7 @@ type NewExtendedDaemonsetSettingOptions struct {
 	CreationTime time.Time
 	Selector     map[string]string
 	Resources    map[string]corev1.ResourceRequirements
+	Formulas     map[string]*datadoghqv1alpha1.ExtendedDaemonsetSettingResourcesFormulas
 }
 
 // NewExtendedDaemonsetSetting returns new ExtendedDaemonsetSetting instance
@@ -159,6 +160,20 @@ func NewExtendedDaemonsetSetting(ns, name, reference string, options *NewExtende
 		for key, val := range options.Resources {
 			edsNode.Spec.Containers = append(edsNode.Spec.Containers, datadoghqv1alpha1.ExtendedDaemonsetSettingContainerSpec{Name: key, Resources: val})
 		}
+
+		for key, val := range options.Formulas {
+			found := false
+			for id := range edsNode.Spec.Containers {
+				if edsNode.Spec.Containers[id].Name == key {
+					edsNode.Spec.Containers[id].ResourcesFormulas = val
+					found = true
+					break
+				}
+			}
+			if !found {
+				edsNode.Spec.Containers = append(edsNode.Spec.Containers, datadoghqv1alpha1.ExtendedDaemonsetSettingContainerSpec{Name: key, ResourcesFormulas: val})
+			}
+		}
 	}
 	return edsNode
 }
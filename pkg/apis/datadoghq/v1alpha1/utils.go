@@ -9,3 +9,19 @@ package v1alpha1
 func NewInt32(i int32) *int32 {
 	return &i
 }
+
+// SetExtendedDaemonSetActionAnnotations stamps the action requested on the ExtendedDaemonSet with the replicaset it targets:
+// the canary replicaset, or the active one. The actor and the reason are used by the controller to fill the status history.
+func SetExtendedDaemonSetActionAnnotations(eds *ExtendedDaemonSet, action ExtendedDaemonSetHistoryAction, actor, reason string) {
+	if eds.Annotations == nil {
+		eds.Annotations = make(map[string]string)
+	}
+	replicaSet := eds.Status.ActiveReplicaSet
+	if eds.Status.Canary != nil {
+		replicaSet = eds.Status.Canary.ReplicaSet
+	}
+	eds.Annotations[ExtendedDaemonSetActionAnnotationKey] = string(action)
+	eds.Annotations[ExtendedDaemonSetActionReplicaSetAnnotationKey] = replicaSet
+	eds.Annotations[ExtendedDaemonSetActionActorAnnotationKey] = actor
+	eds.Annotations[ExtendedDaemonSetActionReasonAnnotationKey] = reason
+}
@@ -11,6 +11,7 @@ package v1alpha1
 
 import (
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	intstr "k8s.io/apimachinery/pkg/util/intstr"
@@ -44,6 +45,68 @@ func (in *ExtendedDaemonSet) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetCondition) DeepCopyInto(out *ExtendedDaemonSetCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetCondition.
+func (in *ExtendedDaemonSetCondition) DeepCopy() *ExtendedDaemonSetCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetDefaults) DeepCopyInto(out *ExtendedDaemonSetDefaults) {
+	*out = *in
+	out.CanaryReplicas = in.CanaryReplicas
+	out.CanaryDuration = in.CanaryDuration
+	out.MaxUnavailable = in.MaxUnavailable
+	out.MaxPodSchedulerFailure = in.MaxPodSchedulerFailure
+	out.SlowStartIntervalDuration = in.SlowStartIntervalDuration
+	out.SlowStartAdditiveIncrease = in.SlowStartAdditiveIncrease
+	out.ReconcileFrequency = in.ReconcileFrequency
+	out.MaxFailedPods = in.MaxFailedPods
+	out.UnavailableDeadline = in.UnavailableDeadline
+	out.UnschedulableDuration = in.UnschedulableDuration
+	out.ProfileRetryInterval = in.ProfileRetryInterval
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetDefaults.
+func (in *ExtendedDaemonSetDefaults) DeepCopy() *ExtendedDaemonSetDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetHook) DeepCopyInto(out *ExtendedDaemonSetHook) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetHook.
+func (in *ExtendedDaemonSetHook) DeepCopy() *ExtendedDaemonSetHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedDaemonSetList) DeepCopyInto(out *ExtendedDaemonSetList) {
 	*out = *in
@@ -156,6 +219,45 @@ func (in *ExtendedDaemonSetReplicaSetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetReplicaSetNodeResourcesProfile) DeepCopyInto(out *ExtendedDaemonSetReplicaSetNodeResourcesProfile) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetReplicaSetNodeResourcesProfile.
+func (in *ExtendedDaemonSetReplicaSetNodeResourcesProfile) DeepCopy() *ExtendedDaemonSetReplicaSetNodeResourcesProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetReplicaSetNodeResourcesProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetReplicaSetSettingsCanary) DeepCopyInto(out *ExtendedDaemonSetReplicaSetSettingsCanary) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetReplicaSetSettingsCanary.
+func (in *ExtendedDaemonSetReplicaSetSettingsCanary) DeepCopy() *ExtendedDaemonSetReplicaSetSettingsCanary {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetReplicaSetSettingsCanary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedDaemonSetReplicaSetSpec) DeepCopyInto(out *ExtendedDaemonSetReplicaSetSpec) {
 	*out = *in
@@ -206,6 +308,18 @@ func (in *ExtendedDaemonSetReplicaSetStatus) DeepCopyInto(out *ExtendedDaemonSet
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.NodesResourcesProfile != nil {
+		in, out := &in.NodesResourcesProfile, &out.NodesResourcesProfile
+		*out = make([]ExtendedDaemonSetReplicaSetNodeResourcesProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SettingsCanary != nil {
+		in, out := &in.SettingsCanary, &out.SettingsCanary
+		*out = new(ExtendedDaemonSetReplicaSetSettingsCanary)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -219,6 +333,29 @@ func (in *ExtendedDaemonSetReplicaSetStatus) DeepCopy() *ExtendedDaemonSetReplic
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetResourcesProfile) DeepCopyInto(out *ExtendedDaemonSetResourcesProfile) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ExtendedDaemonsetSettingContainerSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetResourcesProfile.
+func (in *ExtendedDaemonSetResourcesProfile) DeepCopy() *ExtendedDaemonSetResourcesProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetResourcesProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedDaemonSetSpec) DeepCopyInto(out *ExtendedDaemonSetSpec) {
 	*out = *in
@@ -229,6 +366,23 @@ func (in *ExtendedDaemonSetSpec) DeepCopyInto(out *ExtendedDaemonSetSpec) {
 	}
 	in.Template.DeepCopyInto(&out.Template)
 	in.Strategy.DeepCopyInto(&out.Strategy)
+	if in.OOMKilledPolicy != nil {
+		in, out := &in.OOMKilledPolicy, &out.OOMKilledPolicy
+		*out = new(ExtendedDaemonSetSpecOOMKilledPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourcesProfiles != nil {
+		in, out := &in.ResourcesProfiles, &out.ResourcesProfiles
+		*out = new(ExtendedDaemonSetSpecResourcesProfiles)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make([]ExtendedDaemonSetSpecNotification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -242,6 +396,91 @@ func (in *ExtendedDaemonSetSpec) DeepCopy() *ExtendedDaemonSetSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetSpecNotification) DeepCopyInto(out *ExtendedDaemonSetSpecNotification) {
+	*out = *in
+	if in.URLSecretRef != nil {
+		in, out := &in.URLSecretRef, &out.URLSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetSpecNotification.
+func (in *ExtendedDaemonSetSpecNotification) DeepCopy() *ExtendedDaemonSetSpecNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetSpecNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetSpecOOMKilledPolicy) DeepCopyInto(out *ExtendedDaemonSetSpecOOMKilledPolicy) {
+	*out = *in
+	if in.MinRestartCount != nil {
+		in, out := &in.MinRestartCount, &out.MinRestartCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MemoryIncreasePercent != nil {
+		in, out := &in.MemoryIncreasePercent, &out.MemoryIncreasePercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxMemory != nil {
+		in, out := &in.MaxMemory, &out.MaxMemory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetSpecOOMKilledPolicy.
+func (in *ExtendedDaemonSetSpecOOMKilledPolicy) DeepCopy() *ExtendedDaemonSetSpecOOMKilledPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetSpecOOMKilledPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetSpecResourcesProfiles) DeepCopyInto(out *ExtendedDaemonSetSpecResourcesProfiles) {
+	*out = *in
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make([]ExtendedDaemonSetResourcesProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UnschedulableDuration != nil {
+		in, out := &in.UnschedulableDuration, &out.UnschedulableDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RetryInterval != nil {
+		in, out := &in.RetryInterval, &out.RetryInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetSpecResourcesProfiles.
+func (in *ExtendedDaemonSetSpecResourcesProfiles) DeepCopy() *ExtendedDaemonSetSpecResourcesProfiles {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetSpecResourcesProfiles)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedDaemonSetSpecStrategy) DeepCopyInto(out *ExtendedDaemonSetSpecStrategy) {
 	*out = *in
@@ -256,6 +495,16 @@ func (in *ExtendedDaemonSetSpecStrategy) DeepCopyInto(out *ExtendedDaemonSetSpec
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.ProgressDeadlineSeconds != nil {
+		in, out := &in.ProgressDeadlineSeconds, &out.ProgressDeadlineSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(ExtendedDaemonSetSpecStrategyHooks)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -305,6 +554,42 @@ func (in *ExtendedDaemonSetSpecStrategyCanary) DeepCopy() *ExtendedDaemonSetSpec
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetSpecStrategyHooks) DeepCopyInto(out *ExtendedDaemonSetSpecStrategyHooks) {
+	*out = *in
+	if in.PreCanary != nil {
+		in, out := &in.PreCanary, &out.PreCanary
+		*out = new(ExtendedDaemonSetHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CanaryVerification != nil {
+		in, out := &in.CanaryVerification, &out.CanaryVerification
+		*out = new(ExtendedDaemonSetHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreRollout != nil {
+		in, out := &in.PreRollout, &out.PreRollout
+		*out = new(ExtendedDaemonSetHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostRollout != nil {
+		in, out := &in.PostRollout, &out.PostRollout
+		*out = new(ExtendedDaemonSetHook)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetSpecStrategyHooks.
+func (in *ExtendedDaemonSetSpecStrategyHooks) DeepCopy() *ExtendedDaemonSetSpecStrategyHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetSpecStrategyHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedDaemonSetSpecStrategyRollingUpdate) DeepCopyInto(out *ExtendedDaemonSetSpecStrategyRollingUpdate) {
 	*out = *in
@@ -333,6 +618,16 @@ func (in *ExtendedDaemonSetSpecStrategyRollingUpdate) DeepCopyInto(out *Extended
 		*out = new(intstr.IntOrString)
 		**out = **in
 	}
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
 	return
 }
 
@@ -346,6 +641,37 @@ func (in *ExtendedDaemonSetSpecStrategyRollingUpdate) DeepCopy() *ExtendedDaemon
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy) DeepCopyInto(out *ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy) {
+	*out = *in
+	if in.MaxFailedPods != nil {
+		in, out := &in.MaxFailedPods, &out.MaxFailedPods
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.UnavailableDeadline != nil {
+		in, out := &in.UnavailableDeadline, &out.UnavailableDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxRestarts != nil {
+		in, out := &in.MaxRestarts, &out.MaxRestarts
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy.
+func (in *ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy) DeepCopy() *ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedDaemonSetStatus) DeepCopyInto(out *ExtendedDaemonSetStatus) {
 	*out = *in
@@ -354,6 +680,44 @@ func (in *ExtendedDaemonSetStatus) DeepCopyInto(out *ExtendedDaemonSetStatus) {
 		*out = new(ExtendedDaemonSetStatusCanary)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ExtendedDaemonSetCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(ExtendedDaemonSetStatusRollout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]ExtendedDaemonSetStatusHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]ExtendedDaemonSetStatusHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodesResourcesProfile != nil {
+		in, out := &in.NodesResourcesProfile, &out.NodesResourcesProfile
+		*out = make([]ExtendedDaemonSetReplicaSetNodeResourcesProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CollisionCount != nil {
+		in, out := &in.CollisionCount, &out.CollisionCount
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -388,6 +752,74 @@ func (in *ExtendedDaemonSetStatusCanary) DeepCopy() *ExtendedDaemonSetStatusCana
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetStatusHistoryEntry) DeepCopyInto(out *ExtendedDaemonSetStatusHistoryEntry) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetStatusHistoryEntry.
+func (in *ExtendedDaemonSetStatusHistoryEntry) DeepCopy() *ExtendedDaemonSetStatusHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetStatusHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetStatusHook) DeepCopyInto(out *ExtendedDaemonSetStatusHook) {
+	*out = *in
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetStatusHook.
+func (in *ExtendedDaemonSetStatusHook) DeepCopy() *ExtendedDaemonSetStatusHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetStatusHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonSetStatusRollout) DeepCopyInto(out *ExtendedDaemonSetStatusRollout) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonSetStatusRollout.
+func (in *ExtendedDaemonSetStatusRollout) DeepCopy() *ExtendedDaemonSetStatusRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonSetStatusRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedDaemonsetSetting) DeepCopyInto(out *ExtendedDaemonsetSetting) {
 	*out = *in
@@ -420,6 +852,11 @@ func (in *ExtendedDaemonsetSetting) DeepCopyObject() runtime.Object {
 func (in *ExtendedDaemonsetSettingContainerSpec) DeepCopyInto(out *ExtendedDaemonsetSettingContainerSpec) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.ResourcesFormulas != nil {
+		in, out := &in.ResourcesFormulas, &out.ResourcesFormulas
+		*out = new(ExtendedDaemonsetSettingResourcesFormulas)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -466,6 +903,77 @@ func (in *ExtendedDaemonsetSettingList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonsetSettingResourceFormula) DeepCopyInto(out *ExtendedDaemonsetSettingResourceFormula) {
+	*out = *in
+	if in.Base != nil {
+		in, out := &in.Base, &out.Base
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.PerCPU != nil {
+		in, out := &in.PerCPU, &out.PerCPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.PerMaxPods != nil {
+		in, out := &in.PerMaxPods, &out.PerMaxPods
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonsetSettingResourceFormula.
+func (in *ExtendedDaemonsetSettingResourceFormula) DeepCopy() *ExtendedDaemonsetSettingResourceFormula {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonsetSettingResourceFormula)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtendedDaemonsetSettingResourcesFormulas) DeepCopyInto(out *ExtendedDaemonsetSettingResourcesFormulas) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make([]ExtendedDaemonsetSettingResourceFormula, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make([]ExtendedDaemonsetSettingResourceFormula, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedDaemonsetSettingResourcesFormulas.
+func (in *ExtendedDaemonsetSettingResourcesFormulas) DeepCopy() *ExtendedDaemonsetSettingResourcesFormulas {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtendedDaemonsetSettingResourcesFormulas)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedDaemonsetSettingSpec) DeepCopyInto(out *ExtendedDaemonsetSettingSpec) {
 	*out = *in
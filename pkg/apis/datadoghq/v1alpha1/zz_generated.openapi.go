@@ -18,19 +18,29 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSet":                          schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSet(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetList":                      schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetList(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSet":                schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSet(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetSpec":            schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSetSpec(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetSpecStrategy":    schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSetSpecStrategy(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetStatus":          schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSetStatus(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpec":                      schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpec(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategy":              schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategy(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyCanary":        schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyCanary(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate": schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyRollingUpdate(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatus":                    schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatus(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusCanary":              schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusCanary(ref),
-		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonsetSettingSpec":               schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonsetSettingSpec(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSet":                                       schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSet(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetHook":                                   schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetHook(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetList":                                   schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetList(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSet":                             schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSet(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetSpec":                         schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSetSpec(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetSpecStrategy":                 schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSetSpecStrategy(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetStatus":                       schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSetStatus(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetResourcesProfile":                       schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetResourcesProfile(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpec":                                   schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpec(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecNotification":                       schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecNotification(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecOOMKilledPolicy":                    schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecOOMKilledPolicy(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecResourcesProfiles":                  schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecResourcesProfiles(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategy":                           schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategy(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyCanary":                     schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyCanary(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyHooks":                      schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyHooks(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate":              schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyRollingUpdate(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy": schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatus":                                 schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatus(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusCanary":                           schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusCanary(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusHistoryEntry":                     schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusHistoryEntry(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusHook":                             schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusHook(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusRollout":                          schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusRollout(ref),
+		"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonsetSettingSpec":                            schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonsetSettingSpec(ref),
 	}
 }
 
@@ -78,6 +88,35 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSet(ref common.ReferenceCa
 	}
 }
 
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetHook(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetHook defines a lifecycle hook of an ExtendedDaemonSet rollout",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"template": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Template of the Job created for the hook.",
+							Ref:         ref("k8s.io/api/batch/v1beta1.JobTemplateSpec"),
+						},
+					},
+					"failurePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailurePolicy what happens when a Job of the hook fails: Abort or Ignore. Default value is Abort.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"template"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/batch/v1beta1.JobTemplateSpec"},
+	}
+}
+
 func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetList(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -298,12 +337,83 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetReplicaSetStatus(ref co
 							},
 						},
 					},
+					"nodesResourcesProfile": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"nodeName",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "NodesResourcesProfile the resources profile used on the Nodes where the pod doesn't fit with the default resources.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile"),
+									},
+								},
+							},
+						},
+					},
+					"settingsCanary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SettingsCanary the canary deployment of the last ExtendedDaemonsetSettings and Node resources overwrite changes.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary"),
+						},
+					},
 				},
 				Required: []string{"status", "desired", "current", "ready", "available", "ignoredUnresponsiveNodes"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetCondition"},
+			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetCondition", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary"},
+	}
+}
+
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetResourcesProfile(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetResourcesProfile defines a containers resources override",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the profile.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"containers": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Containers contains a list of container spec override.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonsetSettingContainerSpec"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"name", "containers"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonsetSettingContainerSpec"},
 	}
 }
 
@@ -332,12 +442,170 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpec(ref common.Referen
 							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategy"),
 						},
 					},
+					"oomKilledPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "OOMKilledPolicy configures the automatic memory increase of a container that is repeatedly OOMKilled on a Node. Disabled if not set.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecOOMKilledPolicy"),
+						},
+					},
+					"resourcesProfiles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourcesProfiles configures the fallback container resources used on the Nodes where the pod doesn't fit due to resource constraints.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecResourcesProfiles"),
+						},
+					},
+					"notifications": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Notifications list of the sinks notified of the ExtendedDaemonSet state transitions, in addition to the sinks configured on the controller.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecNotification"),
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"template", "strategy"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategy", "k8s.io/api/core/v1.PodTemplateSpec", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecNotification", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecOOMKilledPolicy", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecResourcesProfiles", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategy", "k8s.io/api/core/v1.PodTemplateSpec", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+	}
+}
+
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecNotification(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetSpecNotification defines a sink notified of the ExtendedDaemonSet state transitions",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the sink: Webhook or Slack",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL of the webhook",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"urlSecretRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URLSecretRef reference to the Secret key containing the URL of the webhook. Used when URL is empty.",
+							Ref:         ref("k8s.io/api/core/v1.SecretKeySelector"),
+						},
+					},
+					"template": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Template go template of the message, executed with the notification event. Default value is \"ExtendedDaemonSet {{ .Namespace }}/{{ .Name }}: {{ .Summary }}\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.SecretKeySelector"},
+	}
+}
+
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecOOMKilledPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetSpecOOMKilledPolicy defines how the memory of OOMKilled containers is increased. The new resources are stored in the Node resources annotation, and the pod is replaced with the rolling update strategy.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"minRestartCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MinRestartCount the minimum number of restarts of a container, with its last termination due to OOMKilled, before increasing its memory. Default value is 3.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"memoryIncreasePercent": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MemoryIncreasePercent the percentage added to the container memory request and limit. Default value is 50.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxMemory": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxMemory the maximum value of the container memory limit, should be greater than 0.",
+							Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+						},
+					},
+				},
+				Required: []string{"maxMemory"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/api/resource.Quantity"},
+	}
+}
+
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecResourcesProfiles(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetSpecResourcesProfiles defines the fallback resources profiles of ExtendedDaemonSet. When a pod stays unschedulable on a Node due to resource constraints, it is recreated on this Node with the next profile of the list.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"profiles": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Profiles ordered list of resources profiles, from the largest to the smallest.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetResourcesProfile"),
+									},
+								},
+							},
+						},
+					},
+					"unschedulableDuration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UnschedulableDuration the duration a pod stays unschedulable on a Node due to resource constraints before being recreated with the next profile. Default value is 5min.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"retryInterval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RetryInterval the duration a Node uses a profile before trying again the previous (larger) profile. Default value is 1h.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+				},
+				Required: []string{"profiles"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetResourcesProfile", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
@@ -365,11 +633,31 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategy(ref common
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
 						},
 					},
+					"progressDeadlineSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ProgressDeadlineSeconds the maximum duration in seconds without an additional pod of the deployed ExtendedDaemonSetReplicaSet becoming available. Once exceeded, the Progressing condition is set to False with the ProgressDeadlineExceeded reason. Disabled if not set.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"progressDeadlineAction": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ProgressDeadlineAction the action executed when the progress deadline is exceeded: None, Pause or Rollback. Default value is None.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"hooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Hooks Jobs executed around the rollout of a new ExtendedDaemonSetReplicaSet.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyHooks"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyCanary", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyCanary", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyHooks", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
@@ -413,6 +701,13 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyCanary(ref
 							},
 						},
 					},
+					"settingsChanges": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SettingsChanges if true, the changes of the ExtendedDaemonsetSettings and of the Node resources overwrite annotations are first deployed on a canary subset of the Nodes they apply to, with the same pause/validate/fail controls.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -421,6 +716,45 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyCanary(ref
 	}
 }
 
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyHooks(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetSpecStrategyHooks defines the lifecycle hooks of an ExtendedDaemonSet rollout. Each hook creates Jobs from its template, the rollout continues only once they succeeded.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"preCanary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreCanary Job executed before the canary pods are created.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetHook"),
+						},
+					},
+					"canaryVerification": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CanaryVerification Job executed on each canary Node once the canary pods are available. The canary deployment is validated only once all the Jobs succeeded.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetHook"),
+						},
+					},
+					"preRollout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreRollout Job executed before the new version is deployed on all the Nodes.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetHook"),
+						},
+					},
+					"postRollout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PostRollout Job executed once the new version is available on all the Nodes.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetHook"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetHook"},
+	}
+}
+
 func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyRollingUpdate(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -459,31 +793,106 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyRollingUpda
 							Ref:         ref("k8s.io/apimachinery/pkg/util/intstr.IntOrString"),
 						},
 					},
-				},
-			},
-		},
-		Dependencies: []string{
-			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration", "k8s.io/apimachinery/pkg/util/intstr.IntOrString"},
-	}
-}
-
-func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
-	return common.OpenAPIDefinition{
-		Schema: spec.Schema{
-			SchemaProps: spec.SchemaProps{
-				Description: "ExtendedDaemonSetStatus defines the observed state of ExtendedDaemonSet",
-				Type:        []string{"object"},
-				Properties: map[string]spec.Schema{
-					"desired": {
+					"failurePolicy": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"integer"},
-							Format: "int32",
+							Description: "FailurePolicy configures the detection of a failing rolling update, after the canary phase. Disabled if not set.",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy"),
 						},
 					},
-					"current": {
+					"deletionOrder": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"integer"},
-							Format: "int32",
+							Description: "DeletionOrder the order the pods of the previous version are replaced: OldestPod or NodeLabel. The pods already unavailable or in CrashLoopBackOff are always replaced first, they don't consume MaxUnavailable. Default value is OldestPod.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"deletionOrderNodeLabelKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeletionOrderNodeLabelKey the Node label used by the NodeLabel deletion order: the pods are replaced by ascending label value, compared as numbers when possible. The Nodes without the label come last.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"deletionMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeletionMode how the pods of the previous version and the pods cleaned up are removed: Delete or Evict. With Evict, the pods are removed with the Eviction API that honours the PodDisruptionBudgets, the evictions rejected by a PodDisruptionBudget are retried later. Default value is Delete.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"terminationGracePeriodSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TerminationGracePeriodSeconds overrides the termination grace period of the pods of the previous version and of the pods cleaned up. If not set, the pod termination grace period is used.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration", "k8s.io/apimachinery/pkg/util/intstr.IntOrString"},
+	}
+}
+
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy defines when a rolling update is considered as failed. A failed rolling update is halted: the remaining pods are not replaced.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"maxFailedPods": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxFailedPods the maximum number of failed pods of the new version before the rolling update is considered as failed. A pod is failed if it is not available after UnavailableDeadline, or if its containers restarted more than MaxRestarts times. Value can be an absolute number (ex: 5) or a percentage of total number of DaemonSet pods (ex: 10%). Absolute number is calculated from percentage by rounding up. Default value is 10%.",
+							Ref:         ref("k8s.io/apimachinery/pkg/util/intstr.IntOrString"),
+						},
+					},
+					"unavailableDeadline": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UnavailableDeadline the duration after its creation a pod of the new version can stay unavailable. Default value is 10min.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"maxRestarts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxRestarts the maximum number of restarts of the containers of a pod of the new version. Default value is 5.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"rollback": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rollback if true, the ExtendedDaemonSet template is reverted to the template of the previously active ExtendedDaemonSetReplicaSet when the rolling update fails. The previous version is then deployed with the rolling update strategy, without canary phase.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration", "k8s.io/apimachinery/pkg/util/intstr.IntOrString"},
+	}
+}
+
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetStatus defines the observed state of ExtendedDaemonSet",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"desired": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"current": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
 						},
 					},
 					"ready": {
@@ -529,17 +938,111 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatus(ref common.Refer
 					},
 					"reason": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Reason provides an explanation for canary deployment autopause",
+							Description: "Reason provides an explanation for canary deployment autopause, or for the rolling update failure",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"conditions": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"type",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions Represents the latest available observations of the ExtendedDaemonSet rollout.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetCondition"),
+									},
+								},
+							},
+						},
+					},
+					"rollout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rollout progress of the latest ExtendedDaemonSetReplicaSet deployment",
+							Ref:         ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusRollout"),
+						},
+					},
+					"hooks": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"type",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Hooks status of the lifecycle hooks of the latest rollout",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusHook"),
+									},
+								},
+							},
+						},
+					},
+					"history": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "History of the last rollout transitions, from the oldest to the newest",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusHistoryEntry"),
+									},
+								},
+							},
+						},
+					},
+					"nodesResourcesProfile": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"nodeName",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "NodesResourcesProfile the resources profile used on the Nodes, mirrored from the active ExtendedDaemonSetReplicaSet. A new ExtendedDaemonSetReplicaSet starts from these profiles.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile"),
+									},
+								},
+							},
+						},
+					},
+					"collisionCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CollisionCount count of hash collisions of the pod template. It is used to compute the template hash of a new ExtendedDaemonSetReplicaSet when it collides with the hash of an existing one.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 				},
 				Required: []string{"desired", "current", "ready", "available", "upToDate", "ignoredUnresponsiveNodes", "activeReplicaSet"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusCanary"},
+			"./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetCondition", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusCanary", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusHistoryEntry", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusHook", "./pkg/apis/datadoghq/v1alpha1.ExtendedDaemonSetStatusRollout"},
 	}
 }
 
@@ -581,6 +1084,214 @@ func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusCanary(ref common
 	}
 }
 
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusHistoryEntry(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetStatusHistoryEntry defines a rollout transition of an ExtendedDaemonSet",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"time": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time of the transition",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"action": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Action the rollout transition",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"activeReplicaSet": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ActiveReplicaSet name of the active ExtendedDaemonSetReplicaSet",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"canaryReplicaSet": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CanaryReplicaSet name of the canary ExtendedDaemonSetReplicaSet",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replicaSet": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReplicaSet name of the ExtendedDaemonSetReplicaSet deployed by the rollout, set on the RolloutStarted entries",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"changeCause": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ChangeCause summary of the changes deployed by the rollout, set on the RolloutStarted entries",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason of the transition",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"actor": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Actor who requested the transition: a user, \"controller\" for the automatic transitions, or empty if unknown",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"time", "action"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusHook(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetStatusHook defines the observed state of a lifecycle hook of an ExtendedDaemonSet rollout",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the hook: PreCanary, CanaryVerification, PreRollout or PostRollout",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replicaSet": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReplicaSet name of the ExtendedDaemonSetReplicaSet deployed by the rollout",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the hook: Running, Succeeded or Failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"jobs": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "set",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Jobs names of the Jobs created for the hook",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartTime time the hook Jobs were created",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CompletionTime time the hook succeeded or failed",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message details about the hook failure",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type", "replicaSet", "phase", "startTime"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonSetStatusRollout(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ExtendedDaemonSetStatusRollout defines the observed progress of an ExtendedDaemonSet rollout",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"replicaSet": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReplicaSet name of the ExtendedDaemonSetReplicaSet deployed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"previousReplicaSet": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreviousReplicaSet name of the ExtendedDaemonSetReplicaSet active before the rollout, used by the rolling update failure policy to roll back",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the rollout: Canary, Rolling or Complete",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartTime time the rollout started",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CompletionTime time the new version became available on all the nodes",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"percentComplete": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PercentComplete percentage of the nodes running an available pod of the new version",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"estimatedCompletionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EstimatedCompletionTime estimated end of the Rolling phase, computed from the slow start parameters and the observed pod readiness rate. Only updated when it moves by more than a slow start interval",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"replicaSet", "phase", "startTime", "percentComplete"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
 func schema_pkg_apis_datadoghq_v1alpha1_ExtendedDaemonsetSettingSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -0,0 +1,323 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/election"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/shard"
+)
+
+const (
+	// OperatorConfigurationAPIVersion version of the operator configuration file
+	OperatorConfigurationAPIVersion = "config.datadoghq.com/v1alpha1"
+	// OperatorConfigurationKind kind of the operator configuration file
+	OperatorConfigurationKind = "ExtendedDaemonSetOperatorConfiguration"
+)
+
+const (
+	// DefaultPodCreationWorkers maximum number of pods created concurrently by a reconcile
+	DefaultPodCreationWorkers = 20
+	// DefaultPodCreationBackoff initial delay before retrying the pod creation on a Node where it failed,
+	// doubled on each new failure up to DefaultPodCreationMaxBackoff
+	DefaultPodCreationBackoff    = 10 * time.Second
+	DefaultPodCreationMaxBackoff = 5 * time.Minute
+	// DefaultPodDeletionInterval minimum interval between two pods deletions of an ExtendedDaemonSetReplicaSet
+	DefaultPodDeletionInterval = 5 * time.Second
+	// DefaultSchedulerIssueTimeout duration after which a pod not scheduled, or not terminated after its grace period,
+	// is considered blocked by a scheduler issue
+	DefaultSchedulerIssueTimeout = 10 * time.Minute
+)
+
+// OperatorConfiguration is the operator configuration file, loaded at startup.
+// The Tuning values and the ExtendedDaemonSet defaults are reloaded when the file changes,
+// the other values require a restart.
+type OperatorConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Health         HealthConfiguration         `json:"health"`
+	Metrics        MetricsConfiguration        `json:"metrics"`
+	LeaderElection LeaderElectionConfiguration `json:"leaderElection"`
+	Shard          ShardConfiguration          `json:"shard"`
+	Controllers    ControllersConfiguration    `json:"controllers"`
+
+	// WatchNamespaceSelector only the namespaces matching this label selector are watched, restricted to the
+	// namespaces of the WATCH_NAMESPACE environment variable if set
+	WatchNamespaceSelector string `json:"watchNamespaceSelector,omitempty"`
+
+	// NodeAffinityMatchMinVersion the pods are assigned to their Node with a node affinity on the Kubernetes
+	// versions strictly greater than this one, instead of the pod nodeName
+	NodeAffinityMatchMinVersion string `json:"nodeAffinityMatchMinVersion"`
+
+	// ExtendedDaemonSetDefaults cluster-wide default values of the ExtendedDaemonSet fields not set by the user
+	ExtendedDaemonSetDefaults datadoghqv1alpha1.ExtendedDaemonSetDefaults `json:"extendedDaemonSetDefaults"`
+}
+
+// HealthConfiguration configures the liveness and readiness probes endpoint
+type HealthConfiguration struct {
+	BindAddress string `json:"bindAddress"`
+	// GoroutineThreshold the liveness check fails above this number of goroutines, to detect leaks
+	GoroutineThreshold int `json:"goroutineThreshold"`
+}
+
+// MetricsConfiguration configures the metrics endpoint
+type MetricsConfiguration struct {
+	BindAddress string `json:"bindAddress"`
+}
+
+// LeaderElectionConfiguration configures the leader election between the operator replicas
+type LeaderElectionConfiguration struct {
+	Mode            election.Mode   `json:"mode"`
+	Namespace       string          `json:"namespace,omitempty"`
+	LeaseDuration   metav1.Duration `json:"leaseDuration"`
+	RenewDeadline   metav1.Duration `json:"renewDeadline"`
+	RetryPeriod     metav1.Duration `json:"retryPeriod"`
+	ReleaseOnCancel bool            `json:"releaseOnCancel"`
+}
+
+// ShardConfiguration selects the ExtendedDaemonSets reconciled by this operator replica
+type ShardConfiguration struct {
+	Count    int    `json:"count,omitempty"`
+	Index    int    `json:"index,omitempty"`
+	Selector string `json:"selector,omitempty"`
+}
+
+// ControllersConfiguration configures the controllers
+type ControllersConfiguration struct {
+	ExtendedDaemonSet           ControllerConfiguration           `json:"extendedDaemonSet"`
+	ExtendedDaemonSetReplicaSet ReplicaSetControllerConfiguration `json:"extendedDaemonSetReplicaSet"`
+	ExtendedDaemonsetSetting    ControllerConfiguration           `json:"extendedDaemonsetSetting"`
+}
+
+// ControllerConfiguration configures a controller
+type ControllerConfiguration struct {
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles"`
+}
+
+// ReplicaSetControllerConfiguration configures the ExtendedDaemonSetReplicaSet controller
+type ReplicaSetControllerConfiguration struct {
+	ControllerConfiguration `json:",inline"`
+	Tuning                  `json:",inline"`
+
+	PodCreationBackoff    metav1.Duration `json:"podCreationBackoff"`
+	PodCreationMaxBackoff metav1.Duration `json:"podCreationMaxBackoff"`
+}
+
+// NewDefaultOperatorConfiguration returns the operator configuration used without configuration file
+func NewDefaultOperatorConfiguration() *OperatorConfiguration {
+	leaderElection := election.DefaultOptions()
+	return &OperatorConfiguration{
+		TypeMeta: metav1.TypeMeta{APIVersion: OperatorConfigurationAPIVersion, Kind: OperatorConfigurationKind},
+		Health: HealthConfiguration{
+			BindAddress:        ":8080",
+			GoroutineThreshold: 200,
+		},
+		Metrics: MetricsConfiguration{
+			BindAddress: "0.0.0.0:8383",
+		},
+		LeaderElection: LeaderElectionConfiguration{
+			Mode:            leaderElection.Mode,
+			LeaseDuration:   metav1.Duration{Duration: leaderElection.LeaseDuration},
+			RenewDeadline:   metav1.Duration{Duration: leaderElection.RenewDeadline},
+			RetryPeriod:     metav1.Duration{Duration: leaderElection.RetryPeriod},
+			ReleaseOnCancel: leaderElection.ReleaseOnCancel,
+		},
+		Controllers: ControllersConfiguration{
+			ExtendedDaemonSet: ControllerConfiguration{MaxConcurrentReconciles: 1},
+			ExtendedDaemonSetReplicaSet: ReplicaSetControllerConfiguration{
+				ControllerConfiguration: ControllerConfiguration{MaxConcurrentReconciles: 1},
+				Tuning:                  DefaultTuning(),
+				PodCreationBackoff:      metav1.Duration{Duration: DefaultPodCreationBackoff},
+				PodCreationMaxBackoff:   metav1.Duration{Duration: DefaultPodCreationMaxBackoff},
+			},
+			ExtendedDaemonsetSetting: ControllerConfiguration{MaxConcurrentReconciles: 1},
+		},
+		NodeAffinityMatchMinVersion: "1.12.0",
+		ExtendedDaemonSetDefaults:   datadoghqv1alpha1.NewExtendedDaemonSetDefaults(),
+	}
+}
+
+// AddFlags binds the command line flags to the configuration fields
+func AddFlags(fs *pflag.FlagSet, c *OperatorConfiguration) {
+	fs.StringVarP(&c.WatchNamespaceSelector, "watch-namespace-selector", "", c.WatchNamespaceSelector, "label selector of the namespaces watched by the operator, restricted to the WATCH_NAMESPACE namespaces if set")
+	fs.IntVarP(&c.Controllers.ExtendedDaemonSet.MaxConcurrentReconciles, "eds-max-concurrent-reconciles", "", c.Controllers.ExtendedDaemonSet.MaxConcurrentReconciles, "maximum number of ExtendedDaemonSets reconciled concurrently")
+	fs.IntVarP(&c.Controllers.ExtendedDaemonSetReplicaSet.MaxConcurrentReconciles, "ers-max-concurrent-reconciles", "", c.Controllers.ExtendedDaemonSetReplicaSet.MaxConcurrentReconciles, "maximum number of ExtendedDaemonSetReplicaSets reconciled concurrently")
+	fs.IntVarP(&c.Controllers.ExtendedDaemonsetSetting.MaxConcurrentReconciles, "eds-setting-max-concurrent-reconciles", "", c.Controllers.ExtendedDaemonsetSetting.MaxConcurrentReconciles, "maximum number of ExtendedDaemonsetSettings reconciled concurrently")
+	fs.IntVarP(&c.Shard.Count, "shard-count", "", c.Shard.Count, "number of operator replicas sharing the ExtendedDaemonSets by the hash of their namespace and name (disabled if <= 1)")
+	fs.IntVarP(&c.Shard.Index, "shard-index", "", c.Shard.Index, "shard of this operator replica, between 0 and shard-count - 1")
+	fs.StringVarP(&c.Shard.Selector, "shard-selector", "", c.Shard.Selector, "label selector of the ExtendedDaemonSets reconciled by this operator replica (all if empty)")
+	fs.StringVarP((*string)(&c.LeaderElection.Mode), "leader-election-mode", "", string(c.LeaderElection.Mode), "leader election mode: \"leader-for-life\" (ConfigMap lock released when the leader pod is garbage collected) or \"lease\" (Lease lock taken over when not renewed)")
+	fs.StringVarP(&c.LeaderElection.Namespace, "leader-election-namespace", "", c.LeaderElection.Namespace, "namespace of the leader election Lease (operator namespace if empty)")
+	fs.DurationVarP(&c.LeaderElection.LeaseDuration.Duration, "leader-election-lease-duration", "", c.LeaderElection.LeaseDuration.Duration, "duration the non-leader replicas wait before taking over a Lease not renewed")
+	fs.DurationVarP(&c.LeaderElection.RenewDeadline.Duration, "leader-election-renew-deadline", "", c.LeaderElection.RenewDeadline.Duration, "duration the leader retries to renew the Lease before stepping down")
+	fs.DurationVarP(&c.LeaderElection.RetryPeriod.Duration, "leader-election-retry-period", "", c.LeaderElection.RetryPeriod.Duration, "duration between the tries to acquire or renew the Lease")
+	fs.BoolVarP(&c.LeaderElection.ReleaseOnCancel, "leader-election-release-on-cancel", "", c.LeaderElection.ReleaseOnCancel, "release the Lease when the operator stops, so another replica takes over immediately")
+}
+
+// LoadOperatorConfiguration reads the configuration file. The fields not set in the file keep their default value,
+// and the flags explicitly set on the command line take precedence over the file.
+func LoadOperatorConfiguration(path string, flags *pflag.FlagSet) (*OperatorConfiguration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOperatorConfiguration(data, flags)
+}
+
+// ParseOperatorConfiguration parses the content of a configuration file, see LoadOperatorConfiguration
+func ParseOperatorConfiguration(data []byte, flags *pflag.FlagSet) (*OperatorConfiguration, error) {
+	c := NewDefaultOperatorConfiguration()
+	c.TypeMeta = metav1.TypeMeta{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, fmt.Errorf("invalid operator configuration: %v", err)
+	}
+	if c.APIVersion != OperatorConfigurationAPIVersion || c.Kind != OperatorConfigurationKind {
+		return nil, fmt.Errorf("unsupported operator configuration %s, %s: expected %s, %s", c.APIVersion, c.Kind, OperatorConfigurationAPIVersion, OperatorConfigurationKind)
+	}
+
+	if flags != nil {
+		overrides := pflag.NewFlagSet("overrides", pflag.ContinueOnError)
+		AddFlags(overrides, c)
+		var errs []error
+		flags.Visit(func(f *pflag.Flag) {
+			if overrides.Lookup(f.Name) == nil {
+				return
+			}
+			if err := overrides.Set(f.Name, f.Value.String()); err != nil {
+				errs = append(errs, err)
+			}
+		})
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns an error if the configuration is invalid
+func (c *OperatorConfiguration) Validate() error {
+	if _, err := c.MetricsPort(); err != nil {
+		return fmt.Errorf("invalid metrics bindAddress %q: %v", c.Metrics.BindAddress, err)
+	}
+	if _, _, err := net.SplitHostPort(c.Health.BindAddress); err != nil {
+		return fmt.Errorf("invalid health bindAddress %q: %v", c.Health.BindAddress, err)
+	}
+	if _, err := semver.Make(c.NodeAffinityMatchMinVersion); err != nil {
+		return fmt.Errorf("invalid nodeAffinityMatchMinVersion %q: %v", c.NodeAffinityMatchMinVersion, err)
+	}
+	if c.Controllers.ExtendedDaemonSetReplicaSet.PodCreationBackoff.Duration <= 0 || c.Controllers.ExtendedDaemonSetReplicaSet.PodCreationMaxBackoff.Duration < c.Controllers.ExtendedDaemonSetReplicaSet.PodCreationBackoff.Duration {
+		return fmt.Errorf("invalid pod creation backoff: podCreationBackoff must be positive and lower than podCreationMaxBackoff")
+	}
+	if err := c.Controllers.ExtendedDaemonSetReplicaSet.Tuning.Validate(); err != nil {
+		return err
+	}
+	if err := c.ExtendedDaemonSetDefaults.Validate(); err != nil {
+		return fmt.Errorf("invalid extendedDaemonSetDefaults: %v", err)
+	}
+	if _, err := c.GetShard(); err != nil {
+		return err
+	}
+	if _, err := c.GetWatchNamespaceSelector(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetWatchNamespaceSelector returns the label selector of the watched namespaces, nil if not set
+func (c *OperatorConfiguration) GetWatchNamespaceSelector() (labels.Selector, error) {
+	if c.WatchNamespaceSelector == "" {
+		return nil, nil
+	}
+	selector, err := labels.Parse(c.WatchNamespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid watch namespace selector: %v", err)
+	}
+	return selector, nil
+}
+
+// MetricsPort returns the port of the metrics endpoint
+func (c *OperatorConfiguration) MetricsPort() (int, error) {
+	_, port, err := net.SplitHostPort(c.Metrics.BindAddress)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(port)
+}
+
+// GetShard returns the shard of this operator replica
+func (c *OperatorConfiguration) GetShard() (*shard.Shard, error) {
+	s := &shard.Shard{Count: c.Shard.Count, Index: c.Shard.Index}
+	if c.Shard.Selector != "" {
+		selector, err := labels.Parse(c.Shard.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard selector: %v", err)
+		}
+		s.LabelSelector = selector
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetLeaderElectionOptions returns the leader election options
+func (c *OperatorConfiguration) GetLeaderElectionOptions(lockName string) election.Options {
+	return election.Options{
+		Mode:            c.LeaderElection.Mode,
+		LockName:        lockName,
+		Namespace:       c.LeaderElection.Namespace,
+		LeaseDuration:   c.LeaderElection.LeaseDuration.Duration,
+		RenewDeadline:   c.LeaderElection.RenewDeadline.Duration,
+		RetryPeriod:     c.LeaderElection.RetryPeriod.Duration,
+		ReleaseOnCancel: c.LeaderElection.ReleaseOnCancel,
+	}
+}
+
+// GetOptions returns the controllers options
+func (c *OperatorConfiguration) GetOptions(s *shard.Shard, tuning *TuningProvider) Options {
+	return Options{
+		ExtendedDaemonSet:           ControllerOptions{MaxConcurrentReconciles: c.Controllers.ExtendedDaemonSet.MaxConcurrentReconciles},
+		ExtendedDaemonSetReplicaSet: ControllerOptions{MaxConcurrentReconciles: c.Controllers.ExtendedDaemonSetReplicaSet.MaxConcurrentReconciles},
+		ExtendedDaemonsetSetting:    ControllerOptions{MaxConcurrentReconciles: c.Controllers.ExtendedDaemonsetSetting.MaxConcurrentReconciles},
+		Shard:                       s,
+		PodCreationBackoff:          c.Controllers.ExtendedDaemonSetReplicaSet.PodCreationBackoff.Duration,
+		PodCreationMaxBackoff:       c.Controllers.ExtendedDaemonSetReplicaSet.PodCreationMaxBackoff.Duration,
+		Tuning:                      tuning,
+	}
+}
+
+// ApplyReloadable applies the values that can change without restarting the operator:
+// the controllers Tuning and the ExtendedDaemonSet defaults
+func (c *OperatorConfiguration) ApplyReloadable(tuning *TuningProvider) {
+	tuning.Set(c.Controllers.ExtendedDaemonSetReplicaSet.Tuning)
+	datadoghqv1alpha1.SetExtendedDaemonSetDefaults(c.ExtendedDaemonSetDefaults)
+}
+
+// RequiresRestart returns true if the configurations differ on values that can't be reloaded
+func (c *OperatorConfiguration) RequiresRestart(other *OperatorConfiguration) bool {
+	a, b := *c, *other
+	a.Controllers.ExtendedDaemonSetReplicaSet.Tuning = Tuning{}
+	b.Controllers.ExtendedDaemonSetReplicaSet.Tuning = Tuning{}
+	a.ExtendedDaemonSetDefaults = datadoghqv1alpha1.ExtendedDaemonSetDefaults{}
+	b.ExtendedDaemonSetDefaults = datadoghqv1alpha1.ExtendedDaemonSetDefaults{}
+	return a != b
+}
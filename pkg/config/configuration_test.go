@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/election"
+)
+
+const testConfiguration = `apiVersion: config.datadoghq.com/v1alpha1
+kind: ExtendedDaemonSetOperatorConfiguration
+health:
+  goroutineThreshold: 500
+metrics:
+  bindAddress: "0.0.0.0:9090"
+leaderElection:
+  mode: lease
+  leaseDuration: 30s
+shard:
+  count: 2
+  index: 1
+controllers:
+  extendedDaemonSetReplicaSet:
+    maxConcurrentReconciles: 4
+    podCreationWorkers: 50
+    schedulerIssueTimeout: 5m
+extendedDaemonSetDefaults:
+  canaryDuration: 20m
+  maxUnavailable: 10%
+`
+
+func TestParseOperatorConfiguration(t *testing.T) {
+	c, err := ParseOperatorConfiguration([]byte(testConfiguration), nil)
+	if err != nil {
+		t.Fatalf("ParseOperatorConfiguration() error = %v", err)
+	}
+
+	if c.Health.GoroutineThreshold != 500 || c.Health.BindAddress != ":8080" {
+		t.Errorf("Health = %+v, want the file threshold and the default bind address", c.Health)
+	}
+	if port, _ := c.MetricsPort(); port != 9090 {
+		t.Errorf("MetricsPort() = %d, want 9090", port)
+	}
+	if c.LeaderElection.Mode != election.ModeLease || c.LeaderElection.LeaseDuration.Duration != 30*time.Second || c.LeaderElection.RenewDeadline.Duration != 10*time.Second {
+		t.Errorf("LeaderElection = %+v, want the file mode and lease duration, and the default renew deadline", c.LeaderElection)
+	}
+	if s, _ := c.GetShard(); s.ID() != "shard-1-of-2" {
+		t.Errorf("GetShard().ID() = %s, want shard-1-of-2", s.ID())
+	}
+	ers := c.Controllers.ExtendedDaemonSetReplicaSet
+	if ers.MaxConcurrentReconciles != 4 || ers.PodCreationWorkers != 50 || ers.SchedulerIssueTimeout.Duration != 5*time.Minute || ers.PodDeletionInterval.Duration != DefaultPodDeletionInterval {
+		t.Errorf("Controllers.ExtendedDaemonSetReplicaSet = %+v", ers)
+	}
+	if c.Controllers.ExtendedDaemonSet.MaxConcurrentReconciles != 1 {
+		t.Errorf("Controllers.ExtendedDaemonSet.MaxConcurrentReconciles = %d, want the default 1", c.Controllers.ExtendedDaemonSet.MaxConcurrentReconciles)
+	}
+	defaults := c.ExtendedDaemonSetDefaults
+	if defaults.CanaryDuration.Duration != 20*time.Minute || defaults.MaxUnavailable.String() != "10%" || defaults.MaxParallelPodCreation != datadoghqv1alpha1.NewExtendedDaemonSetDefaults().MaxParallelPodCreation {
+		t.Errorf("ExtendedDaemonSetDefaults = %+v, want the file canaryDuration and maxUnavailable, and the other defaults", defaults)
+	}
+}
+
+func TestParseOperatorConfiguration_FlagsPrecedence(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlags(fs, NewDefaultOperatorConfiguration())
+	if err := fs.Parse([]string{"--ers-max-concurrent-reconciles=8", "--leader-election-lease-duration=1m"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	c, err := ParseOperatorConfiguration([]byte(testConfiguration), fs)
+	if err != nil {
+		t.Fatalf("ParseOperatorConfiguration() error = %v", err)
+	}
+	if got := c.Controllers.ExtendedDaemonSetReplicaSet.MaxConcurrentReconciles; got != 8 {
+		t.Errorf("MaxConcurrentReconciles = %d, want the flag value 8", got)
+	}
+	if got := c.LeaderElection.LeaseDuration.Duration; got != time.Minute {
+		t.Errorf("LeaseDuration = %v, want the flag value 1m", got)
+	}
+	// the flags not set don't override the file
+	if c.Shard.Count != 2 {
+		t.Errorf("Shard.Count = %d, want the file value 2", c.Shard.Count)
+	}
+}
+
+func TestParseOperatorConfiguration_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "missing version",
+			data: "health:\n  goroutineThreshold: 500\n",
+		},
+		{
+			name: "unsupported version",
+			data: "apiVersion: config.datadoghq.com/v2\nkind: ExtendedDaemonSetOperatorConfiguration\n",
+		},
+		{
+			name: "unknown field",
+			data: "apiVersion: config.datadoghq.com/v1alpha1\nkind: ExtendedDaemonSetOperatorConfiguration\nhealth:\n  port: 8080\n",
+		},
+		{
+			name: "invalid shard",
+			data: "apiVersion: config.datadoghq.com/v1alpha1\nkind: ExtendedDaemonSetOperatorConfiguration\nshard:\n  count: 2\n  index: 2\n",
+		},
+		{
+			name: "invalid version gate",
+			data: "apiVersion: config.datadoghq.com/v1alpha1\nkind: ExtendedDaemonSetOperatorConfiguration\nnodeAffinityMatchMinVersion: foo\n",
+		},
+		{
+			name: "invalid default maxUnavailable",
+			data: "apiVersion: config.datadoghq.com/v1alpha1\nkind: ExtendedDaemonSetOperatorConfiguration\nextendedDaemonSetDefaults:\n  maxUnavailable: abc%\n",
+		},
+		{
+			name: "invalid default duration",
+			data: "apiVersion: config.datadoghq.com/v1alpha1\nkind: ExtendedDaemonSetOperatorConfiguration\nextendedDaemonSetDefaults:\n  reconcileFrequency: -1s\n",
+		},
+		{
+			name: "invalid tuning duration",
+			data: "apiVersion: config.datadoghq.com/v1alpha1\nkind: ExtendedDaemonSetOperatorConfiguration\ncontrollers:\n  extendedDaemonSetReplicaSet:\n    podDeletionInterval: 0s\n",
+		},
+		{
+			name: "negative tuning workers",
+			data: "apiVersion: config.datadoghq.com/v1alpha1\nkind: ExtendedDaemonSetOperatorConfiguration\ncontrollers:\n  extendedDaemonSetReplicaSet:\n    podCreationWorkers: -1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseOperatorConfiguration([]byte(tt.data), nil); err == nil {
+				t.Errorf("ParseOperatorConfiguration() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestWatcher_reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eds-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	if err = ioutil.WriteFile(path, []byte(testConfiguration), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer datadoghqv1alpha1.SetExtendedDaemonSetDefaults(datadoghqv1alpha1.NewExtendedDaemonSetDefaults())
+
+	c, err := LoadOperatorConfiguration(path, nil)
+	if err != nil {
+		t.Fatalf("LoadOperatorConfiguration() error = %v", err)
+	}
+	tuning := NewTuningProvider(c.Controllers.ExtendedDaemonSetReplicaSet.Tuning)
+	w, err := NewWatcher(path, nil, c, tuning)
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	// invalid file, the current configuration is kept
+	if err = ioutil.WriteFile(path, []byte(testConfiguration+"foo: bar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w.reload()
+	if got := tuning.Get().PodCreationWorkers; got != 50 {
+		t.Errorf("PodCreationWorkers = %d after an invalid reload, want 50", got)
+	}
+
+	// the extendedDaemonSetDefaults section is the last one of testConfiguration
+	updated := strings.Replace(testConfiguration, "podCreationWorkers: 50", "podCreationWorkers: 10", 1) + "  canaryReplicas: 3\n"
+	if err = ioutil.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w.reload()
+	if got := tuning.Get().PodCreationWorkers; got != 10 {
+		t.Errorf("PodCreationWorkers = %d after the reload, want 10", got)
+	}
+	if got := datadoghqv1alpha1.GetExtendedDaemonSetDefaults().CanaryReplicas; got != intstr.FromInt(3) {
+		t.Errorf("CanaryReplicas default = %v after the reload, want 3", got.String())
+	}
+	if w.current.RequiresRestart(c) {
+		t.Errorf("RequiresRestart() = true, want false when only the reloadable values changed")
+	}
+}
@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"time"
+
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/shard"
+)
+
+// ControllerOptions contains the options of a controller
+type ControllerOptions struct {
+	// MaxConcurrentReconciles maximum number of reconciles run concurrently by the controller, 1 if not set
+	MaxConcurrentReconciles int
+}
+
+// Options contains the options of all the controllers
+type Options struct {
+	ExtendedDaemonSet           ControllerOptions
+	ExtendedDaemonSetReplicaSet ControllerOptions
+	ExtendedDaemonsetSetting    ControllerOptions
+
+	// Shard selects the ExtendedDaemonSets reconciled by this operator replica, all of them if nil
+	Shard *shard.Shard
+
+	// PodCreationBackoff and PodCreationMaxBackoff bound the delay before retrying the pod creation on a Node
+	// where it failed, DefaultPodCreationBackoff and DefaultPodCreationMaxBackoff if not set
+	PodCreationBackoff    time.Duration
+	PodCreationMaxBackoff time.Duration
+
+	// Tuning gives the values reloaded with the configuration file, the DefaultTuning if nil
+	Tuning *TuningProvider
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Tuning contains the controllers values applied without restarting the operator
+type Tuning struct {
+	// PodCreationWorkers maximum number of pods created concurrently by a reconcile
+	PodCreationWorkers int `json:"podCreationWorkers"`
+	// PodDeletionInterval minimum interval between two pods deletions of an ExtendedDaemonSetReplicaSet
+	PodDeletionInterval metav1.Duration `json:"podDeletionInterval"`
+	// SchedulerIssueTimeout duration after which a pod not scheduled, or not terminated after its grace period,
+	// is ignored by the rolling update within the maxPodSchedulerFailure limit
+	SchedulerIssueTimeout metav1.Duration `json:"schedulerIssueTimeout"`
+}
+
+// DefaultTuning returns the default Tuning
+func DefaultTuning() Tuning {
+	return Tuning{
+		PodCreationWorkers:    DefaultPodCreationWorkers,
+		PodDeletionInterval:   metav1.Duration{Duration: DefaultPodDeletionInterval},
+		SchedulerIssueTimeout: metav1.Duration{Duration: DefaultSchedulerIssueTimeout},
+	}
+}
+
+// Validate returns an error if one of the Tuning values is invalid
+func (t Tuning) Validate() error {
+	if t.PodCreationWorkers < 0 {
+		return fmt.Errorf("invalid podCreationWorkers %d: must not be negative", t.PodCreationWorkers)
+	}
+	if t.PodDeletionInterval.Duration <= 0 {
+		return fmt.Errorf("invalid podDeletionInterval %s: must be positive", t.PodDeletionInterval.Duration)
+	}
+	if t.SchedulerIssueTimeout.Duration <= 0 {
+		return fmt.Errorf("invalid schedulerIssueTimeout %s: must be positive", t.SchedulerIssueTimeout.Duration)
+	}
+	return nil
+}
+
+// TuningProvider gives the current Tuning to the controllers, updated when the configuration file is reloaded
+type TuningProvider struct {
+	mutex  sync.RWMutex
+	tuning Tuning
+}
+
+// NewTuningProvider returns a new TuningProvider
+func NewTuningProvider(tuning Tuning) *TuningProvider {
+	return &TuningProvider{tuning: tuning}
+}
+
+// Get returns the current Tuning, the default one if the provider is nil
+func (p *TuningProvider) Get() Tuning {
+	if p == nil {
+		return DefaultTuning()
+	}
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.tuning
+}
+
+// Set changes the current Tuning
+func (p *TuningProvider) Set(tuning Tuning) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.tuning = tuning
+}
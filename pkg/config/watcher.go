@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"time"
+
+	"github.com/spf13/pflag"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("config")
+
+const defaultWatchInterval = 10 * time.Second
+
+// Watcher reloads the configuration file when it changes. The file is polled, so the changes of a mounted
+// ConfigMap, applied by swapping a symlink, are also detected.
+type Watcher struct {
+	path     string
+	flags    *pflag.FlagSet
+	interval time.Duration
+	tuning   *TuningProvider
+
+	current *OperatorConfiguration
+	data    []byte
+}
+
+// NewWatcher returns a new Watcher of the configuration file loaded with current
+func NewWatcher(path string, flags *pflag.FlagSet, current *OperatorConfiguration, tuning *TuningProvider) (*Watcher, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		path:     path,
+		flags:    flags,
+		interval: defaultWatchInterval,
+		tuning:   tuning,
+		current:  current,
+		data:     data,
+	}, nil
+}
+
+// Start polls the configuration file until stop is closed, it implements the manager.Runnable interface
+func (w *Watcher) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			w.reload()
+		}
+	}
+}
+
+func (w *Watcher) reload() {
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		log.Error(err, "Unable to read the configuration file", "path", w.path)
+		return
+	}
+	if bytes.Equal(data, w.data) {
+		return
+	}
+	w.data = data
+
+	newConfig, err := ParseOperatorConfiguration(data, w.flags)
+	if err != nil {
+		log.Error(err, "Invalid configuration file, keeping the current configuration", "path", w.path)
+		return
+	}
+	if w.current.RequiresRestart(newConfig) {
+		log.Info("Configuration file changed on values applied only at startup, restart the operator to apply them", "path", w.path)
+	}
+	newConfig.ApplyReloadable(w.tuning)
+	w.current = newConfig
+	log.Info("Configuration file reloaded", "path", w.path)
+}
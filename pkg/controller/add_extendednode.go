@@ -12,4 +12,5 @@ import (
 func init() {
 	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
 	AddToManagerFuncs = append(AddToManagerFuncs, extendeddaemonsetsetting.Add)
+	AddToMetricsHandlerFuncs = append(AddToMetricsHandlerFuncs, extendeddaemonsetsetting.AddMetrics)
 }
@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNamespace default prefix of the metrics names
+	DefaultNamespace = "extendeddaemonset."
+
+	// AlertTypeInfo event alert type "info"
+	AlertTypeInfo = "info"
+	// AlertTypeSuccess event alert type "success"
+	AlertTypeSuccess = "success"
+	// AlertTypeWarning event alert type "warning"
+	AlertTypeWarning = "warning"
+	// AlertTypeError event alert type "error"
+	AlertTypeError = "error"
+
+	unixAddressPrefix = "unix://"
+	eventSourceType   = "extendeddaemonset"
+	writeTimeout      = 100 * time.Millisecond
+)
+
+// tagReplacer replaces the characters of the DogStatsD protocol in the tags
+var tagReplacer = strings.NewReplacer("|", "_", ",", "_", "\n", "_")
+
+// Options use to provides Client creation options
+type Options struct {
+	// Address of the DogStatsD server: "host:port" for UDP or "unix:///path/to/dsd.socket" for UDS
+	Address string
+	// Namespace prefix of the metrics names
+	Namespace string
+	// Tags added to all the metrics and events
+	Tags []string
+}
+
+// Client sends metrics and events to a DogStatsD server
+// Each metric or event is sent in its own datagram. The connection is opened on the first send and reopened after
+// a write error, so the DogStatsD server, often the Datadog agent deployed by this operator, can start later or restart.
+type Client struct {
+	namespace string
+	tags      []string
+	network   string
+	address   string
+
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+// New returns a new Client instance, the connection to the DogStatsD server is opened on the first send
+func New(options Options) (*Client, error) {
+	network, address := "udp", options.Address
+	if strings.HasPrefix(address, unixAddressPrefix) {
+		network, address = "unixgram", strings.TrimPrefix(address, unixAddressPrefix)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("empty DogStatsD address")
+	}
+
+	return &Client{
+		namespace: options.Namespace,
+		tags:      options.Tags,
+		network:   network,
+		address:   address,
+	}, nil
+}
+
+// Count sends a count metric
+func (c *Client) Count(name string, value int64, tags []string) error {
+	return c.sendMetric(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+// Gauge sends a gauge metric
+func (c *Client) Gauge(name string, value float64, tags []string) error {
+	return c.sendMetric(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+// Histogram sends a histogram metric
+func (c *Client) Histogram(name string, value float64, tags []string) error {
+	return c.sendMetric(name, strconv.FormatFloat(value, 'f', -1, 64), "h", tags)
+}
+
+// Event sends an event. The events with the same aggregationKey are grouped together.
+func (c *Client) Event(title, text, alertType, aggregationKey string, tags []string) error {
+	text = strings.Replace(text, "\n", "\\n", -1)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "_e{%d,%d}:%s|%s", len(title), len(text), title, text)
+	if alertType != "" {
+		buf.WriteString("|t:")
+		buf.WriteString(alertType)
+	}
+	if aggregationKey != "" {
+		buf.WriteString("|k:")
+		buf.WriteString(aggregationKey)
+	}
+	buf.WriteString("|s:")
+	buf.WriteString(eventSourceType)
+	c.writeTags(buf, tags)
+
+	return c.send(buf.Bytes())
+}
+
+// Close closes the connection to the DogStatsD server
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) sendMetric(name, value, metricType string, tags []string) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString(c.namespace)
+	buf.WriteString(name)
+	buf.WriteByte(':')
+	buf.WriteString(value)
+	buf.WriteByte('|')
+	buf.WriteString(metricType)
+	c.writeTags(buf, tags)
+
+	return c.send(buf.Bytes())
+}
+
+func (c *Client) writeTags(buf *bytes.Buffer, tags []string) {
+	first := true
+	for _, list := range [][]string{c.tags, tags} {
+		for _, tag := range list {
+			if first {
+				buf.WriteString("|#")
+				first = false
+			} else {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(tagReplacer.Replace(tag))
+		}
+	}
+}
+
+func (c *Client) send(payload []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.conn == nil {
+		conn, err := net.Dial(c.network, c.address)
+		if err != nil {
+			return fmt.Errorf("unable to connect to DogStatsD on %s, err: %v", c.address, err)
+		}
+		c.conn = conn
+	}
+
+	// the write on a full unix socket blocks: never block the reconcile loop for a metric
+	err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err == nil {
+		_, err = c.conn.Write(payload)
+	}
+	if err != nil {
+		// reconnect on the next send, the DogStatsD server may have been restarted
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	return err
+}
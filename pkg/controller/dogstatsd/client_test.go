@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(c *Client) error
+		want string
+	}{
+		{
+			name: "count",
+			send: func(c *Client) error { return c.Count("pods_created", 2, []string{"kube_namespace:bar"}) },
+			want: "eds.pods_created:2|c|#env:test,kube_namespace:bar",
+		},
+		{
+			name: "gauge",
+			send: func(c *Client) error { return c.Gauge("rollout.percent_complete", 42.5, nil) },
+			want: "eds.rollout.percent_complete:42.5|g|#env:test",
+		},
+		{
+			name: "histogram",
+			send: func(c *Client) error { return c.Histogram("rollout.duration", 120, []string{"reason:a|b,c"}) },
+			want: "eds.rollout.duration:120|h|#env:test,reason:a_b_c",
+		},
+		{
+			name: "event",
+			send: func(c *Client) error {
+				return c.Event("Canary failed", "foo\nbar", AlertTypeError, "bar/foo", []string{"extendeddaemonset:foo"})
+			},
+			want: "_e{13,8}:Canary failed|foo\\nbar|t:error|k:bar/foo|s:extendeddaemonset|#env:test,extendeddaemonset:foo",
+		},
+	}
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := New(Options{Address: listener.LocalAddr().String(), Namespace: "eds.", Tags: []string{"env:test"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.send(client); err != nil {
+				t.Fatalf("send error = %v", err)
+			}
+			if got := readPacket(t, listener); got != tt.want {
+				t.Errorf("payload = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dogstatsd")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "dsd.socket")
+	listener, err := net.ListenPacket("unixgram", socketPath)
+	if err != nil {
+		t.Skipf("unix datagram sockets not supported: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := New(Options{Address: "unix://" + socketPath})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if err = client.Count("pods_deleted", 1, nil); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if got, want := readPacket(t, listener), "pods_deleted:1|c"; got != want {
+		t.Errorf("payload = %q, want %q", got, want)
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dogstatsd")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "dsd.socket")
+
+	// the DogStatsD server isn't started yet
+	client, err := New(Options{Address: "unix://" + socketPath})
+	if err != nil {
+		t.Fatalf("New() error = %v, want no error before the DogStatsD server is started", err)
+	}
+	defer client.Close()
+	if err = client.Count("pods_deleted", 1, nil); err == nil {
+		t.Errorf("Count() error = nil, want an error without DogStatsD server")
+	}
+
+	listener, err := net.ListenPacket("unixgram", socketPath)
+	if err != nil {
+		t.Skipf("unix datagram sockets not supported: %v", err)
+	}
+	defer listener.Close()
+
+	if err = client.Count("pods_deleted", 2, nil); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if got, want := readPacket(t, listener), "pods_deleted:2|c"; got != want {
+		t.Errorf("payload = %q, want %q", got, want)
+	}
+}
+
+func TestNewEmptyAddress(t *testing.T) {
+	if _, err := New(Options{Address: "unix://"}); err == nil {
+		t.Errorf("New() should return an error for an empty address")
+	}
+}
+
+func readPacket(t *testing.T, listener net.PacketConn) string {
+	buf := make([]byte, 1024)
+	if err := listener.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("unable to set read deadline: %v", err)
+	}
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("unable to read packet: %v", err)
+	}
+	return string(buf[:n])
+}
@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package election elects the operator replica running the controllers, with a leader-for-life ConfigMap lock
+// or a Lease lock allowing a fast failover.
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/operator-framework/operator-sdk/pkg/leader"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("election")
+
+// Mode leader election mode
+type Mode string
+
+const (
+	// ModeLeaderForLife the leader holds a ConfigMap lock owned by its pod, until the pod is garbage collected
+	ModeLeaderForLife Mode = "leader-for-life"
+	// ModeLease the leader renews a Lease, taken over by another replica when not renewed during the lease duration
+	ModeLease Mode = "lease"
+)
+
+// Options defines the leader election
+type Options struct {
+	// Mode leader election mode
+	Mode Mode
+	// LockName name of the ConfigMap or Lease used as lock
+	LockName string
+	// Namespace of the Lease, the operator namespace if empty. The leader-for-life ConfigMap is always created
+	// in the operator namespace.
+	Namespace string
+	// Identity of the replica holding the Lease, the hostname with a unique suffix if empty
+	Identity string
+
+	// LeaseDuration duration the other replicas wait before taking over a Lease not renewed
+	LeaseDuration time.Duration
+	// RenewDeadline duration the leader retries to renew the Lease before giving up
+	RenewDeadline time.Duration
+	// RetryPeriod duration between the tries to acquire or renew the Lease
+	RetryPeriod time.Duration
+	// ReleaseOnCancel releases the Lease when the context is canceled, so another replica takes over without waiting
+	// the lease duration. The controllers must be stopped before canceling the context.
+	ReleaseOnCancel bool
+}
+
+// DefaultOptions returns the default leader election options: the leader-for-life mode kept for backward
+// compatibility, and the client-go default durations for the lease mode
+func DefaultOptions() Options {
+	return Options{
+		Mode:            ModeLeaderForLife,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		ReleaseOnCancel: true,
+	}
+}
+
+// Become blocks until the replica is elected leader, or the context is canceled.
+// It returns a channel closed when the leadership ends: the Lease was released after the context cancellation,
+// or it couldn't be renewed. In ModeLeaderForLife, the leadership only ends with the context.
+func Become(ctx context.Context, client kubernetes.Interface, opts Options) (<-chan struct{}, error) {
+	switch opts.Mode {
+	case ModeLeaderForLife:
+		if err := leader.Become(ctx, opts.LockName); err != nil {
+			return nil, err
+		}
+		return ctx.Done(), nil
+	case ModeLease:
+		return becomeLeaseHolder(ctx, client, opts)
+	default:
+		return nil, fmt.Errorf("unknown leader election mode: %s", opts.Mode)
+	}
+}
+
+func becomeLeaseHolder(ctx context.Context, client kubernetes.Interface, opts Options) (<-chan struct{}, error) {
+	if opts.Namespace == "" {
+		ns, err := k8sutil.GetOperatorNamespace()
+		if err == k8sutil.ErrRunLocal {
+			log.Info("Skipping leader election; not running in a cluster.")
+			return ctx.Done(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		opts.Namespace = ns
+	}
+	identity := opts.Identity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		identity = hostname + "_" + string(uuid.NewUUID())
+	}
+
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, opts.Namespace, opts.LockName, client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: identity})
+	if err != nil {
+		return nil, err
+	}
+
+	elected := make(chan struct{})
+	done := make(chan struct{})
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            opts.LockName,
+		LeaseDuration:   opts.LeaseDuration,
+		RenewDeadline:   opts.RenewDeadline,
+		RetryPeriod:     opts.RetryPeriod,
+		ReleaseOnCancel: opts.ReleaseOnCancel,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				log.Info("Became the leader", "lease", opts.Namespace+"/"+opts.LockName, "identity", identity)
+				close(elected)
+			},
+			OnStoppedLeading: func() {
+				log.Info("Stopped leading", "lease", opts.Namespace+"/"+opts.LockName, "identity", identity)
+			},
+			OnNewLeader: func(leaderIdentity string) {
+				if leaderIdentity != identity {
+					log.Info("Waiting for the leader", "lease", opts.Namespace+"/"+opts.LockName, "leader", leaderIdentity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer close(done)
+		elector.Run(ctx)
+	}()
+
+	select {
+	case <-elected:
+		return done, nil
+	case <-done:
+		return nil, fmt.Errorf("leader election stopped before being elected: %v", ctx.Err())
+	}
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package election
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+func testOptions(identity string) Options {
+	opts := DefaultOptions()
+	opts.Mode = ModeLease
+	opts.LockName = "extendeddaemonset-lock"
+	opts.Namespace = "bar"
+	opts.Identity = identity
+	opts.LeaseDuration = 2 * time.Second
+	opts.RenewDeadline = time.Second
+	opts.RetryPeriod = 100 * time.Millisecond
+	return opts
+}
+
+func TestBecome_Lease(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	client := fake.NewSimpleClientset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done, err := Become(ctx, client, testOptions("replica-1"))
+	if err != nil {
+		t.Fatalf("Become() error = %v", err)
+	}
+	lease, err := client.CoordinationV1().Leases("bar").Get("extendeddaemonset-lock", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Lease not created: %v", err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != "replica-1" {
+		t.Errorf("Lease holder = %v, want replica-1", lease.Spec.HolderIdentity)
+	}
+
+	// a second replica waits for the Lease
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	elected2 := make(chan struct{})
+	go func() {
+		if _, err2 := Become(ctx2, client, testOptions("replica-2")); err2 == nil {
+			close(elected2)
+		}
+	}()
+	select {
+	case <-elected2:
+		t.Fatalf("replica-2 elected while replica-1 holds the Lease")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// the Lease is released on cancel, replica-2 takes over without waiting the lease duration
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("leadership of replica-1 not ended after the context cancellation")
+	}
+	select {
+	case <-elected2:
+	case <-time.After(testOptions("replica-2").LeaseDuration):
+		t.Fatalf("replica-2 not elected after the Lease release")
+	}
+}
+
+func TestBecome_UnknownMode(t *testing.T) {
+	opts := testOptions("replica-1")
+	opts.Mode = "foo"
+	if _, err := Become(context.Background(), fake.NewSimpleClientset(), opts); err == nil {
+		t.Errorf("Become() error = nil, want an error for an unknown mode")
+	}
+}
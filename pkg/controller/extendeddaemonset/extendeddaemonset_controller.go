@@ -14,6 +14,7 @@ import (
 
 	"github.com/go-logr/logr"
 
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -33,36 +34,50 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/config"
 	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/scheduler"
+	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
+	"github.com/datadog/extendeddaemonset/pkg/controller/notifier"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils/comparison"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils/enqueue"
 	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/shard"
 )
 
 var log = logf.Log.WithName("ExtendedDaemonSet")
 
+// maxTemplateChanges maximum number of pod template changes listed on a new ExtendedDaemonSetReplicaSet
+const maxTemplateChanges = 20
+
 // Add creates a new ExtendedDaemonSet Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, opts config.Options) error {
+	return add(mgr, newReconciler(mgr, opts), opts)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) *ReconcileExtendedDaemonSet {
-	return &ReconcileExtendedDaemonSet{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor("ExtendedDaemonSet")}
+func newReconciler(mgr manager.Manager, opts config.Options) *ReconcileExtendedDaemonSet {
+	return &ReconcileExtendedDaemonSet{
+		client:    mgr.GetClient(),
+		apiReader: mgr.GetAPIReader(),
+		scheme:    mgr.GetScheme(),
+		recorder:  mgr.GetEventRecorderFor("ExtendedDaemonSet"),
+		notifier:  notifier.Default(),
+		shard:     opts.Shard,
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, opts config.Options) error {
 	// Create a new controller
-	c, err := controller.New("extendeddaemonset-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("extendeddaemonset-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: opts.ExtendedDaemonSet.MaxConcurrentReconciles})
 	if err != nil {
 		return err
 	}
 
-	// Watch for changes to primary resource ExtendedDaemonSet
-	err = c.Watch(&source.Kind{Type: &datadoghqv1alpha1.ExtendedDaemonSet{}}, &handler.EnqueueRequestForObject{})
+	// Watch for changes to primary resource ExtendedDaemonSet, only the ones of the shard
+	err = c.Watch(&source.Kind{Type: &datadoghqv1alpha1.ExtendedDaemonSet{}}, &handler.EnqueueRequestForObject{}, opts.Shard.Predicate())
 	if err != nil {
 		return err
 	}
@@ -83,6 +98,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to the lifecycle hooks Jobs and requeue the ExtendedDaemonSet
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &enqueue.RequestForExtendedDaemonSetLabel{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -93,9 +114,14 @@ var _ reconcile.Reconciler = &ReconcileExtendedDaemonSet{}
 type ReconcileExtendedDaemonSet struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client   client.Client
-	scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	client client.Client
+	// apiReader reads objects from the apiserver, used for the objects not cached, like the secrets
+	apiReader client.Reader
+	scheme    *runtime.Scheme
+	recorder  record.EventRecorder
+	notifier  *notifier.Notifier
+	// shard selects the ExtendedDaemonSets reconciled by this operator replica
+	shard *shard.Shard
 }
 
 // Reconcile reads that state of the cluster for a ExtendedDaemonSet object and makes changes based on the state read
@@ -121,6 +147,11 @@ func (r *ReconcileExtendedDaemonSet) Reconcile(request reconcile.Request) (recon
 		return reconcile.Result{}, err
 	}
 
+	if !r.shard.Contains(instance) {
+		reqLogger.V(1).Info("ExtendedDaemonSet reconciled by another shard")
+		return reconcile.Result{}, nil
+	}
+
 	if !datadoghqv1alpha1.IsDefaultedExtendedDaemonSet(instance) {
 		reqLogger.Info("Defaulting values")
 		defaultedInstance := datadoghqv1alpha1.DefaultExtendedDaemonSet(instance)
@@ -167,7 +198,13 @@ func (r *ReconcileExtendedDaemonSet) Reconcile(request reconcile.Request) (recon
 
 	if upToDateRS == nil {
 		// If there is no ReplicaSet that matches the EDS Spec, create a new one and return to apply the reconcile loop again
-		return r.createNewReplicaSet(reqLogger, instance)
+		return r.createNewReplicaSet(reqLogger, instance, replicaSetList, activeRS)
+	}
+
+	// Run the lifecycle hooks Jobs and update their status
+	if instance, err = r.manageHooks(reqLogger, instance, activeRS, upToDateRS, now); err != nil {
+		reqLogger.Error(err, "failed to manage the lifecycle hooks")
+		return reconcile.Result{}, err
 	}
 
 	// Select the ReplicaSet that should be current
@@ -178,18 +215,28 @@ func (r *ReconcileExtendedDaemonSet) Reconcile(request reconcile.Request) (recon
 		return reconcile.Result{RequeueAfter: requeueAfter}, nil
 	}
 
-	_, result, err := r.updateInstanceWithCurrentRS(reqLogger, instance, currentRS, upToDateRS, podsCounter)
+	_, result, err := r.updateInstanceWithCurrentRS(reqLogger, instance, currentRS, upToDateRS, podsCounter, now)
 	result = utils.MergeResult(result, reconcile.Result{RequeueAfter: requeueAfter})
 	return result, err
 }
 
-func (r *ReconcileExtendedDaemonSet) createNewReplicaSet(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet) (reconcile.Result, error) {
+func (r *ReconcileExtendedDaemonSet) createNewReplicaSet(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, rsList *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList, activeRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) (reconcile.Result, error) {
 	var err error
 	// replicaSet up to date didn't exist yet, new to create one
 	var newRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
 	if newRS, err = newReplicaSetFromInstance(daemonset); err != nil {
 		return reconcile.Result{}, err
 	}
+	// the template hash collides with the hash of another template, increase the collision count to get a new hash
+	for _, rs := range rsList.Items {
+		if rs.Spec.TemplateGeneration == newRS.Spec.TemplateGeneration {
+			return r.increaseCollisionCount(logger, daemonset, rs.Name)
+		}
+	}
+	var changeCause string
+	if activeRS != nil {
+		changeCause = setTemplateChangesAnnotations(logger, newRS, activeRS)
+	}
 	// Set ExtendedDaemonSet instance as the owner and controller
 	if err = controllerutil.SetControllerReference(daemonset, newRS, r.scheme); err != nil {
 		return reconcile.Result{}, err
@@ -200,7 +247,11 @@ func (r *ReconcileExtendedDaemonSet) createNewReplicaSet(logger logr.Logger, dae
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	r.recorder.Event(daemonset, corev1.EventTypeNormal, "Create ExtendedDaemonSetReplicaSet", fmt.Sprintf("%s/%s", newRS.Namespace, newRS.Name))
+	message := fmt.Sprintf("%s/%s", newRS.Namespace, newRS.Name)
+	if changeCause != "" {
+		message = fmt.Sprintf("%s: %s", message, changeCause)
+	}
+	r.recorder.Event(daemonset, corev1.EventTypeNormal, "Create ExtendedDaemonSetReplicaSet", message)
 	return reconcile.Result{Requeue: true}, nil
 }
 
@@ -218,25 +269,33 @@ func selectCurrentReplicaSet(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, act
 		return upToDateRS, requeueAfter
 	}
 
+	// If the rolling update of the active ReplicaSet failed, the previous ReplicaSet is rolled back without Canary phase nor hooks
+	if isFailed, _ := IsRollingUpdateFailed(activeRS); isFailed && isRollbackReplicaSet(daemonset, activeRS, upToDateRS) {
+		return upToDateRS, requeueAfter
+	}
+
+	// The rolling update waits for the PreRollout hook
+	isPreRolloutCompleted := isHookCompleted(daemonset, datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout, upToDateRS.Name)
+
 	// If there is no Canary phase, then use the latest ReplicaSet
 	if daemonset.Spec.Strategy.Canary == nil {
-		return upToDateRS, requeueAfter
+		if isPreRolloutCompleted {
+			return upToDateRS, requeueAfter
+		}
+		return activeRS, requeueAfter
 	}
 
-	// If in Canary phase, then only update ReplicaSet if it has ended or been declared valid
-	var isEnded bool
-	dsAnnotations := daemonset.GetAnnotations()
-	isEnded, requeueAfter = IsCanaryDeploymentEnded(daemonset.Spec.Strategy.Canary, upToDateRS, now)
-	isPaused, _ := IsCanaryDeploymentPaused(dsAnnotations)
-	isValid := IsCanaryDeploymentValid(dsAnnotations, upToDateRS.GetName())
-	if isValid || (!isPaused && isEnded) {
+	// If in Canary phase, then only update ReplicaSet if it has ended or been declared valid, and its hooks are completed
+	var isDone bool
+	isDone, requeueAfter = isCanaryDeploymentDone(daemonset, upToDateRS, now)
+	if isDone && isPreRolloutCompleted {
 		return upToDateRS, requeueAfter
 	}
 
 	return activeRS, requeueAfter
 }
 
-func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, current, upToDate *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, podsCounter podsCounterType) (*datadoghqv1alpha1.ExtendedDaemonSet, reconcile.Result, error) {
+func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, current, upToDate *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, podsCounter podsCounterType, now time.Time) (*datadoghqv1alpha1.ExtendedDaemonSet, reconcile.Result, error) {
 	newDaemonset := daemonset.DeepCopy()
 	newDaemonset.Status.Current = podsCounter.Current
 	newDaemonset.Status.Ready = podsCounter.Ready
@@ -246,10 +305,14 @@ func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Log
 		newDaemonset.Status.UpToDate = current.Status.Available
 		newDaemonset.Status.Available = current.Status.Available
 		newDaemonset.Status.State = datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning
+		newDaemonset.Status.Reason = ""
 		newDaemonset.Status.IgnoredUnresponsiveNodes = current.Status.IgnoredUnresponsiveNodes
+		newDaemonset.Status.NodesResourcesProfile = current.Status.NodesResourcesProfile
 	}
 
-	var updateDaemonsetSpec bool
+	var updateDaemonsetSpec, canaryStarted, canaryFailed, canaryValidated, rollingUpdateFailed bool
+	var rollbackRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
+	var nbCanaryNodesReselected int
 	// If the deployment is in Canary phase, then update status (and spec as needed)
 	if daemonset.Spec.Strategy.Canary != nil {
 		switch {
@@ -261,8 +324,10 @@ func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Log
 			// Restore active replicaset template. Note: this requires a full daemonset update
 			newDaemonset.Spec.Template = current.Spec.Template
 			updateDaemonsetSpec = true
+			canaryFailed = daemonset.Status.State != datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed
 		case current.Name == upToDate.Name:
 			// Canary deployment is no longer needed because it completed without issue
+			canaryValidated = daemonset.Status.Canary != nil
 			newDaemonset.Status.Canary = nil
 			newDaemonset.Status.State = datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning
 		default:
@@ -270,6 +335,7 @@ func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Log
 			if newDaemonset.Status.Canary == nil {
 				newDaemonset.Status.Canary = &datadoghqv1alpha1.ExtendedDaemonSetStatusCanary{}
 			}
+			canaryStarted = newDaemonset.Status.Canary.ReplicaSet != upToDate.Name
 			newDaemonset.Status.Desired += upToDate.Status.Desired
 			newDaemonset.Status.UpToDate += upToDate.Status.Available
 			newDaemonset.Status.Available += upToDate.Status.Available
@@ -283,11 +349,15 @@ func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Log
 				return newDaemonset, reconcile.Result{}, err
 			}
 
-			if nbCanaryPod != len(newDaemonset.Status.Canary.Nodes) {
+			// The canary Nodes are selected once the PreCanary hook is completed
+			isPreCanaryCompleted := isHookCompleted(daemonset, datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary, upToDate.Name)
+			if isPreCanaryCompleted && nbCanaryPod != len(newDaemonset.Status.Canary.Nodes) {
+				previousNodes := append([]string{}, newDaemonset.Status.Canary.Nodes...)
 				if err = r.selectNodes(logger, &newDaemonset.Spec, upToDate, newDaemonset.Status.Canary); err != nil {
 					logger.Error(err, "unable to select Nodes for canary")
 					return newDaemonset, reconcile.Result{}, err
 				}
+				nbCanaryNodesReselected = countRemovedNodes(previousNodes, newDaemonset.Status.Canary.Nodes)
 			}
 
 			isPaused, reason := IsCanaryDeploymentPaused(daemonset.GetAnnotations())
@@ -300,6 +370,36 @@ func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Log
 		}
 	}
 
+	// If the rolling update was halted by the failure policy or the progress deadline, then update the status and roll back as needed
+	if isFailed, reason := IsRollingUpdateFailed(current); isFailed && current.Name == upToDate.Name {
+		newDaemonset.Status.State = datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed
+		newDaemonset.Status.Reason = reason
+		rollingUpdateFailed = daemonset.Status.State != datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed
+		if isRollbackEnabled(daemonset, reason) {
+			var err error
+			if rollbackRS, err = r.getRollbackReplicaSet(daemonset, current); err != nil {
+				logger.Error(err, "unable to roll back the ExtendedDaemonSet")
+			} else if rollbackRS != nil {
+				// Restore the previous replicaset template. Note: this requires a full daemonset update
+				newDaemonset.Spec.Template = rollbackRS.Spec.Template
+				updateDaemonsetSpec = true
+			}
+		}
+	}
+
+	if isPaused, reason := IsRollingUpdatePaused(current); isPaused && current.Name == upToDate.Name && newDaemonset.Status.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning {
+		newDaemonset.Status.State = datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdatePaused
+		newDaemonset.Status.Reason = reason
+	}
+
+	var progressDeadlineExceeded bool
+	newDaemonset.Status.Conditions, progressDeadlineExceeded = computeConditions(daemonset, upToDate)
+	newDaemonset.Status.Rollout = computeRolloutStatus(daemonset, &newDaemonset.Status, current, upToDate, now)
+	rolloutCompleted := isRolloutCompleted(daemonset.Status.Rollout, newDaemonset.Status.Rollout)
+	phaseLeft, phaseDuration, isPhaseLeft := rolloutPhaseLeft(daemonset.Status.Rollout, newDaemonset.Status.Rollout, upToDate, now)
+	newDaemonset.Status.History = computeHistory(daemonset, &newDaemonset.Status, upToDate, now)
+	metrics.ForwardRolloutStatus(daemonset.Namespace, daemonset.Name, newDaemonset.Status.Rollout, now)
+
 	// Check if newDaemonset differs from existing daemonset, and update if so
 	if !apiequality.Semantic.DeepEqual(daemonset, newDaemonset) {
 		if updateDaemonsetSpec {
@@ -312,6 +412,38 @@ func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Log
 		if err := r.client.Status().Update(context.TODO(), newDaemonset); err != nil {
 			return newDaemonset, reconcile.Result{}, err
 		}
+		if canaryStarted {
+			metrics.IncCanaryStarted(daemonset.Namespace, daemonset.Name, upToDate.Name)
+		}
+		if canaryFailed {
+			metrics.IncCanaryFailed(daemonset.Namespace, daemonset.Name)
+		}
+		if canaryValidated {
+			metrics.IncCanaryValidated(daemonset.Namespace, daemonset.Name)
+		}
+		if rollingUpdateFailed {
+			metrics.IncRollingUpdateFailed(daemonset.Namespace, daemonset.Name, string(newDaemonset.Status.Reason))
+			r.recorder.Event(daemonset, corev1.EventTypeWarning, "RollingUpdateFailed", fmt.Sprintf("Rolling update of %s halted, reason: %s", current.Name, newDaemonset.Status.Reason))
+		}
+		if progressDeadlineExceeded {
+			metrics.IncProgressDeadlineExceeded(daemonset.Namespace, daemonset.Name, upToDate.Name)
+			r.recorder.Event(daemonset, corev1.EventTypeWarning, "ProgressDeadlineExceeded", fmt.Sprintf("No additional pod of %s became available within the progress deadline", upToDate.Name))
+		}
+		if rollbackRS != nil {
+			metrics.IncRollback(daemonset.Namespace, daemonset.Name, rollbackRS.Name)
+			r.recorder.Event(daemonset, corev1.EventTypeWarning, "Rollback", fmt.Sprintf("Rolling back from %s to %s", current.Name, rollbackRS.Name))
+		}
+		if nbCanaryNodesReselected > 0 {
+			metrics.AddCanaryNodesReselected(daemonset.Namespace, daemonset.Name, nbCanaryNodesReselected)
+		}
+		if isPhaseLeft {
+			metrics.ObserveRolloutPhaseDuration(daemonset.Namespace, daemonset.Name, string(phaseLeft), phaseDuration)
+		}
+		if rolloutCompleted {
+			rollout := newDaemonset.Status.Rollout
+			metrics.ObserveRolloutDuration(daemonset.Namespace, daemonset.Name, rollout.CompletionTime.Sub(rollout.StartTime.Time))
+		}
+		r.notify(logger, daemonset, &newDaemonset.Status, now)
 		return newDaemonset, reconcile.Result{}, nil
 	}
 
@@ -320,7 +452,7 @@ func (r *ReconcileExtendedDaemonSet) updateInstanceWithCurrentRS(logger logr.Log
 
 func (r *ReconcileExtendedDaemonSet) selectNodes(logger logr.Logger, daemonsetSpec *datadoghqv1alpha1.ExtendedDaemonSetSpec, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, canaryStatus *datadoghqv1alpha1.ExtendedDaemonSetStatusCanary) error {
 	// create a Fake pod from the current replicaset.spec.template
-	newPod, _ := podutils.CreatePodFromDaemonSetReplicaSet(r.scheme, replicaset, nil, nil, false)
+	newPod, _ := podutils.CreatePodFromDaemonSetReplicaSet(r.scheme, replicaset, nil, nil, nil, false)
 
 	nodeList := &corev1.NodeList{}
 
@@ -454,6 +586,21 @@ func (r *ReconcileExtendedDaemonSet) selectNodes(logger logr.Logger, daemonsetSp
 	return nil
 }
 
+// countRemovedNodes returns the number of previous nodes not present in the current nodes
+func countRemovedNodes(previous, current []string) int {
+	currentNodes := make(map[string]bool, len(current))
+	for _, name := range current {
+		currentNodes[name] = true
+	}
+	var nb int
+	for _, name := range previous {
+		if !currentNodes[name] {
+			nb++
+		}
+	}
+	return nb
+}
+
 func getAntiAffinityKeysValue(node *corev1.Node, daemonsetSpec *datadoghqv1alpha1.ExtendedDaemonSetSpec) string {
 	values := make([]string, 0, len(daemonsetSpec.Strategy.Canary.NodeAntiAffinityKeys))
 	for _, antiAffinityKey := range daemonsetSpec.Strategy.Canary.NodeAntiAffinityKeys {
@@ -469,12 +616,19 @@ func newReplicaSetFromInstance(daemonset *datadoghqv1alpha1.ExtendedDaemonSet) (
 	for key, val := range daemonset.Labels {
 		labels[key] = val
 	}
+	var annotations map[string]string
+	if daemonset.Annotations != nil {
+		annotations = make(map[string]string, len(daemonset.Annotations))
+		for key, val := range daemonset.Annotations {
+			annotations[key] = val
+		}
+	}
 	rs := &datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: fmt.Sprintf("%s-", daemonset.Name),
 			Namespace:    daemonset.Namespace,
 			Labels:       labels,
-			Annotations:  daemonset.Annotations,
+			Annotations:  annotations,
 		},
 		Spec: datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSpec{
 			Selector: daemonset.Spec.Selector.DeepCopy(),
@@ -482,11 +636,54 @@ func newReplicaSetFromInstance(daemonset *datadoghqv1alpha1.ExtendedDaemonSet) (
 		},
 	}
 
-	hash, err := comparison.SetMD5PodTemplateSpecAnnotation(rs, daemonset)
+	hash, err := comparison.SetPodTemplateSpecHashAnnotation(rs, daemonset)
 	rs.Spec.TemplateGeneration = hash
 	return rs, err
 }
 
+// increaseCollisionCount increases the collision count of the ExtendedDaemonSet, so the next template hash doesn't
+// collide with the one of the replicaset rsName
+func (r *ReconcileExtendedDaemonSet) increaseCollisionCount(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, rsName string) (reconcile.Result, error) {
+	newDaemonset := daemonset.DeepCopy()
+	var collisionCount int32
+	if newDaemonset.Status.CollisionCount != nil {
+		collisionCount = *newDaemonset.Status.CollisionCount
+	}
+	collisionCount++
+	newDaemonset.Status.CollisionCount = &collisionCount
+	logger.Info("Template hash collision, increase the collision count", "replicaSet.Name", rsName, "collisionCount", collisionCount)
+	if err := r.client.Status().Update(context.TODO(), newDaemonset); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{Requeue: true}, nil
+}
+
+// setTemplateChangesAnnotations lists on the new replicaset the pod template fields that changed compared to the active replicaset,
+// and the change cause summarizing them. It returns the change cause.
+func setTemplateChangesAnnotations(logger logr.Logger, newRS, activeRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) string {
+	changes, err := comparison.DiffPodTemplateSpec(&activeRS.Spec.Template, &newRS.Spec.Template)
+	if err != nil {
+		logger.Error(err, "unable to compare the pod template with the active replicaset", "activeReplicaSet", activeRS.Name)
+		return ""
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	changeCause, err := comparison.ChangeCause(&activeRS.Spec.Template, &newRS.Spec.Template)
+	if err != nil {
+		logger.Error(err, "unable to compute the change cause", "activeReplicaSet", activeRS.Name)
+	}
+	logger.Info("Pod template changed", "activeReplicaSet", activeRS.Name, "changes", changes, "changeCause", changeCause)
+	if len(changes) > maxTemplateChanges {
+		changes = append(changes[:maxTemplateChanges], "...")
+	}
+	newRS.Annotations[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetTemplateChangesAnnotationKey] = strings.Join(changes, ",")
+	if changeCause != "" {
+		newRS.Annotations[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetChangeCauseAnnotationKey] = changeCause
+	}
+	return changeCause
+}
+
 func (r *ReconcileExtendedDaemonSet) cleanupReplicaSet(logger logr.Logger, rsList *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList, current, updatetodate *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) error {
 	var wg sync.WaitGroup
 	errsChan := make(chan error, len(rsList.Items))
@@ -210,10 +210,10 @@ func Test_newReplicaSetFromInstance(t *testing.T) {
 					Namespace:    "bar",
 					GenerateName: "foo-",
 					Labels:       map[string]string{"extendeddaemonset.datadoghq.com/name": "foo"},
-					Annotations:  map[string]string{"extendeddaemonset.datadoghq.com/templatehash": "a2bb34618483323482d9a56ae2515eed"},
+					Annotations:  map[string]string{"extendeddaemonset.datadoghq.com/templatehash": "679bdd4ff"},
 				},
 				Spec: datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSpec{
-					TemplateGeneration: "a2bb34618483323482d9a56ae2515eed",
+					TemplateGeneration: "679bdd4ff",
 				},
 			},
 		},
@@ -226,10 +226,10 @@ func Test_newReplicaSetFromInstance(t *testing.T) {
 					Namespace:    "bar",
 					GenerateName: "foo-",
 					Labels:       map[string]string{"foo-key": "bar-value", "extendeddaemonset.datadoghq.com/name": "foo"},
-					Annotations:  map[string]string{"extendeddaemonset.datadoghq.com/templatehash": "a2bb34618483323482d9a56ae2515eed"},
+					Annotations:  map[string]string{"extendeddaemonset.datadoghq.com/templatehash": "679bdd4ff"},
 				},
 				Spec: datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSpec{
-					TemplateGeneration: "a2bb34618483323482d9a56ae2515eed",
+					TemplateGeneration: "679bdd4ff",
 				},
 			},
 		},
@@ -302,6 +302,47 @@ func Test_selectCurrentReplicaSet(t *testing.T) {
 			ActiveReplicaSet: replicassetOld.Name,
 		},
 	})
+	replicassetRollingUpdateFailed := test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", &test.NewExtendedDaemonSetReplicaSetOptions{
+		CreationTime: &creationTimeRSDone,
+		Labels:       map[string]string{"foo-key": "bar-value"},
+		Status: &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{
+			Conditions: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{
+				{
+					Type:   datadoghqv1alpha1.ConditionTypeRollingUpdateFailed,
+					Status: corev1.ConditionTrue,
+					Reason: string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonPodsRestarting),
+				},
+			},
+		},
+	})
+	daemonsetWithCanaryRollingUpdateFailed := test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{
+		CreationTime: &creationTimeDaemonset,
+		Labels:       map[string]string{"foo-key": "old-value"},
+		Canary: &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyCanary{
+			Replicas: &intString1,
+			Duration: &metav1.Duration{Duration: 5 * time.Minute},
+		},
+		Status: &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+			ActiveReplicaSet: replicassetRollingUpdateFailed.Name,
+			Rollout: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+				ReplicaSet:         replicassetRollingUpdateFailed.Name,
+				PreviousReplicaSet: replicassetOld.Name,
+			},
+		},
+	})
+	preRolloutHooks := &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyHooks{
+		PreRollout: &datadoghqv1alpha1.ExtendedDaemonSetHook{FailurePolicy: datadoghqv1alpha1.ExtendedDaemonSetHookFailurePolicyAbort},
+	}
+	daemonsetWithPreRolloutRunning := daemonset.DeepCopy()
+	daemonsetWithPreRolloutRunning.Spec.Strategy.Hooks = preRolloutHooks
+	daemonsetWithPreRolloutRunning.Status.Hooks = []datadoghqv1alpha1.ExtendedDaemonSetStatusHook{
+		{Type: datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout, ReplicaSet: "foo-1", Phase: datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning},
+	}
+	daemonsetWithCanaryValidPreRolloutSucceeded := daemonsetWithCanaryValid.DeepCopy()
+	daemonsetWithCanaryValidPreRolloutSucceeded.Spec.Strategy.Hooks = preRolloutHooks
+	daemonsetWithCanaryValidPreRolloutSucceeded.Status.Hooks = []datadoghqv1alpha1.ExtendedDaemonSetStatusHook{
+		{Type: datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout, ReplicaSet: "foo-1", Phase: datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded},
+	}
 
 	type args struct {
 		daemonset  *datadoghqv1alpha1.ExtendedDaemonSet
@@ -404,6 +445,50 @@ func Test_selectCurrentReplicaSet(t *testing.T) {
 			want:  replicassetOld,
 			want1: -time.Minute,
 		},
+		{
+			name: "two RS, rolling update failed, rollback without canary",
+			args: args{
+				daemonset:  daemonsetWithCanaryRollingUpdateFailed,
+				upToDateRS: replicassetOld,
+				activeRS:   replicassetRollingUpdateFailed,
+				now:        now,
+			},
+			want:  replicassetOld,
+			want1: 0,
+		},
+		{
+			name: "two RS, rolling update failed, new RS with canary",
+			args: args{
+				daemonset:  daemonsetWithCanaryRollingUpdateFailed,
+				upToDateRS: replicassetUpToDate,
+				activeRS:   replicassetRollingUpdateFailed,
+				now:        now,
+			},
+			want:  replicassetRollingUpdateFailed,
+			want1: 5 * time.Minute,
+		},
+		{
+			name: "two RS, canary not set, PreRollout hook running",
+			args: args{
+				daemonset:  daemonsetWithPreRolloutRunning,
+				upToDateRS: replicassetUpToDate,
+				activeRS:   replicassetOld,
+				now:        now,
+			},
+			want:  replicassetOld,
+			want1: 0,
+		},
+		{
+			name: "two RS, canary set, canary declared valid, PreRollout hook succeeded",
+			args: args{
+				daemonset:  daemonsetWithCanaryValidPreRolloutSucceeded,
+				upToDateRS: replicassetUpToDate,
+				activeRS:   replicassetOld,
+				now:        now,
+			},
+			want:  replicassetUpToDate,
+			want1: 5 * time.Minute,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -507,8 +592,17 @@ func TestReconcileExtendedDaemonSet_createNewReplicaSet(t *testing.T) {
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
 	s.AddKnownTypes(datadoghqv1alpha1.SchemeGroupVersion, &datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{})
+	s.AddKnownTypes(datadoghqv1alpha1.SchemeGroupVersion, &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList{})
 	s.AddKnownTypes(datadoghqv1alpha1.SchemeGroupVersion, &datadoghqv1alpha1.ExtendedDaemonSet{})
 
+	daemonset := test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{Labels: map[string]string{"foo-key": "bar-value"}})
+	daemonset.Spec.Template.Spec.Containers = []corev1.Container{{Name: "daemon", Image: "pause:3.1"}}
+	hash, _ := comparison.GeneratePodTemplateSpecHash(&daemonset.Spec.Template, nil)
+	collidingRS := test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", nil)
+	collidingRS.Spec.TemplateGeneration = hash
+	activeRS := test.NewExtendedDaemonSetReplicaSet("bar", "foo-2", nil)
+	activeRS.Spec.Template.Spec.Containers = []corev1.Container{{Name: "daemon", Image: "pause:3.0"}}
+
 	type fields struct {
 		client client.Client
 		scheme *runtime.Scheme
@@ -516,13 +610,16 @@ func TestReconcileExtendedDaemonSet_createNewReplicaSet(t *testing.T) {
 	type args struct {
 		logger    logr.Logger
 		daemonset *datadoghqv1alpha1.ExtendedDaemonSet
+		rsList    *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList
+		activeRS  *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    reconcile.Result
-		wantErr bool
+		name     string
+		fields   fields
+		args     args
+		want     reconcile.Result
+		wantErr  bool
+		wantFunc func(c client.Client) error
 	}{
 		{
 			name: "create new RS",
@@ -533,10 +630,73 @@ func TestReconcileExtendedDaemonSet_createNewReplicaSet(t *testing.T) {
 			args: args{
 				logger:    log,
 				daemonset: test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{Labels: map[string]string{"foo-key": "bar-value"}}),
+				rsList:    &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList{},
 			},
 			want:    reconcile.Result{Requeue: true},
 			wantErr: false,
 		},
+		{
+			name: "create new RS, with the template changes",
+			fields: fields{
+				client: fake.NewFakeClient(),
+				scheme: s,
+			},
+			args: args{
+				logger:    log,
+				daemonset: daemonset.DeepCopy(),
+				rsList:    &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList{Items: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{*activeRS}},
+				activeRS:  activeRS,
+			},
+			want:    reconcile.Result{Requeue: true},
+			wantErr: false,
+			wantFunc: func(c client.Client) error {
+				rsList := &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList{}
+				if err := c.List(context.TODO(), rsList); err != nil {
+					return err
+				}
+				if len(rsList.Items) != 1 {
+					return fmt.Errorf("expected 1 replicaset, got %d", len(rsList.Items))
+				}
+				if changes := rsList.Items[0].Annotations[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetTemplateChangesAnnotationKey]; changes != "spec.containers[daemon].image" {
+					return fmt.Errorf("unexpected template changes annotation: %q", changes)
+				}
+				if changeCause := rsList.Items[0].Annotations[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetChangeCauseAnnotationKey]; changeCause != "image daemon: pause:3.0 -> pause:3.1" {
+					return fmt.Errorf("unexpected change cause annotation: %q", changeCause)
+				}
+				return nil
+			},
+		},
+		{
+			name: "template hash collision",
+			fields: fields{
+				client: fake.NewFakeClient(daemonset.DeepCopy()),
+				scheme: s,
+			},
+			args: args{
+				logger:    log,
+				daemonset: daemonset.DeepCopy(),
+				rsList:    &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList{Items: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{*collidingRS}},
+			},
+			want:    reconcile.Result{Requeue: true},
+			wantErr: false,
+			wantFunc: func(c client.Client) error {
+				eds := &datadoghqv1alpha1.ExtendedDaemonSet{}
+				if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo"}, eds); err != nil {
+					return err
+				}
+				if eds.Status.CollisionCount == nil || *eds.Status.CollisionCount != 1 {
+					return fmt.Errorf("expected collisionCount 1, got %v", eds.Status.CollisionCount)
+				}
+				rsList := &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList{}
+				if err := c.List(context.TODO(), rsList); err != nil {
+					return err
+				}
+				if len(rsList.Items) != 0 {
+					return fmt.Errorf("expected no replicaset created, got %d", len(rsList.Items))
+				}
+				return nil
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -545,7 +705,7 @@ func TestReconcileExtendedDaemonSet_createNewReplicaSet(t *testing.T) {
 				scheme:   tt.fields.scheme,
 				recorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "TestReconcileExtendedDaemonSet_cleanupReplicaSet"}),
 			}
-			got, err := r.createNewReplicaSet(tt.args.logger, tt.args.daemonset)
+			got, err := r.createNewReplicaSet(tt.args.logger, tt.args.daemonset, tt.args.rsList, tt.args.activeRS)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ReconcileExtendedDaemonSet.createNewReplicaSet() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -553,6 +713,11 @@ func TestReconcileExtendedDaemonSet_createNewReplicaSet(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ReconcileExtendedDaemonSet.createNewReplicaSet() = %v, want %v", got, tt.want)
 			}
+			if tt.wantFunc != nil {
+				if err := tt.wantFunc(tt.fields.client); err != nil {
+					t.Errorf("ReconcileExtendedDaemonSet.createNewReplicaSet() wantFunc validation error: %v", err)
+				}
+			}
 		})
 	}
 }
@@ -579,6 +744,9 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 			Ready:     2,
 		}})
 
+	now := time.Now().Truncate(time.Second)
+	nowMeta := metav1.NewTime(now)
+
 	daemonsetWithStatus := daemonset.DeepCopy()
 	daemonsetWithStatus.ResourceVersion = "2"
 	daemonsetWithStatus.Status = datadoghqv1alpha1.ExtendedDaemonSetStatus{
@@ -589,6 +757,18 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 		Ready:            2,
 		UpToDate:         3,
 		State:            "Running",
+		Rollout: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+			ReplicaSet:      "current",
+			Phase:           datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete,
+			StartTime:       nowMeta,
+			CompletionTime:  &nowMeta,
+			PercentComplete: 100,
+		},
+	}
+	canaryRollout := &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+		ReplicaSet: "foo-1",
+		Phase:      datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary,
+		StartTime:  nowMeta,
 	}
 	intString1 := intstr.FromInt(1)
 	daemonsetWithCanaryWithStatus := daemonsetWithStatus.DeepCopy()
@@ -602,6 +782,7 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 			Nodes:      []string{"node1"},
 			ReplicaSet: "foo-1",
 		}
+		daemonsetWithCanaryWithStatus.Status.Rollout = canaryRollout
 	}
 
 	daemonsetWithCanaryPaused := test.NewExtendedDaemonSet(
@@ -628,8 +809,9 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 					Nodes:      []string{"node1"},
 					ReplicaSet: "foo-1",
 				},
-				State:  datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused,
-				Reason: datadoghqv1alpha1.ExtendedDaemonSetStatusReasonCLB,
+				State:   datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused,
+				Reason:  datadoghqv1alpha1.ExtendedDaemonSetStatusReasonCLB,
+				Rollout: canaryRollout,
 			},
 		},
 	)
@@ -647,6 +829,14 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 		daemonsetWithCanaryFailedNewStatus.ResourceVersion = "3"
 		daemonsetWithCanaryFailedNewStatus.Status.State = datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed
 		daemonsetWithCanaryFailedNewStatus.Status.Canary = nil
+		daemonsetWithCanaryFailedNewStatus.Status.History = []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+			{
+				Time:             metav1.NewTime(now),
+				Action:           datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryFailed,
+				ActiveReplicaSet: "current",
+				CanaryReplicaSet: "foo-1",
+			},
+		}
 	}
 
 	type fields struct {
@@ -659,6 +849,7 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 		current     *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
 		upToDate    *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
 		podsCounter podsCounterType
+		now         time.Time
 	}
 	tests := []struct {
 		name       string
@@ -699,6 +890,7 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 					Current: 3,
 					Ready:   2,
 				},
+				now: now,
 			},
 			want:       daemonsetWithStatus,
 			wantResult: reconcile.Result{Requeue: false},
@@ -719,6 +911,7 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 					Current: 3,
 					Ready:   2,
 				},
+				now: now,
 			},
 			want:       daemonsetWithCanaryWithStatus,
 			wantResult: reconcile.Result{Requeue: false},
@@ -739,6 +932,7 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 					Current: 3,
 					Ready:   2,
 				},
+				now: now,
 			},
 			want:       daemonsetWithCanaryPaused,
 			wantResult: reconcile.Result{Requeue: false},
@@ -759,6 +953,7 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 					Current: 3,
 					Ready:   2,
 				},
+				now: now,
 			},
 			want:       daemonsetWithCanaryFailedNewStatus,
 			wantResult: reconcile.Result{Requeue: false},
@@ -772,7 +967,7 @@ func TestReconcileExtendedDaemonSet_updateInstanceWithCurrentRS(t *testing.T) {
 				scheme:   tt.fields.scheme,
 				recorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "TestReconcileExtendedDaemonSet_cleanupReplicaSet"}),
 			}
-			got, got1, err := r.updateInstanceWithCurrentRS(tt.args.logger, tt.args.daemonset, tt.args.current, tt.args.upToDate, tt.args.podsCounter)
+			got, got1, err := r.updateInstanceWithCurrentRS(tt.args.logger, tt.args.daemonset, tt.args.current, tt.args.upToDate, tt.args.podsCounter, tt.args.now)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ReconcileExtendedDaemonSet.updateInstanceWithCurrentRS() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1045,3 +1240,43 @@ func Test_getAntiAffinityKeysValue(t *testing.T) {
 		})
 	}
 }
+
+func Test_countRemovedNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		current  []string
+		want     int
+	}{
+		{
+			name:    "first selection",
+			current: []string{"node1", "node2"},
+			want:    0,
+		},
+		{
+			name:     "same nodes",
+			previous: []string{"node1", "node2"},
+			current:  []string{"node2", "node1"},
+			want:     0,
+		},
+		{
+			name:     "one node replaced",
+			previous: []string{"node1", "node2"},
+			current:  []string{"node1", "node3"},
+			want:     1,
+		},
+		{
+			name:     "one node removed",
+			previous: []string{"node1", "node2"},
+			current:  []string{"node2"},
+			want:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countRemovedNodes(tt.previous, tt.current); got != tt.want {
+				t.Errorf("countRemovedNodes() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+// maxHistoryEntries maximum number of entries kept in the ExtendedDaemonSet status history
+const maxHistoryEntries = 20
+
+// computeHistory returns the ExtendedDaemonSet history updated with the rollout transitions
+// between the current status and newStatus. upToDate is the ExtendedDaemonSetReplicaSet of the rollout.
+func computeHistory(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, newStatus *datadoghqv1alpha1.ExtendedDaemonSetStatus, upToDate *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, now time.Time) []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry {
+	oldStatus := &daemonset.Status
+	oldCanaryRS := getCanaryReplicaSet(oldStatus)
+	newCanaryRS := getCanaryReplicaSet(newStatus)
+
+	var actions []datadoghqv1alpha1.ExtendedDaemonSetHistoryAction
+	if isRolloutStarted(oldStatus.Rollout, newStatus.Rollout) {
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolloutStarted)
+	}
+	if newCanaryRS != "" && newCanaryRS != oldCanaryRS {
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryStarted)
+	}
+	switch {
+	case oldStatus.State != datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused && newStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused:
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryPaused)
+	case oldStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused && newStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanary:
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryUnpaused)
+	case oldStatus.State != datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed && newStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed:
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryFailed)
+	case oldStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed && newStatus.State != datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed:
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryReset)
+	case oldStatus.State != datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed && newStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed:
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRollingUpdateFailed)
+	case oldStatus.State != datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdatePaused && newStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdatePaused:
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRollingUpdatePaused)
+	case oldStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdatePaused && newStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning:
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRollingUpdateResumed)
+	case oldCanaryRS != "" && newCanaryRS == "" && newStatus.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning:
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryValidated)
+	}
+	if isRolledBack(oldStatus.Rollout, newStatus.Rollout) {
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolledBack)
+	}
+	if isRolloutCompleted(oldStatus.Rollout, newStatus.Rollout) {
+		actions = append(actions, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolloutComplete)
+	}
+	if len(actions) == 0 {
+		return oldStatus.History
+	}
+
+	history := append([]datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{}, oldStatus.History...)
+	for _, action := range actions {
+		entry := datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+			Time:             metav1.NewTime(now),
+			Action:           action,
+			ActiveReplicaSet: newStatus.ActiveReplicaSet,
+			CanaryReplicaSet: newCanaryRS,
+		}
+		if entry.CanaryReplicaSet == "" {
+			entry.CanaryReplicaSet = oldCanaryRS
+		}
+		if action == datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolloutStarted {
+			entry.ReplicaSet = newStatus.Rollout.ReplicaSet
+			if upToDate != nil && upToDate.Name == entry.ReplicaSet {
+				entry.ChangeCause = upToDate.Annotations[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetChangeCauseAnnotationKey]
+			}
+		}
+		entry.Actor, entry.Reason = getHistoryActorAndReason(daemonset, newStatus, &entry)
+		history = append(history, entry)
+	}
+	if len(history) > maxHistoryEntries {
+		history = history[len(history)-maxHistoryEntries:]
+	}
+	return history
+}
+
+// getHistoryActorAndReason returns who requested the transition and why.
+// The actor and reason annotations stamped by the kubectl plugin are only used if they were set for this action
+// on the same replicaset, so that a stale annotation of a previous rollout is not recorded again.
+func getHistoryActorAndReason(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, newStatus *datadoghqv1alpha1.ExtendedDaemonSetStatus, entry *datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry) (string, string) {
+	annotations := daemonset.GetAnnotations()
+	entryReplicaSet := entry.CanaryReplicaSet
+	if entryReplicaSet == "" {
+		entryReplicaSet = entry.ActiveReplicaSet
+	}
+	if annotations[datadoghqv1alpha1.ExtendedDaemonSetActionAnnotationKey] == string(entry.Action) && annotations[datadoghqv1alpha1.ExtendedDaemonSetActionReplicaSetAnnotationKey] == entryReplicaSet {
+		return annotations[datadoghqv1alpha1.ExtendedDaemonSetActionActorAnnotationKey], annotations[datadoghqv1alpha1.ExtendedDaemonSetActionReasonAnnotationKey]
+	}
+
+	switch entry.Action {
+	case datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryStarted, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolloutComplete:
+		return datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController, ""
+	case datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryValidated:
+		if annotations[datadoghqv1alpha1.ExtendedDaemonSetCanaryValidAnnotationKey] != entry.CanaryReplicaSet {
+			return datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController, "canary duration elapsed"
+		}
+	case datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRollingUpdateFailed:
+		return datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController, string(newStatus.Reason)
+	case datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRollingUpdatePaused:
+		if newStatus.Reason == datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded {
+			return datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController, string(newStatus.Reason)
+		}
+	case datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolledBack:
+		if daemonset.Status.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed {
+			// automatic rollback of the failure policy
+			return datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController, string(daemonset.Status.Reason)
+		}
+	}
+	return "", ""
+}
+
+// isRolloutStarted returns true if the rollout of a new replicaset replacing the active one started.
+// A rollback is recorded as RolledBack.
+func isRolloutStarted(previous, rollout *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout) bool {
+	if rollout == nil || rollout.PreviousReplicaSet == "" || (previous != nil && previous.ReplicaSet == rollout.ReplicaSet) {
+		return false
+	}
+	return !isRolledBack(previous, rollout)
+}
+
+// isRolledBack returns true if the replicaset active before the previous rollout is deployed again
+func isRolledBack(previous, rollout *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout) bool {
+	if previous == nil || rollout == nil || previous.PreviousReplicaSet == "" {
+		return false
+	}
+	return rollout.ReplicaSet != previous.ReplicaSet && rollout.ReplicaSet == previous.PreviousReplicaSet
+}
+
+func getCanaryReplicaSet(status *datadoghqv1alpha1.ExtendedDaemonSetStatus) string {
+	if status.Canary == nil {
+		return ""
+	}
+	return status.Canary.ReplicaSet
+}
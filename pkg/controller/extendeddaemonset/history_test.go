@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+)
+
+func Test_computeHistory(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	nowMeta := metav1.NewTime(now)
+
+	newStatus := func(state datadoghqv1alpha1.ExtendedDaemonSetStatusState, canaryRS string, phase datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhase) *datadoghqv1alpha1.ExtendedDaemonSetStatus {
+		status := &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+			ActiveReplicaSet: "foo-1",
+			State:            state,
+			Rollout:          &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{ReplicaSet: "foo-2", Phase: phase},
+		}
+		if phase == datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete {
+			status.Rollout.CompletionTime = &nowMeta
+		}
+		if canaryRS != "" {
+			status.Canary = &datadoghqv1alpha1.ExtendedDaemonSetStatusCanary{ReplicaSet: canaryRS}
+		}
+		return status
+	}
+	actionAnnotations := func(action datadoghqv1alpha1.ExtendedDaemonSetHistoryAction, replicaSet, actor, reason string) map[string]string {
+		return map[string]string{
+			datadoghqv1alpha1.ExtendedDaemonSetActionAnnotationKey:           string(action),
+			datadoghqv1alpha1.ExtendedDaemonSetActionReplicaSetAnnotationKey: replicaSet,
+			datadoghqv1alpha1.ExtendedDaemonSetActionActorAnnotationKey:      actor,
+			datadoghqv1alpha1.ExtendedDaemonSetActionReasonAnnotationKey:     reason,
+		}
+	}
+	const (
+		running      = datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning
+		canary       = datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanary
+		canaryPaused = datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused
+		canaryFailed = datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed
+		phaseCanary  = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary
+		rolling      = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling
+		complete     = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete
+	)
+	previousEntry := datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+		Time:             metav1.NewTime(now.Add(-time.Hour)),
+		Action:           datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolloutComplete,
+		ActiveReplicaSet: "foo-1",
+		Actor:            datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController,
+	}
+	upToDate := test.NewExtendedDaemonSetReplicaSet("bar", "foo-2", &test.NewExtendedDaemonSetReplicaSetOptions{
+		Annotations: map[string]string{datadoghqv1alpha1.ExtendedDaemonSetReplicaSetChangeCauseAnnotationKey: "image daemon: pause:3.0 -> pause:3.1"},
+	})
+	var fullHistory []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry
+	for i := 0; i < maxHistoryEntries; i++ {
+		entry := previousEntry
+		entry.ActiveReplicaSet = fmt.Sprintf("foo-%d", i)
+		fullHistory = append(fullHistory, entry)
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		oldStatus   *datadoghqv1alpha1.ExtendedDaemonSetStatus
+		newStatus   *datadoghqv1alpha1.ExtendedDaemonSetStatus
+		want        []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry
+	}{
+		{
+			name:      "no transition",
+			oldStatus: newStatus(canary, "foo-2", phaseCanary),
+			newStatus: newStatus(canary, "foo-2", phaseCanary),
+		},
+		{
+			name:      "canary started",
+			oldStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{State: running, History: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{previousEntry}},
+			newStatus: newStatus(canary, "foo-2", phaseCanary),
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				previousEntry,
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryStarted, ActiveReplicaSet: "foo-1", CanaryReplicaSet: "foo-2", Actor: "controller"},
+			},
+		},
+		{
+			name:      "rollout started, with the change cause",
+			oldStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{State: running, ActiveReplicaSet: "foo-1"},
+			newStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-1",
+				State:            running,
+				Rollout:          &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{ReplicaSet: "foo-2", PreviousReplicaSet: "foo-1", Phase: rolling},
+			},
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolloutStarted, ActiveReplicaSet: "foo-1", ReplicaSet: "foo-2", ChangeCause: "image daemon: pause:3.0 -> pause:3.1"},
+			},
+		},
+		{
+			name:        "canary paused by a user",
+			annotations: actionAnnotations(datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryPaused, "foo-2", "alice", "high memory usage"),
+			oldStatus:   newStatus(canary, "foo-2", phaseCanary),
+			newStatus:   newStatus(canaryPaused, "foo-2", phaseCanary),
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryPaused, ActiveReplicaSet: "foo-1", CanaryReplicaSet: "foo-2", Actor: "alice", Reason: "high memory usage"},
+			},
+		},
+		{
+			name:        "canary unpaused, actor annotations of another action ignored",
+			annotations: actionAnnotations(datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryPaused, "foo-2", "alice", "high memory usage"),
+			oldStatus:   newStatus(canaryPaused, "foo-2", phaseCanary),
+			newStatus:   newStatus(canary, "foo-2", phaseCanary),
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryUnpaused, ActiveReplicaSet: "foo-1", CanaryReplicaSet: "foo-2"},
+			},
+		},
+		{
+			name:        "canary paused, stale actor annotations of a previous canary ignored",
+			annotations: actionAnnotations(datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryPaused, "foo-0", "alice", "high memory usage"),
+			oldStatus:   newStatus(canary, "foo-2", phaseCanary),
+			newStatus:   newStatus(canaryPaused, "foo-2", phaseCanary),
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryPaused, ActiveReplicaSet: "foo-1", CanaryReplicaSet: "foo-2"},
+			},
+		},
+		{
+			name:        "canary failed",
+			annotations: actionAnnotations(datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryFailed, "foo-2", "bob", ""),
+			oldStatus:   newStatus(canary, "foo-2", phaseCanary),
+			newStatus:   newStatus(canaryFailed, "", phaseCanary),
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryFailed, ActiveReplicaSet: "foo-1", CanaryReplicaSet: "foo-2", Actor: "bob"},
+			},
+		},
+		{
+			name:      "canary validated by the controller",
+			oldStatus: newStatus(canary, "foo-2", phaseCanary),
+			newStatus: newStatus(running, "", rolling),
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryValidated, ActiveReplicaSet: "foo-1", CanaryReplicaSet: "foo-2", Actor: "controller", Reason: "canary duration elapsed"},
+			},
+		},
+		{
+			name: "canary validated by a user",
+			annotations: map[string]string{
+				datadoghqv1alpha1.ExtendedDaemonSetCanaryValidAnnotationKey: "foo-2",
+			},
+			oldStatus: newStatus(canary, "foo-2", phaseCanary),
+			newStatus: newStatus(running, "", rolling),
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryValidated, ActiveReplicaSet: "foo-1", CanaryReplicaSet: "foo-2"},
+			},
+		},
+		{
+			name:      "rolling update failed",
+			oldStatus: newStatus(running, "", rolling),
+			newStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-2",
+				State:            datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed,
+				Reason:           datadoghqv1alpha1.ExtendedDaemonSetStatusReasonPodsRestarting,
+				Rollout:          newStatus(running, "", rolling).Rollout,
+			},
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRollingUpdateFailed, ActiveReplicaSet: "foo-2", Actor: "controller", Reason: "PodsRestarting"},
+			},
+		},
+		{
+			name:      "rolling update paused by the progress deadline",
+			oldStatus: newStatus(running, "", rolling),
+			newStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-1",
+				State:            datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdatePaused,
+				Reason:           datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded,
+				Rollout:          newStatus(running, "", rolling).Rollout,
+			},
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRollingUpdatePaused, ActiveReplicaSet: "foo-1", Actor: "controller", Reason: "ProgressDeadlineExceeded"},
+			},
+		},
+		{
+			name: "rolling update resumed",
+			oldStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-1",
+				State:            datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdatePaused,
+				Reason:           datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded,
+				Rollout:          newStatus(running, "", rolling).Rollout,
+			},
+			newStatus: newStatus(running, "", rolling),
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRollingUpdateResumed, ActiveReplicaSet: "foo-1"},
+			},
+		},
+		{
+			name: "rolled back by the controller",
+			oldStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-2",
+				State:            datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed,
+				Reason:           datadoghqv1alpha1.ExtendedDaemonSetStatusReasonPodsRestarting,
+				Rollout:          &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{ReplicaSet: "foo-2", PreviousReplicaSet: "foo-1", Phase: rolling},
+			},
+			newStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-1",
+				State:            running,
+				Rollout:          &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{ReplicaSet: "foo-1", PreviousReplicaSet: "foo-2", Phase: rolling},
+			},
+			want: []datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{
+				{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolledBack, ActiveReplicaSet: "foo-1", Actor: "controller", Reason: "PodsRestarting"},
+			},
+		},
+		{
+			name:      "rollout complete, history bounded",
+			oldStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{State: running, ActiveReplicaSet: "foo-1", Rollout: newStatus(running, "", rolling).Rollout, History: fullHistory},
+			newStatus: newStatus(running, "", complete),
+			want: append(append([]datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{}, fullHistory[1:]...),
+				datadoghqv1alpha1.ExtendedDaemonSetStatusHistoryEntry{Time: nowMeta, Action: datadoghqv1alpha1.ExtendedDaemonSetHistoryActionRolloutComplete, ActiveReplicaSet: "foo-1", Actor: "controller"},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemonset := test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{Annotations: tt.annotations, Status: tt.oldStatus})
+			got := computeHistory(daemonset, tt.newStatus, upToDate, now)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("computeHistory() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
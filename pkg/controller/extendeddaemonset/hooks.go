@@ -0,0 +1,357 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/affinity"
+)
+
+// hookTypes lifecycle hooks, in their execution order
+var hookTypes = []datadoghqv1alpha1.ExtendedDaemonSetHookType{
+	datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary,
+	datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification,
+	datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout,
+	datadoghqv1alpha1.ExtendedDaemonSetHookTypePostRollout,
+}
+
+// manageHooks creates the Jobs of the lifecycle hooks that should start, and updates the hooks status
+// from the Jobs status. If a hook configured with the Abort failure policy fails before the rolling update,
+// the canary deployment is marked as failed.
+// Returns the ExtendedDaemonSet updated with the hooks status.
+func (r *ReconcileExtendedDaemonSet) manageHooks(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, activeRS, upToDateRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, now time.Time) (*datadoghqv1alpha1.ExtendedDaemonSet, error) {
+	if daemonset.Spec.Strategy.Hooks == nil && len(daemonset.Status.Hooks) == 0 {
+		return daemonset, nil
+	}
+
+	newDaemonset := daemonset.DeepCopy()
+	// only the hooks of the up-to-date replicaset are kept
+	newDaemonset.Status.Hooks = nil
+	for _, status := range daemonset.Status.Hooks {
+		if status.ReplicaSet == upToDateRS.Name {
+			newDaemonset.Status.Hooks = append(newDaemonset.Status.Hooks, status)
+		}
+	}
+
+	var completedHooks []datadoghqv1alpha1.ExtendedDaemonSetStatusHook
+	for _, hookType := range hookTypes {
+		hook := getHook(daemonset, hookType)
+		if hook == nil {
+			continue
+		}
+		status := getHookStatus(&newDaemonset.Status, hookType, upToDateRS.Name)
+		if status == nil {
+			if !shouldStartHook(newDaemonset, hookType, activeRS, upToDateRS, now) {
+				continue
+			}
+			newDaemonset.Status.Hooks = append(newDaemonset.Status.Hooks, datadoghqv1alpha1.ExtendedDaemonSetStatusHook{
+				Type:       hookType,
+				ReplicaSet: upToDateRS.Name,
+				Phase:      datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning,
+				Jobs:       getHookJobNames(newDaemonset, hookType, upToDateRS),
+				StartTime:  metav1.NewTime(now),
+			})
+			status = &newDaemonset.Status.Hooks[len(newDaemonset.Status.Hooks)-1]
+			logger.Info("Starting lifecycle hook", "hook", hookType, "replicaSet", upToDateRS.Name)
+		}
+		if status.Phase != datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning {
+			continue
+		}
+
+		phase, message, err := r.syncHookJobs(newDaemonset, hook, status, upToDateRS)
+		if err != nil {
+			return daemonset, err
+		}
+		if phase != datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning {
+			status.Phase = phase
+			status.Message = message
+			completionTime := metav1.NewTime(now)
+			status.CompletionTime = &completionTime
+			completedHooks = append(completedHooks, *status)
+		}
+	}
+
+	if apiequality.Semantic.DeepEqual(daemonset.Status.Hooks, newDaemonset.Status.Hooks) {
+		return daemonset, nil
+	}
+	if err := r.client.Status().Update(context.TODO(), newDaemonset); err != nil {
+		return daemonset, err
+	}
+
+	var canaryFailedReason string
+	for _, status := range completedHooks {
+		metrics.IncHookCompleted(daemonset.Namespace, daemonset.Name, string(status.Type), status.ReplicaSet, status.Phase == datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded)
+		if status.Phase == datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded {
+			r.recorder.Event(daemonset, corev1.EventTypeNormal, "HookSucceeded", fmt.Sprintf("%s hook of %s succeeded", status.Type, status.ReplicaSet))
+			continue
+		}
+		r.recorder.Event(daemonset, corev1.EventTypeWarning, "HookFailed", fmt.Sprintf("%s hook of %s failed: %s", status.Type, status.ReplicaSet, status.Message))
+		if isCanaryFailedByHook(newDaemonset, status.Type) && canaryFailedReason == "" {
+			canaryFailedReason = fmt.Sprintf("%s hook failed", status.Type)
+		}
+	}
+
+	if canaryFailedReason != "" && !IsCanaryDeploymentFailed(newDaemonset.GetAnnotations()) {
+		logger.Info("Lifecycle hook failed, failing the canary deployment", "reason", canaryFailedReason)
+		if newDaemonset.Annotations == nil {
+			newDaemonset.Annotations = make(map[string]string)
+		}
+		newDaemonset.Annotations[datadoghqv1alpha1.ExtendedDaemonSetCanaryFailedAnnotationKey] = "true"
+		datadoghqv1alpha1.SetExtendedDaemonSetActionAnnotations(newDaemonset, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryFailed, datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController, canaryFailedReason)
+		if err := r.client.Update(context.TODO(), newDaemonset); err != nil {
+			return newDaemonset, err
+		}
+	}
+	return newDaemonset, nil
+}
+
+// syncHookJobs creates the missing Jobs of a running hook, and returns the hook phase computed from the Jobs status
+func (r *ReconcileExtendedDaemonSet) syncHookJobs(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, hook *datadoghqv1alpha1.ExtendedDaemonSetHook, status *datadoghqv1alpha1.ExtendedDaemonSetStatusHook, upToDateRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) (datadoghqv1alpha1.ExtendedDaemonSetHookPhase, string, error) {
+	phase := datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded
+	var failedJobs []string
+	for id, jobName := range status.Jobs {
+		job := &batchv1.Job{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: daemonset.Namespace, Name: jobName}, job)
+		if err != nil && !errors.IsNotFound(err) {
+			return "", "", err
+		}
+		if errors.IsNotFound(err) {
+			var nodeName string
+			if status.Type == datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification && daemonset.Status.Canary != nil && id < len(daemonset.Status.Canary.Nodes) {
+				nodeName = daemonset.Status.Canary.Nodes[id]
+			}
+			job = newHookJob(daemonset, hook, status.Type, jobName, nodeName, upToDateRS)
+			if err = controllerutil.SetControllerReference(upToDateRS, job, r.scheme); err != nil {
+				return "", "", err
+			}
+			if err = r.client.Create(context.TODO(), job); err != nil && !errors.IsAlreadyExists(err) {
+				return "", "", err
+			}
+		}
+
+		switch getJobPhase(job) {
+		case datadoghqv1alpha1.ExtendedDaemonSetHookPhaseFailed:
+			failedJobs = append(failedJobs, jobName)
+		case datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning:
+			if phase == datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded {
+				phase = datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning
+			}
+		}
+	}
+	if len(failedJobs) > 0 {
+		return datadoghqv1alpha1.ExtendedDaemonSetHookPhaseFailed, fmt.Sprintf("failed jobs: %s", strings.Join(failedJobs, ", ")), nil
+	}
+	return phase, "", nil
+}
+
+// newHookJob returns the Job of a hook created from the hook template.
+// If nodeName is not empty, the Job pod is pinned to this Node.
+func newHookJob(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, hook *datadoghqv1alpha1.ExtendedDaemonSetHook, hookType datadoghqv1alpha1.ExtendedDaemonSetHookType, name, nodeName string, upToDateRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) *batchv1.Job {
+	template := hook.Template.DeepCopy()
+	job := &batchv1.Job{
+		ObjectMeta: template.ObjectMeta,
+		Spec:       template.Spec,
+	}
+	job.Name = name
+	job.Namespace = daemonset.Namespace
+	if job.Labels == nil {
+		job.Labels = make(map[string]string)
+	}
+	job.Labels[datadoghqv1alpha1.ExtendedDaemonSetNameLabelKey] = daemonset.Name
+	job.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNameLabelKey] = upToDateRS.Name
+	job.Labels[datadoghqv1alpha1.ExtendedDaemonSetHookLabelKey] = string(hookType)
+	if nodeName != "" {
+		job.Spec.Template.Spec.Affinity = affinity.ReplaceNodeNameNodeAffinity(job.Spec.Template.Spec.Affinity, nodeName)
+	}
+	if job.Spec.Template.Spec.RestartPolicy == "" {
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	return job
+}
+
+// getHookJobNames returns the names of the Jobs to create for a hook.
+// The CanaryVerification hook runs one Job by canary Node.
+func getHookJobNames(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, hookType datadoghqv1alpha1.ExtendedDaemonSetHookType, upToDateRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) []string {
+	prefix := fmt.Sprintf("%s-%s", upToDateRS.Name, hookJobSuffix(hookType))
+	if hookType != datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification {
+		return []string{hookJobName(prefix)}
+	}
+	var names []string
+	for id := range daemonset.Status.Canary.Nodes {
+		names = append(names, hookJobName(fmt.Sprintf("%s-%d", prefix, id)))
+	}
+	return names
+}
+
+// hookJobName truncates the name to the maximum length of the job-name label set by the Job controller
+// on its pods, and adds a hash of the full name to keep the names unique.
+func hookJobName(name string) string {
+	if len(name) <= validation.LabelValueMaxLength {
+		return name
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	suffix := rand.SafeEncodeString(fmt.Sprint(hash.Sum32()))
+	return fmt.Sprintf("%s-%s", name[:validation.LabelValueMaxLength-len(suffix)-1], suffix)
+}
+
+func hookJobSuffix(hookType datadoghqv1alpha1.ExtendedDaemonSetHookType) string {
+	switch hookType {
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary:
+		return "pre-canary"
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification:
+		return "canary-verification"
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout:
+		return "pre-rollout"
+	default:
+		return "post-rollout"
+	}
+}
+
+// getJobPhase returns the hook phase corresponding to the Job conditions
+func getJobPhase(job *batchv1.Job) datadoghqv1alpha1.ExtendedDaemonSetHookPhase {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded
+		case batchv1.JobFailed:
+			return datadoghqv1alpha1.ExtendedDaemonSetHookPhaseFailed
+		}
+	}
+	return datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning
+}
+
+// shouldStartHook returns true if the hook should start for the up-to-date replicaset
+func shouldStartHook(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, hookType datadoghqv1alpha1.ExtendedDaemonSetHookType, activeRS, upToDateRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, now time.Time) bool {
+	if hookType == datadoghqv1alpha1.ExtendedDaemonSetHookTypePostRollout {
+		rollout := daemonset.Status.Rollout
+		return activeRS != nil && activeRS.Name == upToDateRS.Name && rollout != nil && rollout.ReplicaSet == upToDateRS.Name &&
+			rollout.PreviousReplicaSet != "" && rollout.Phase == datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete
+	}
+
+	// the other hooks only run during a rollout, except when the ExtendedDaemonSet is rolled back
+	if activeRS == nil || activeRS.Name == upToDateRS.Name {
+		return false
+	}
+	if isFailed, _ := IsRollingUpdateFailed(activeRS); isFailed && isRollbackReplicaSet(daemonset, activeRS, upToDateRS) {
+		return false
+	}
+	canary := daemonset.Spec.Strategy.Canary
+	if canary != nil && IsCanaryDeploymentFailed(daemonset.GetAnnotations()) {
+		return false
+	}
+
+	switch hookType {
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary:
+		return canary != nil
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification:
+		return canary != nil && isHookCompleted(daemonset, datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary, upToDateRS.Name) &&
+			isCanaryAvailable(daemonset, upToDateRS)
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout:
+		if canary == nil {
+			return true
+		}
+		isDone, _ := isCanaryDeploymentDone(daemonset, upToDateRS, now)
+		return isDone
+	}
+	return false
+}
+
+// isCanaryAvailable returns true if the canary pods of the up-to-date replicaset are available
+func isCanaryAvailable(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, upToDateRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) bool {
+	status := daemonset.Status.Canary
+	return status != nil && status.ReplicaSet == upToDateRS.Name && len(status.Nodes) > 0 &&
+		upToDateRS.Status.Desired > 0 && upToDateRS.Status.Available >= upToDateRS.Status.Desired
+}
+
+// isCanaryDeploymentDone returns true if the canary deployment has been declared valid, or if it has ended
+// without being paused and its hooks are completed.
+// If the canary duration is not completed, it also returns the remaining duration.
+func isCanaryDeploymentDone(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, upToDateRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, now time.Time) (bool, time.Duration) {
+	dsAnnotations := daemonset.GetAnnotations()
+	isEnded, requeueAfter := IsCanaryDeploymentEnded(daemonset.Spec.Strategy.Canary, upToDateRS, now)
+	isPaused, _ := IsCanaryDeploymentPaused(dsAnnotations)
+	if IsCanaryDeploymentValid(dsAnnotations, upToDateRS.GetName()) {
+		return true, requeueAfter
+	}
+	isDone := !isPaused && isEnded &&
+		isHookCompleted(daemonset, datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary, upToDateRS.Name) &&
+		isHookCompleted(daemonset, datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification, upToDateRS.Name)
+	return isDone, requeueAfter
+}
+
+// isHookCompleted returns true if the hook is not defined, or if it succeeded or failed with the Ignore failure policy
+func isHookCompleted(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, hookType datadoghqv1alpha1.ExtendedDaemonSetHookType, rsName string) bool {
+	hook := getHook(daemonset, hookType)
+	if hook == nil {
+		return true
+	}
+	status := getHookStatus(&daemonset.Status, hookType, rsName)
+	if status == nil {
+		return false
+	}
+	return status.Phase == datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded ||
+		(status.Phase == datadoghqv1alpha1.ExtendedDaemonSetHookPhaseFailed && hook.FailurePolicy == datadoghqv1alpha1.ExtendedDaemonSetHookFailurePolicyIgnore)
+}
+
+// isCanaryFailedByHook returns true if the failure of the hook fails the canary deployment
+func isCanaryFailedByHook(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, hookType datadoghqv1alpha1.ExtendedDaemonSetHookType) bool {
+	hook := getHook(daemonset, hookType)
+	if hook == nil || hook.FailurePolicy == datadoghqv1alpha1.ExtendedDaemonSetHookFailurePolicyIgnore {
+		return false
+	}
+	return daemonset.Spec.Strategy.Canary != nil && hookType != datadoghqv1alpha1.ExtendedDaemonSetHookTypePostRollout
+}
+
+// getHook returns the hook definition, nil if it is not defined
+func getHook(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, hookType datadoghqv1alpha1.ExtendedDaemonSetHookType) *datadoghqv1alpha1.ExtendedDaemonSetHook {
+	hooks := daemonset.Spec.Strategy.Hooks
+	if hooks == nil {
+		return nil
+	}
+	switch hookType {
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary:
+		return hooks.PreCanary
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification:
+		return hooks.CanaryVerification
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout:
+		return hooks.PreRollout
+	case datadoghqv1alpha1.ExtendedDaemonSetHookTypePostRollout:
+		return hooks.PostRollout
+	}
+	return nil
+}
+
+// getHookStatus returns the status of the hook for the replicaset, nil if the hook didn't start
+func getHookStatus(status *datadoghqv1alpha1.ExtendedDaemonSetStatus, hookType datadoghqv1alpha1.ExtendedDaemonSetHookType, rsName string) *datadoghqv1alpha1.ExtendedDaemonSetStatusHook {
+	for id := range status.Hooks {
+		if status.Hooks[id].Type == hookType && status.Hooks[id].ReplicaSet == rsName {
+			return &status.Hooks[id]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	test "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+)
+
+func Test_shouldStartHook(t *testing.T) {
+	now := time.Now()
+	creationTime := now.Add(-10 * time.Minute)
+	intString1 := intstr.FromInt(1)
+	canary := &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyCanary{
+		Replicas: &intString1,
+		Duration: &metav1.Duration{Duration: 5 * time.Minute},
+	}
+	hook := &datadoghqv1alpha1.ExtendedDaemonSetHook{FailurePolicy: datadoghqv1alpha1.ExtendedDaemonSetHookFailurePolicyAbort}
+	hooks := &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyHooks{
+		PreCanary:          hook,
+		CanaryVerification: hook,
+		PreRollout:         hook,
+		PostRollout:        hook,
+	}
+
+	activeRS := test.NewExtendedDaemonSetReplicaSet("bar", "foo-old", &test.NewExtendedDaemonSetReplicaSetOptions{CreationTime: &creationTime})
+	upToDateRS := test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", &test.NewExtendedDaemonSetReplicaSetOptions{
+		CreationTime: &creationTime,
+		Status:       &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{Desired: 1, Available: 1},
+	})
+	newDaemonset := func(canary *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyCanary, status *datadoghqv1alpha1.ExtendedDaemonSetStatus) *datadoghqv1alpha1.ExtendedDaemonSet {
+		daemonset := test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{Canary: canary, Status: status})
+		daemonset.Spec.Strategy.Hooks = hooks
+		return daemonset
+	}
+	succeeded := func(hookType datadoghqv1alpha1.ExtendedDaemonSetHookType) datadoghqv1alpha1.ExtendedDaemonSetStatusHook {
+		return datadoghqv1alpha1.ExtendedDaemonSetStatusHook{Type: hookType, ReplicaSet: "foo-1", Phase: datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded}
+	}
+	canaryStatus := &datadoghqv1alpha1.ExtendedDaemonSetStatusCanary{ReplicaSet: "foo-1", Nodes: []string{"node1"}}
+
+	tests := []struct {
+		name      string
+		daemonset *datadoghqv1alpha1.ExtendedDaemonSet
+		hookType  datadoghqv1alpha1.ExtendedDaemonSetHookType
+		activeRS  *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
+		want      bool
+	}{
+		{
+			name:      "PreCanary, no rollout",
+			daemonset: newDaemonset(canary, &datadoghqv1alpha1.ExtendedDaemonSetStatus{ActiveReplicaSet: "foo-1"}),
+			hookType:  datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary,
+			activeRS:  upToDateRS,
+			want:      false,
+		},
+		{
+			name:      "PreCanary, rollout with canary",
+			daemonset: newDaemonset(canary, &datadoghqv1alpha1.ExtendedDaemonSetStatus{ActiveReplicaSet: "foo-old"}),
+			hookType:  datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary,
+			activeRS:  activeRS,
+			want:      true,
+		},
+		{
+			name:      "PreCanary, rollout without canary",
+			daemonset: newDaemonset(nil, &datadoghqv1alpha1.ExtendedDaemonSetStatus{ActiveReplicaSet: "foo-old"}),
+			hookType:  datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary,
+			activeRS:  activeRS,
+			want:      false,
+		},
+		{
+			name:      "CanaryVerification, PreCanary not completed",
+			daemonset: newDaemonset(canary, &datadoghqv1alpha1.ExtendedDaemonSetStatus{ActiveReplicaSet: "foo-old", Canary: canaryStatus}),
+			hookType:  datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification,
+			activeRS:  activeRS,
+			want:      false,
+		},
+		{
+			name: "CanaryVerification, canary pods available",
+			daemonset: newDaemonset(canary, &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-old",
+				Canary:           canaryStatus,
+				Hooks:            []datadoghqv1alpha1.ExtendedDaemonSetStatusHook{succeeded(datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary)},
+			}),
+			hookType: datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification,
+			activeRS: activeRS,
+			want:     true,
+		},
+		{
+			name: "PreRollout, canary verification not completed",
+			daemonset: newDaemonset(canary, &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-old",
+				Canary:           canaryStatus,
+				Hooks:            []datadoghqv1alpha1.ExtendedDaemonSetStatusHook{succeeded(datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary)},
+			}),
+			hookType: datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout,
+			activeRS: activeRS,
+			want:     false,
+		},
+		{
+			name: "PreRollout, canary done",
+			daemonset: newDaemonset(canary, &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-old",
+				Canary:           canaryStatus,
+				Hooks: []datadoghqv1alpha1.ExtendedDaemonSetStatusHook{
+					succeeded(datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary),
+					succeeded(datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification),
+				},
+			}),
+			hookType: datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout,
+			activeRS: activeRS,
+			want:     true,
+		},
+		{
+			name:      "PreRollout, rollout without canary",
+			daemonset: newDaemonset(nil, &datadoghqv1alpha1.ExtendedDaemonSetStatus{ActiveReplicaSet: "foo-old"}),
+			hookType:  datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout,
+			activeRS:  activeRS,
+			want:      true,
+		},
+		{
+			name:      "PostRollout, rollout in progress",
+			daemonset: newDaemonset(nil, &datadoghqv1alpha1.ExtendedDaemonSetStatus{ActiveReplicaSet: "foo-old"}),
+			hookType:  datadoghqv1alpha1.ExtendedDaemonSetHookTypePostRollout,
+			activeRS:  activeRS,
+			want:      false,
+		},
+		{
+			name: "PostRollout, rollout complete",
+			daemonset: newDaemonset(nil, &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+				ActiveReplicaSet: "foo-1",
+				Rollout: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+					ReplicaSet:         "foo-1",
+					PreviousReplicaSet: "foo-old",
+					Phase:              datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete,
+				},
+			}),
+			hookType: datadoghqv1alpha1.ExtendedDaemonSetHookTypePostRollout,
+			activeRS: upToDateRS,
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldStartHook(tt.daemonset, tt.hookType, tt.activeRS, upToDateRS, now); got != tt.want {
+				t.Errorf("shouldStartHook() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileExtendedDaemonSet_manageHooks(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	log = logf.Log.WithName("TestReconcileExtendedDaemonSet_manageHooks")
+
+	s := scheme.Scheme
+	s.AddKnownTypes(datadoghqv1alpha1.SchemeGroupVersion, &datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{})
+	s.AddKnownTypes(datadoghqv1alpha1.SchemeGroupVersion, &datadoghqv1alpha1.ExtendedDaemonSet{})
+
+	now := time.Now().Truncate(time.Second)
+	intString1 := intstr.FromInt(1)
+	canary := &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyCanary{
+		Replicas: &intString1,
+		Duration: &metav1.Duration{Duration: 5 * time.Minute},
+	}
+	hook := &datadoghqv1alpha1.ExtendedDaemonSetHook{FailurePolicy: datadoghqv1alpha1.ExtendedDaemonSetHookFailurePolicyAbort}
+	hook.Template.Spec.Template.Spec.Containers = []corev1.Container{{Name: "check", Image: "busybox"}}
+
+	activeRS := test.NewExtendedDaemonSetReplicaSet("bar", "foo-old", nil)
+	upToDateRS := test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", nil)
+	newDaemonset := func(canary *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyCanary, hooks *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyHooks, statusHooks []datadoghqv1alpha1.ExtendedDaemonSetStatusHook) *datadoghqv1alpha1.ExtendedDaemonSet {
+		daemonset := test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{
+			Canary: canary,
+			Status: &datadoghqv1alpha1.ExtendedDaemonSetStatus{ActiveReplicaSet: "foo-old", Hooks: statusHooks},
+		})
+		daemonset.Spec.Strategy.Hooks = hooks
+		return daemonset
+	}
+	newJob := func(name string, conditionType batchv1.JobConditionType) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: name},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	running := func(hookType datadoghqv1alpha1.ExtendedDaemonSetHookType, job string) []datadoghqv1alpha1.ExtendedDaemonSetStatusHook {
+		return []datadoghqv1alpha1.ExtendedDaemonSetStatusHook{
+			{Type: hookType, ReplicaSet: "foo-1", Phase: datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning, Jobs: []string{job}, StartTime: metav1.NewTime(now)},
+		}
+	}
+
+	daemonsetPreRollout := newDaemonset(nil, &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyHooks{PreRollout: hook}, nil)
+	daemonsetPreRolloutRunning := newDaemonset(nil, &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyHooks{PreRollout: hook}, running(datadoghqv1alpha1.ExtendedDaemonSetHookTypePreRollout, "foo-1-pre-rollout"))
+	daemonsetPreCanaryRunning := newDaemonset(canary, &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyHooks{PreCanary: hook}, running(datadoghqv1alpha1.ExtendedDaemonSetHookTypePreCanary, "foo-1-pre-canary"))
+
+	tests := []struct {
+		name             string
+		daemonset        *datadoghqv1alpha1.ExtendedDaemonSet
+		objects          []runtime.Object
+		wantPhase        datadoghqv1alpha1.ExtendedDaemonSetHookPhase
+		wantJob          string
+		wantCanaryFailed bool
+	}{
+		{
+			name:      "PreRollout hook started",
+			daemonset: daemonsetPreRollout,
+			wantPhase: datadoghqv1alpha1.ExtendedDaemonSetHookPhaseRunning,
+			wantJob:   "foo-1-pre-rollout",
+		},
+		{
+			name:      "PreRollout hook succeeded",
+			daemonset: daemonsetPreRolloutRunning,
+			objects:   []runtime.Object{newJob("foo-1-pre-rollout", batchv1.JobComplete)},
+			wantPhase: datadoghqv1alpha1.ExtendedDaemonSetHookPhaseSucceeded,
+			wantJob:   "foo-1-pre-rollout",
+		},
+		{
+			name:             "PreCanary hook failed, canary failed",
+			daemonset:        daemonsetPreCanaryRunning,
+			objects:          []runtime.Object{newJob("foo-1-pre-canary", batchv1.JobFailed)},
+			wantPhase:        datadoghqv1alpha1.ExtendedDaemonSetHookPhaseFailed,
+			wantJob:          "foo-1-pre-canary",
+			wantCanaryFailed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ReconcileExtendedDaemonSet{
+				client:   fake.NewFakeClient(append(tt.objects, tt.daemonset.DeepCopy(), activeRS, upToDateRS)...),
+				scheme:   s,
+				recorder: record.NewFakeRecorder(10),
+			}
+			got, err := r.manageHooks(log, tt.daemonset.DeepCopy(), activeRS, upToDateRS, now)
+			if err != nil {
+				t.Fatalf("ReconcileExtendedDaemonSet.manageHooks() unexpected error: %v", err)
+			}
+			if len(got.Status.Hooks) != 1 {
+				t.Fatalf("ReconcileExtendedDaemonSet.manageHooks() hooks = %#v, want 1 hook", got.Status.Hooks)
+			}
+			if got.Status.Hooks[0].Phase != tt.wantPhase {
+				t.Errorf("ReconcileExtendedDaemonSet.manageHooks() phase = %v, want %v", got.Status.Hooks[0].Phase, tt.wantPhase)
+			}
+			if isFailed := IsCanaryDeploymentFailed(got.GetAnnotations()); isFailed != tt.wantCanaryFailed {
+				t.Errorf("ReconcileExtendedDaemonSet.manageHooks() canary failed = %v, want %v", isFailed, tt.wantCanaryFailed)
+			}
+
+			job := &batchv1.Job{}
+			if err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: tt.wantJob}, job); err != nil {
+				t.Fatalf("unable to get the hook Job %s: %v", tt.wantJob, err)
+			}
+			stored := &datadoghqv1alpha1.ExtendedDaemonSet{}
+			if err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo"}, stored); err != nil {
+				t.Fatalf("unable to get the ExtendedDaemonSet: %v", err)
+			}
+			if len(stored.Status.Hooks) != 1 || stored.Status.Hooks[0].Phase != tt.wantPhase {
+				t.Errorf("stored hooks status = %#v, want phase %v", stored.Status.Hooks, tt.wantPhase)
+			}
+		})
+	}
+}
+
+func Test_getHookJobNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		edsName   string
+		rsName    string
+		wantShort string
+	}{
+		{
+			name:      "short name kept",
+			edsName:   "foo",
+			rsName:    "foo-x7k2p",
+			wantShort: "foo-x7k2p-canary-verification-0",
+		},
+		{
+			name:    "long ExtendedDaemonSet name truncated",
+			edsName: "datadog-agent-with-a-very-long-extendeddaemonset-name",
+			rsName:  "datadog-agent-with-a-very-long-extendeddaemonset-name-x7k2p",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemonset := test.NewExtendedDaemonSet("bar", tt.edsName, &test.NewExtendedDaemonSetOptions{
+				Status: &datadoghqv1alpha1.ExtendedDaemonSetStatus{Canary: &datadoghqv1alpha1.ExtendedDaemonSetStatusCanary{Nodes: []string{"node1", "node2"}}},
+			})
+			upToDateRS := test.NewExtendedDaemonSetReplicaSet("bar", tt.rsName, nil)
+
+			names := getHookJobNames(daemonset, datadoghqv1alpha1.ExtendedDaemonSetHookTypeCanaryVerification, upToDateRS)
+			if len(names) != 2 || names[0] == names[1] {
+				t.Fatalf("getHookJobNames() = %v, want 2 different names", names)
+			}
+			if tt.wantShort != "" && names[0] != tt.wantShort {
+				t.Errorf("getHookJobNames() = %s, want %s", names[0], tt.wantShort)
+			}
+			for _, name := range names {
+				if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+					t.Errorf("getHookJobNames() name %s is not a valid job-name label: %v", name, errs)
+				}
+			}
+		})
+	}
+}
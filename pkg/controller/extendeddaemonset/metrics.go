@@ -6,18 +6,16 @@
 package extendeddaemonset
 
 import (
+	"strconv"
+
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils"
-	"github.com/datadog/extendeddaemonset/pkg/generated/clientset/versioned"
-	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/tools/cache"
 	ksmetric "k8s.io/kube-state-metrics/pkg/metric"
 )
 
@@ -32,32 +30,35 @@ const (
 	extendeddaemonsetStatusCanaryActivated          = "eds_status_canary_activated"
 	extendeddaemonsetStatusCanaryNumberOfNodes      = "eds_status_canary_node_number"
 	extendeddaemonsetLabels                         = "eds_labels"
+	extendeddaemonsetStatusState                    = "eds_status_state"
+	extendeddaemonsetCanaryNode                     = "eds_canary_node"
+	extendeddaemonsetPodInfo                        = "eds_pod_info"
+	extendeddaemonsetRolloutPhase                   = "eds_status_rollout_phase"
+	extendeddaemonsetRolloutPercentComplete         = "eds_status_rollout_percent_complete"
+	extendeddaemonsetRolloutStartTime               = "eds_status_rollout_start_time"
+	extendeddaemonsetRolloutEstimatedCompletionTime = "eds_status_rollout_estimated_completion_time"
 )
 
 // AddMetrics add ExtentedDaemonSet metrics
 func AddMetrics(mgr manager.Manager, h metrics.Handler) error {
 	families := generateMetricFamilies()
 
-	clientset, err := versioned.NewForConfig(mgr.GetConfig())
+	// the metrics store is fed by the manager informer, to not duplicate the watch
+	informer, err := mgr.GetCache().GetInformer(&datadoghqv1alpha1.ExtendedDaemonSet{})
 	if err != nil {
 		return err
 	}
-
-	namespace, err := k8sutil.GetWatchNamespace()
-	if err != nil {
+	if err = h.RegisterStore(families, informer); err != nil {
 		return err
 	}
 
-	lw := &cache.ListWatch{
-		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
-			return clientset.DatadoghqV1alpha1().ExtendedDaemonSets(namespace).List(opts)
-		},
-		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
-			return clientset.DatadoghqV1alpha1().ExtendedDaemonSets(namespace).Watch(opts)
-		},
+	// the pods metrics have their own store, updated on the pods events
+	podInformer, err := mgr.GetCache().GetInformer(&corev1.Pod{})
+	if err != nil {
+		return err
 	}
 
-	return h.RegisterStore(families, &datadoghqv1alpha1.ExtendedDaemonSet{}, lw)
+	return h.RegisterStore(generatePodMetricFamilies(), podInformer)
 }
 
 func generateMetricFamilies() []ksmetric.FamilyGenerator {
@@ -261,5 +262,151 @@ func generateMetricFamilies() []ksmetric.FamilyGenerator {
 				}
 			},
 		},
+		{
+			Name: extendeddaemonsetStatusState,
+			Type: ksmetric.Gauge,
+			Help: "The state of the ExtendedDaemonSet and the reason of a paused canary deployment",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				eds := obj.(*datadoghqv1alpha1.ExtendedDaemonSet)
+				labelKeys, labelValues := utils.GetLabelsValues(&eds.ObjectMeta)
+				return &ksmetric.Family{
+					Metrics: []*ksmetric.Metric{
+						{
+							Value:       1,
+							LabelKeys:   append(labelKeys, "state", "reason"),
+							LabelValues: append(labelValues, string(eds.Status.State), string(eds.Status.Reason)),
+						},
+					},
+				}
+			},
+		},
+		{
+			Name: extendeddaemonsetCanaryNode,
+			Type: ksmetric.Gauge,
+			Help: "The nodes selected for the canary deployment",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				eds := obj.(*datadoghqv1alpha1.ExtendedDaemonSet)
+				labelKeys, labelValues := utils.GetLabelsValues(&eds.ObjectMeta)
+				var nodeMetrics []*ksmetric.Metric
+				if eds.Status.Canary != nil {
+					for _, node := range eds.Status.Canary.Nodes {
+						nodeMetrics = append(nodeMetrics, &ksmetric.Metric{
+							Value:       1,
+							LabelKeys:   append(labelKeys, "replicaset", "node"),
+							LabelValues: append(labelValues, eds.Status.Canary.ReplicaSet, node),
+						})
+					}
+				}
+				return &ksmetric.Family{
+					Metrics: nodeMetrics,
+				}
+			},
+		},
+		{
+			Name: extendeddaemonsetRolloutPhase,
+			Type: ksmetric.Gauge,
+			Help: "The phase of the current rollout: canary, rolling or complete",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				eds := obj.(*datadoghqv1alpha1.ExtendedDaemonSet)
+				labelKeys, labelValues := utils.GetLabelsValues(&eds.ObjectMeta)
+				family := &ksmetric.Family{}
+				if eds.Status.Rollout != nil {
+					family.Metrics = append(family.Metrics, &ksmetric.Metric{
+						Value:       1,
+						LabelKeys:   append(labelKeys, "replicaset", "phase"),
+						LabelValues: append(labelValues, eds.Status.Rollout.ReplicaSet, string(eds.Status.Rollout.Phase)),
+					})
+				}
+				return family
+			},
+		},
+		{
+			Name: extendeddaemonsetRolloutPercentComplete,
+			Type: ksmetric.Gauge,
+			Help: "The percentage of nodes running an available pod of the rollout ExtendedDaemonSetReplicaSet",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				eds := obj.(*datadoghqv1alpha1.ExtendedDaemonSet)
+				labelKeys, labelValues := utils.GetLabelsValues(&eds.ObjectMeta)
+				family := &ksmetric.Family{}
+				if eds.Status.Rollout != nil {
+					family.Metrics = append(family.Metrics, &ksmetric.Metric{
+						Value:       float64(eds.Status.Rollout.PercentComplete),
+						LabelKeys:   labelKeys,
+						LabelValues: labelValues,
+					})
+				}
+				return family
+			},
+		},
+		{
+			Name: extendeddaemonsetRolloutStartTime,
+			Type: ksmetric.Gauge,
+			Help: "Unix start timestamp of the current rollout",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				eds := obj.(*datadoghqv1alpha1.ExtendedDaemonSet)
+				labelKeys, labelValues := utils.GetLabelsValues(&eds.ObjectMeta)
+				family := &ksmetric.Family{}
+				if eds.Status.Rollout != nil {
+					family.Metrics = append(family.Metrics, &ksmetric.Metric{
+						Value:       float64(eds.Status.Rollout.StartTime.Unix()),
+						LabelKeys:   labelKeys,
+						LabelValues: labelValues,
+					})
+				}
+				return family
+			},
+		},
+		{
+			Name: extendeddaemonsetRolloutEstimatedCompletionTime,
+			Type: ksmetric.Gauge,
+			Help: "Unix timestamp of the estimated end of the current rolling update",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				eds := obj.(*datadoghqv1alpha1.ExtendedDaemonSet)
+				labelKeys, labelValues := utils.GetLabelsValues(&eds.ObjectMeta)
+				family := &ksmetric.Family{}
+				if eds.Status.Rollout != nil && eds.Status.Rollout.EstimatedCompletionTime != nil {
+					family.Metrics = append(family.Metrics, &ksmetric.Metric{
+						Value:       float64(eds.Status.Rollout.EstimatedCompletionTime.Unix()),
+						LabelKeys:   labelKeys,
+						LabelValues: labelValues,
+					})
+				}
+				return family
+			},
+		},
+	}
+}
+
+func generatePodMetricFamilies() []ksmetric.FamilyGenerator {
+	return []ksmetric.FamilyGenerator{
+		{
+			Name: extendeddaemonsetPodInfo,
+			Type: ksmetric.Gauge,
+			Help: "Information about the pods managed by an ExtendedDaemonSet",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				pod := obj.(*corev1.Pod)
+				family := &ksmetric.Family{}
+				if metric := generatePodInfoMetric(pod); metric != nil {
+					family.Metrics = append(family.Metrics, metric)
+				}
+				return family
+			},
+		},
+	}
+}
+
+// generatePodInfoMetric returns the metric of a pod keyed by its ExtendedDaemonSet, with its node, replicaset
+// and if it is a canary pod. It returns nil if the pod is not managed by an ExtendedDaemonSet.
+func generatePodInfoMetric(pod *corev1.Pod) *ksmetric.Metric {
+	edsName, found := pod.Labels[datadoghqv1alpha1.ExtendedDaemonSetNameLabelKey]
+	if !found {
+		return nil
+	}
+
+	labelKeys, labelValues := utils.GetLabelsValues(&metav1.ObjectMeta{Namespace: pod.Namespace, Name: edsName})
+	return &ksmetric.Metric{
+		Value:       1,
+		LabelKeys:   append(labelKeys, "pod", "node", "ers", "canary"),
+		LabelValues: append(labelValues, pod.Name, pod.Spec.NodeName, pod.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNameLabelKey], strconv.FormatBool(pod.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCanaryLabelKey] == "true")),
 	}
 }
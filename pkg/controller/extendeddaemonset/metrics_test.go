@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	ksmetric "k8s.io/kube-state-metrics/pkg/metric"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func Test_generatePodInfoMetric(t *testing.T) {
+	newPod := func(name, nodeName, ers string) *corev1.Pod {
+		return commontest.NewPod("bar", name, nodeName, &commontest.NewPodOptions{
+			Labels: map[string]string{
+				datadoghqv1alpha1.ExtendedDaemonSetNameLabelKey:           "foo",
+				datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNameLabelKey: ers,
+			},
+		})
+	}
+	labelKeys := []string{"namespace", "name", "pod", "node", "ers", "canary"}
+	canaryPod := newPod("foo-2-b", "node2", "foo-2")
+	canaryPod.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCanaryLabelKey] = "true"
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want *ksmetric.Metric
+	}{
+		{
+			name: "pod of an ExtendedDaemonSet",
+			pod:  newPod("foo-1-a", "node1", "foo-1"),
+			want: &ksmetric.Metric{Value: 1, LabelKeys: labelKeys, LabelValues: []string{"bar", "foo", "foo-1-a", "node1", "foo-1", "false"}},
+		},
+		{
+			name: "canary pod",
+			pod:  canaryPod,
+			want: &ksmetric.Metric{Value: 1, LabelKeys: labelKeys, LabelValues: []string{"bar", "foo", "foo-2-b", "node2", "foo-2", "true"}},
+		},
+		{
+			name: "pod not managed by an ExtendedDaemonSet",
+			pod:  commontest.NewPod("bar", "other", "node1", nil),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generatePodInfoMetric(tt.pod)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("generatePodInfoMetric() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilserrors "k8s.io/apimachinery/pkg/util/errors"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/notifier"
+)
+
+// notify sends the notification of the ExtendedDaemonSet state transition, if any
+func (r *ReconcileExtendedDaemonSet) notify(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, newStatus *datadoghqv1alpha1.ExtendedDaemonSetStatus, now time.Time) {
+	if r.notifier == nil {
+		return
+	}
+	event := notifier.NewEvent(daemonset, newStatus, now)
+	if event == nil {
+		return
+	}
+	sinks, err := r.getNotificationSinks(daemonset)
+	if err != nil {
+		logger.Error(err, "Unable to get the notification sinks")
+	}
+	r.notifier.Notify(event, sinks)
+}
+
+// getNotificationSinks returns the notification sinks of the ExtendedDaemonSet, with the URLs read from the secrets
+func (r *ReconcileExtendedDaemonSet) getNotificationSinks(daemonset *datadoghqv1alpha1.ExtendedDaemonSet) ([]notifier.Sink, error) {
+	var sinks []notifier.Sink
+	var errs []error
+	for _, notification := range daemonset.Spec.Notifications {
+		url := notification.URL
+		if url == "" && notification.URLSecretRef != nil {
+			secret := &corev1.Secret{}
+			if err := r.apiReader.Get(context.TODO(), types.NamespacedName{Namespace: daemonset.Namespace, Name: notification.URLSecretRef.Name}, secret); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			url = string(secret.Data[notification.URLSecretRef.Key])
+		}
+		if url == "" {
+			errs = append(errs, fmt.Errorf("no URL for the %s notification", notification.Type))
+			continue
+		}
+		sinks = append(sinks, notifier.Sink{Type: notification.Type, URL: url, Template: notification.Template})
+	}
+	return sinks, utilserrors.NewAggregate(errs)
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+	"github.com/datadog/extendeddaemonset/pkg/controller/notifier"
+)
+
+func TestReconcileExtendedDaemonSet_getNotificationSinks(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "slack"},
+		Data:       map[string][]byte{"url": []byte("https://hooks.slack.com/services/secret")},
+	}
+	secretRef := func(name, key string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+	}
+
+	tests := []struct {
+		name          string
+		notifications []datadoghqv1alpha1.ExtendedDaemonSetSpecNotification
+		want          []notifier.Sink
+		wantErr       bool
+	}{
+		{
+			name: "no notifications",
+		},
+		{
+			name: "url and secret",
+			notifications: []datadoghqv1alpha1.ExtendedDaemonSetSpecNotification{
+				{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeWebhook, URL: "http://webhook", Template: "{{ .State }}"},
+				{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URLSecretRef: secretRef("slack", "url")},
+			},
+			want: []notifier.Sink{
+				{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeWebhook, URL: "http://webhook", Template: "{{ .State }}"},
+				{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: "https://hooks.slack.com/services/secret"},
+			},
+		},
+		{
+			name: "missing secret and key",
+			notifications: []datadoghqv1alpha1.ExtendedDaemonSetSpecNotification{
+				{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URLSecretRef: secretRef("missing", "url")},
+				{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URLSecretRef: secretRef("slack", "missing")},
+				{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeWebhook, URL: "http://webhook"},
+			},
+			want: []notifier.Sink{
+				{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeWebhook, URL: "http://webhook"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemonset := test.NewExtendedDaemonSet("bar", "foo", nil)
+			daemonset.Spec.Notifications = tt.notifications
+			r := &ReconcileExtendedDaemonSet{
+				apiReader: fake.NewFakeClient(secret),
+			}
+			got, err := r.getNotificationSinks(daemonset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReconcileExtendedDaemonSet.getNotificationSinks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ReconcileExtendedDaemonSet.getNotificationSinks() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy/limits"
+)
+
+// computeRolloutStatus returns the rollout status of the up-to-date ExtendedDaemonSetReplicaSet.
+// newStatus should already contain the ExtendedDaemonSet pods counters and canary status.
+func computeRolloutStatus(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, newStatus *datadoghqv1alpha1.ExtendedDaemonSetStatus, current, upToDate *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, now time.Time) *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout {
+	if current == nil || upToDate == nil {
+		return daemonset.Status.Rollout
+	}
+
+	var rollout *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout
+	if daemonset.Status.Rollout != nil && daemonset.Status.Rollout.ReplicaSet == upToDate.Name {
+		rollout = daemonset.Status.Rollout.DeepCopy()
+	} else {
+		rollout = &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+			ReplicaSet: upToDate.Name,
+			StartTime:  upToDate.CreationTimestamp,
+		}
+		if daemonset.Status.ActiveReplicaSet != upToDate.Name {
+			rollout.PreviousReplicaSet = daemonset.Status.ActiveReplicaSet
+		}
+		// a rollback deploys again an ExtendedDaemonSetReplicaSet created before the previous rollout
+		if rollout.StartTime.IsZero() || (daemonset.Status.Rollout != nil && rollout.StartTime.Before(&daemonset.Status.Rollout.StartTime)) {
+			rollout.StartTime = metav1.NewTime(now)
+		}
+	}
+	if rollout.Phase == datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete {
+		return rollout
+	}
+
+	rollout.PercentComplete = 100
+	if newStatus.Desired > 0 {
+		rollout.PercentComplete = percentOf(upToDate.Status.Available, newStatus.Desired)
+	}
+	previousETA := rollout.EstimatedCompletionTime
+	rollout.EstimatedCompletionTime = nil
+
+	switch {
+	case current.Name != upToDate.Name:
+		rollout.Phase = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary
+	case upToDate.Status.Desired > 0 && upToDate.Status.Available >= upToDate.Status.Desired:
+		rollout.Phase = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete
+		rollout.PercentComplete = 100
+		completionTime := metav1.NewTime(now)
+		rollout.CompletionTime = &completionTime
+	default:
+		rollout.Phase = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling
+		eta := estimateRolloutCompletionTime(&daemonset.Spec.Strategy.RollingUpdate, upToDate, now)
+		rollout.EstimatedCompletionTime = stableEstimatedCompletionTime(previousETA, eta, daemonset.Spec.Strategy.RollingUpdate.SlowStartIntervalDuration)
+	}
+	return rollout
+}
+
+// stableEstimatedCompletionTime returns the previous estimated completion time if the new estimation
+// didn't move by more than a slow start interval, to not update the ExtendedDaemonSet status on every reconcile.
+func stableEstimatedCompletionTime(previous, eta *metav1.Time, interval *metav1.Duration) *metav1.Time {
+	if previous == nil || eta == nil || interval == nil {
+		return eta
+	}
+	diff := eta.Sub(previous.Time)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > interval.Duration {
+		return eta
+	}
+	return previous
+}
+
+// estimateRolloutCompletionTime estimates the end of the rolling update from the slow start parameters,
+// limited by the number of pods that became available by slow start interval since the beginning of the rolling update.
+func estimateRolloutCompletionTime(rollingUpdate *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate, upToDate *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, now time.Time) *metav1.Time {
+	if rollingUpdate.SlowStartIntervalDuration == nil || rollingUpdate.SlowStartIntervalDuration.Duration <= 0 || rollingUpdate.MaxParallelPodCreation == nil {
+		return nil
+	}
+	interval := rollingUpdate.SlowStartIntervalDuration.Duration
+	nbNodes := int(upToDate.Status.Desired)
+	startValue, err := intstrutil.GetValueFromIntOrPercent(rollingUpdate.SlowStartAdditiveIncrease, nbNodes, true)
+	if err != nil {
+		return nil
+	}
+
+	startTime := now
+	if cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(&upToDate.Status, datadoghqv1alpha1.ConditionTypeActive); cond != nil && cond.Status == corev1.ConditionTrue {
+		startTime = cond.LastTransitionTime.Time
+	}
+	elapsed := now.Sub(startTime)
+
+	var podsPerSlot float64
+	if elapsed > 0 && upToDate.Status.Available > 0 {
+		podsPerSlot = float64(upToDate.Status.Available) * float64(interval) / float64(elapsed)
+	}
+
+	nbSlots := limits.EstimateSlowStartSlots(nbNodes-int(upToDate.Status.Available), startValue, int(*rollingUpdate.MaxParallelPodCreation), int(elapsed/interval), podsPerSlot)
+	if nbSlots < 0 {
+		return nil
+	}
+	eta := metav1.NewTime(now.Add(time.Duration(nbSlots * float64(interval))).Truncate(time.Second))
+	return &eta
+}
+
+func percentOf(value, total int32) int32 {
+	percent := value * 100 / total
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
+
+// isRolloutCompleted returns true if the rollout of the same ExtendedDaemonSetReplicaSet just completed
+func isRolloutCompleted(previous, rollout *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout) bool {
+	if previous == nil || rollout == nil || previous.ReplicaSet != rollout.ReplicaSet || rollout.CompletionTime == nil {
+		return false
+	}
+	return previous.Phase != datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete && rollout.Phase == datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete
+}
+
+// rolloutPhaseLeft returns the phase left by the rollout of the same ExtendedDaemonSetReplicaSet and its duration.
+// The Canary and Rolling phases start when the Canary and Active conditions of the up-to-date replicaset became True.
+func rolloutPhaseLeft(previous, rollout *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout, upToDate *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, now time.Time) (datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhase, time.Duration, bool) {
+	if previous == nil || rollout == nil || upToDate == nil || previous.ReplicaSet != rollout.ReplicaSet || previous.Phase == rollout.Phase {
+		return "", 0, false
+	}
+
+	var condType datadoghqv1alpha1.ExtendedDaemonSetReplicaSetConditionType
+	switch previous.Phase {
+	case datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary:
+		condType = datadoghqv1alpha1.ConditionTypeCanary
+	case datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling:
+		condType = datadoghqv1alpha1.ConditionTypeActive
+	default:
+		return "", 0, false
+	}
+	startTime := previous.StartTime.Time
+	if cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(&upToDate.Status, condType); cond != nil && cond.Status == corev1.ConditionTrue && cond.LastTransitionTime.After(startTime) {
+		startTime = cond.LastTransitionTime.Time
+	}
+	return previous.Phase, now.Sub(startTime), true
+}
+
+// isRollbackReplicaSet returns true if rs was the active replicaset before the rollout of failedRS
+func isRollbackReplicaSet(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, failedRS, rs *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) bool {
+	rollout := daemonset.Status.Rollout
+	return rollout != nil && rollout.ReplicaSet == failedRS.Name && rollout.PreviousReplicaSet != "" && rollout.PreviousReplicaSet == rs.Name
+}
+
+// getRollbackReplicaSet returns the replicaset active before the rollout of the failed replicaset.
+// Returns nil if it doesn't exist anymore, or if the ExtendedDaemonSet template is already rolled back.
+func (r *ReconcileExtendedDaemonSet) getRollbackReplicaSet(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, failedRS *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) (*datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, error) {
+	rollout := daemonset.Status.Rollout
+	if rollout == nil || rollout.ReplicaSet != failedRS.Name || rollout.PreviousReplicaSet == "" {
+		return nil, nil
+	}
+	rs := &datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: daemonset.Namespace, Name: rollout.PreviousReplicaSet}, rs); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if apiequality.Semantic.DeepEqual(rs.Spec.Template, daemonset.Spec.Template) {
+		return nil, nil
+	}
+	return rs, nil
+}
+
+// isRollbackEnabled returns true if the ExtendedDaemonSet is rolled back when its rolling update failed with this reason
+func isRollbackEnabled(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, reason datadoghqv1alpha1.ExtendedDaemonSetStatusReason) bool {
+	if reason == datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded {
+		return daemonset.Spec.Strategy.ProgressDeadlineAction == datadoghqv1alpha1.ExtendedDaemonSetProgressDeadlineActionRollback
+	}
+	policy := daemonset.Spec.Strategy.RollingUpdate.FailurePolicy
+	return policy != nil && policy.Rollback
+}
+
+// computeConditions returns the ExtendedDaemonSet conditions updated with the Progressing condition
+// of the ExtendedDaemonSetReplicaSet deployed. It also returns true if the progress deadline was just exceeded.
+func computeConditions(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, upToDate *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) ([]datadoghqv1alpha1.ExtendedDaemonSetCondition, bool) {
+	var rsCond *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition
+	if upToDate != nil {
+		rsCond = conditions.GetExtendedDaemonSetReplicaSetStatusCondition(&upToDate.Status, datadoghqv1alpha1.ConditionTypeProgressing)
+	}
+
+	var newConditions []datadoghqv1alpha1.ExtendedDaemonSetCondition
+	var previous *datadoghqv1alpha1.ExtendedDaemonSetCondition
+	for i, cond := range daemonset.Status.Conditions {
+		if cond.Type == datadoghqv1alpha1.ExtendedDaemonSetConditionTypeProgressing {
+			previous = &daemonset.Status.Conditions[i]
+			continue
+		}
+		newConditions = append(newConditions, cond)
+	}
+	if rsCond == nil {
+		return newConditions, false
+	}
+
+	newConditions = append(newConditions, datadoghqv1alpha1.ExtendedDaemonSetCondition{
+		Type:               datadoghqv1alpha1.ExtendedDaemonSetConditionTypeProgressing,
+		Status:             rsCond.Status,
+		LastTransitionTime: rsCond.LastTransitionTime,
+		LastUpdateTime:     rsCond.LastUpdateTime,
+		Reason:             rsCond.Reason,
+		Message:            rsCond.Message,
+	})
+	exceeded := rsCond.Status == corev1.ConditionFalse && rsCond.Reason == string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded)
+	return newConditions, exceeded && (previous == nil || previous.Status != corev1.ConditionFalse)
+}
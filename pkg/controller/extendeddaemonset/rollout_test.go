@@ -0,0 +1,328 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonset
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+)
+
+func Test_computeRolloutStatus(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	nowMeta := metav1.NewTime(now)
+	startTime := metav1.NewTime(now.Add(-5 * time.Minute))
+	activeTime := metav1.NewTime(now.Add(-2 * time.Minute))
+	etaTime := metav1.NewTime(now.Add(3 * time.Minute))
+	previousETATime := metav1.NewTime(now.Add(150 * time.Second))
+	outdatedETATime := metav1.NewTime(now.Add(time.Minute))
+
+	intString2 := intstr.FromInt(2)
+	maxParallelPodCreation := int32(10)
+	daemonset := test.NewExtendedDaemonSet("bar", "foo", nil)
+	daemonset.Spec.Strategy.RollingUpdate = datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate{
+		MaxParallelPodCreation:    &maxParallelPodCreation,
+		SlowStartIntervalDuration: &metav1.Duration{Duration: time.Minute},
+		SlowStartAdditiveIncrease: &intString2,
+	}
+	daemonsetWithRollout := func(phase datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhase) *datadoghqv1alpha1.ExtendedDaemonSet {
+		eds := daemonset.DeepCopy()
+		eds.Status.Rollout = &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+			ReplicaSet:      "foo-2",
+			Phase:           phase,
+			StartTime:       startTime,
+			PercentComplete: 10,
+		}
+		return eds
+	}
+
+	current := test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", nil)
+	newUpToDate := func(available int32) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet {
+		ers := test.NewExtendedDaemonSetReplicaSet("bar", "foo-2", &test.NewExtendedDaemonSetReplicaSetOptions{
+			Status: &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{
+				Desired:   10,
+				Available: available,
+				Conditions: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{
+					{
+						Type:               datadoghqv1alpha1.ConditionTypeActive,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: activeTime,
+					},
+				},
+			},
+		})
+		ers.CreationTimestamp = startTime
+		return ers
+	}
+
+	tests := []struct {
+		name      string
+		daemonset *datadoghqv1alpha1.ExtendedDaemonSet
+		current   *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
+		upToDate  *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
+		want      *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout
+	}{
+		{
+			name:      "no replicaset",
+			daemonset: daemonset,
+			want:      nil,
+		},
+		{
+			name:      "new rollout, canary",
+			daemonset: daemonset,
+			current:   current,
+			upToDate:  newUpToDate(1),
+			want: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+				ReplicaSet:      "foo-2",
+				Phase:           datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary,
+				StartTime:       startTime,
+				PercentComplete: 10,
+			},
+		},
+		{
+			name:      "rolling update, estimated completion time limited by the observed rate",
+			daemonset: daemonsetWithRollout(datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary),
+			current:   newUpToDate(4),
+			upToDate:  newUpToDate(4),
+			want: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+				ReplicaSet:              "foo-2",
+				Phase:                   datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling,
+				StartTime:               startTime,
+				PercentComplete:         40,
+				EstimatedCompletionTime: &etaTime,
+			},
+		},
+		{
+			name: "rolling update, estimated completion time kept within a slow start interval",
+			daemonset: func() *datadoghqv1alpha1.ExtendedDaemonSet {
+				eds := daemonsetWithRollout(datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling)
+				eds.Status.Rollout.EstimatedCompletionTime = &previousETATime
+				return eds
+			}(),
+			current:  newUpToDate(4),
+			upToDate: newUpToDate(4),
+			want: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+				ReplicaSet:              "foo-2",
+				Phase:                   datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling,
+				StartTime:               startTime,
+				PercentComplete:         40,
+				EstimatedCompletionTime: &previousETATime,
+			},
+		},
+		{
+			name: "rolling update, estimated completion time updated beyond a slow start interval",
+			daemonset: func() *datadoghqv1alpha1.ExtendedDaemonSet {
+				eds := daemonsetWithRollout(datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling)
+				eds.Status.Rollout.EstimatedCompletionTime = &outdatedETATime
+				return eds
+			}(),
+			current:  newUpToDate(4),
+			upToDate: newUpToDate(4),
+			want: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+				ReplicaSet:              "foo-2",
+				Phase:                   datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling,
+				StartTime:               startTime,
+				PercentComplete:         40,
+				EstimatedCompletionTime: &etaTime,
+			},
+		},
+		{
+			name:      "rolling update done",
+			daemonset: daemonsetWithRollout(datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling),
+			current:   newUpToDate(10),
+			upToDate:  newUpToDate(10),
+			want: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+				ReplicaSet:      "foo-2",
+				Phase:           datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete,
+				StartTime:       startTime,
+				CompletionTime:  &nowMeta,
+				PercentComplete: 100,
+			},
+		},
+		{
+			name:      "completed rollout is not updated",
+			daemonset: daemonsetWithRollout(datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete),
+			current:   newUpToDate(4),
+			upToDate:  newUpToDate(4),
+			want: &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+				ReplicaSet:      "foo-2",
+				Phase:           datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete,
+				StartTime:       startTime,
+				PercentComplete: 10,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newStatus := &datadoghqv1alpha1.ExtendedDaemonSetStatus{Desired: 10}
+			got := computeRolloutStatus(tt.daemonset, newStatus, tt.current, tt.upToDate, now)
+			if !apiequality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("computeRolloutStatus() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_computeConditions(t *testing.T) {
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	progressing := func(status corev1.ConditionStatus, reason string) datadoghqv1alpha1.ExtendedDaemonSetCondition {
+		return datadoghqv1alpha1.ExtendedDaemonSetCondition{
+			Type:               datadoghqv1alpha1.ExtendedDaemonSetConditionTypeProgressing,
+			Status:             status,
+			LastTransitionTime: now,
+			LastUpdateTime:     now,
+			Reason:             reason,
+		}
+	}
+	newReplicaSet := func(conditions ...datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet {
+		return test.NewExtendedDaemonSetReplicaSet("bar", "foo-2", &test.NewExtendedDaemonSetReplicaSetOptions{
+			Status: &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{Conditions: conditions},
+		})
+	}
+	rsProgressing := func(status corev1.ConditionStatus, reason string) datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition {
+		return datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{
+			Type:               datadoghqv1alpha1.ConditionTypeProgressing,
+			Status:             status,
+			LastTransitionTime: now,
+			LastUpdateTime:     now,
+			Reason:             reason,
+		}
+	}
+	const exceeded = string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded)
+
+	tests := []struct {
+		name         string
+		conditions   []datadoghqv1alpha1.ExtendedDaemonSetCondition
+		upToDate     *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
+		want         []datadoghqv1alpha1.ExtendedDaemonSetCondition
+		wantExceeded bool
+	}{
+		{
+			name:     "progress deadline disabled",
+			upToDate: newReplicaSet(),
+		},
+		{
+			name:       "condition removed",
+			conditions: []datadoghqv1alpha1.ExtendedDaemonSetCondition{progressing(corev1.ConditionTrue, "ReplicaSetUpdated")},
+			upToDate:   newReplicaSet(),
+		},
+		{
+			name:     "progressing",
+			upToDate: newReplicaSet(rsProgressing(corev1.ConditionTrue, "ReplicaSetUpdated")),
+			want:     []datadoghqv1alpha1.ExtendedDaemonSetCondition{progressing(corev1.ConditionTrue, "ReplicaSetUpdated")},
+		},
+		{
+			name:         "progress deadline exceeded",
+			conditions:   []datadoghqv1alpha1.ExtendedDaemonSetCondition{progressing(corev1.ConditionTrue, "ReplicaSetUpdated")},
+			upToDate:     newReplicaSet(rsProgressing(corev1.ConditionFalse, exceeded)),
+			want:         []datadoghqv1alpha1.ExtendedDaemonSetCondition{progressing(corev1.ConditionFalse, exceeded)},
+			wantExceeded: true,
+		},
+		{
+			name:       "progress deadline already exceeded",
+			conditions: []datadoghqv1alpha1.ExtendedDaemonSetCondition{progressing(corev1.ConditionFalse, exceeded)},
+			upToDate:   newReplicaSet(rsProgressing(corev1.ConditionFalse, exceeded)),
+			want:       []datadoghqv1alpha1.ExtendedDaemonSetCondition{progressing(corev1.ConditionFalse, exceeded)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemonset := test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{
+				Status: &datadoghqv1alpha1.ExtendedDaemonSetStatus{Conditions: tt.conditions},
+			})
+			got, gotExceeded := computeConditions(daemonset, tt.upToDate)
+			if !apiequality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("computeConditions() = %#v, want %#v", got, tt.want)
+			}
+			if gotExceeded != tt.wantExceeded {
+				t.Errorf("computeConditions() exceeded = %v, want %v", gotExceeded, tt.wantExceeded)
+			}
+		})
+	}
+}
+
+func Test_rolloutPhaseLeft(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	startTime := metav1.NewTime(now.Add(-30 * time.Minute))
+	canaryTime := metav1.NewTime(now.Add(-20 * time.Minute))
+	activeTime := metav1.NewTime(now.Add(-5 * time.Minute))
+
+	newRollout := func(rsName string, phase datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhase) *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout {
+		return &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{ReplicaSet: rsName, Phase: phase, StartTime: startTime}
+	}
+	newUpToDate := func(conds ...datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet {
+		return test.NewExtendedDaemonSetReplicaSet("bar", "foo-2", &test.NewExtendedDaemonSetReplicaSetOptions{
+			Status: &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{Conditions: conds},
+		})
+	}
+	canaryCond := datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{Type: datadoghqv1alpha1.ConditionTypeCanary, Status: corev1.ConditionTrue, LastTransitionTime: canaryTime}
+	activeCond := datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{Type: datadoghqv1alpha1.ConditionTypeActive, Status: corev1.ConditionTrue, LastTransitionTime: activeTime}
+
+	tests := []struct {
+		name         string
+		previous     *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout
+		rollout      *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout
+		upToDate     *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
+		wantPhase    datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhase
+		wantDuration time.Duration
+		wantLeft     bool
+	}{
+		{
+			name:     "same phase",
+			previous: newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary),
+			rollout:  newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary),
+			upToDate: newUpToDate(canaryCond),
+		},
+		{
+			name:     "new rollout",
+			previous: newRollout("foo-1", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling),
+			rollout:  newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary),
+			upToDate: newUpToDate(),
+		},
+		{
+			name:         "canary validated",
+			previous:     newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary),
+			rollout:      newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling),
+			upToDate:     newUpToDate(canaryCond),
+			wantPhase:    datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary,
+			wantDuration: 20 * time.Minute,
+			wantLeft:     true,
+		},
+		{
+			name:         "canary validated, canary condition already False",
+			previous:     newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary),
+			rollout:      newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling),
+			upToDate:     newUpToDate(datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{Type: datadoghqv1alpha1.ConditionTypeCanary, Status: corev1.ConditionFalse, LastTransitionTime: activeTime}),
+			wantPhase:    datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary,
+			wantDuration: 30 * time.Minute,
+			wantLeft:     true,
+		},
+		{
+			name:         "rolling update done",
+			previous:     newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling),
+			rollout:      newRollout("foo-2", datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete),
+			upToDate:     newUpToDate(activeCond),
+			wantPhase:    datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling,
+			wantDuration: 5 * time.Minute,
+			wantLeft:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phase, duration, left := rolloutPhaseLeft(tt.previous, tt.rollout, tt.upToDate, now)
+			if phase != tt.wantPhase || duration != tt.wantDuration || left != tt.wantLeft {
+				t.Errorf("rolloutPhaseLeft() = (%s, %v, %v), want (%s, %v, %v)", phase, duration, left, tt.wantPhase, tt.wantDuration, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -16,6 +16,8 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
 )
 
 // IsCanaryDeploymentEnded used to know if the Canary duration has finished.
@@ -46,7 +48,8 @@ func IsCanaryDeploymentPaused(dsAnnotations map[string]string) (bool, datadoghqv
 			switch reason {
 			case
 				string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonCLB),
-				string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonOOM):
+				string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonOOM),
+				string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded):
 				return true, datadoghqv1alpha1.ExtendedDaemonSetStatusReason(reason)
 			}
 		}
@@ -73,11 +76,43 @@ func IsCanaryDeploymentFailed(dsAnnotations map[string]string) bool {
 	return false
 }
 
+// IsRollingUpdateFailed checks if the rolling update of the replicaset has been halted by the failure policy.
+// It returns the failure reason.
+func IsRollingUpdateFailed(rs *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) (bool, datadoghqv1alpha1.ExtendedDaemonSetStatusReason) {
+	if rs == nil {
+		return false, ""
+	}
+	cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(&rs.Status, datadoghqv1alpha1.ConditionTypeRollingUpdateFailed)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		return false, ""
+	}
+	return true, datadoghqv1alpha1.ExtendedDaemonSetStatusReason(cond.Reason)
+}
+
+// IsRollingUpdatePauseRequested checks if the rolling update of the replicaset is paused by the ExtendedDaemonSet annotation
+func IsRollingUpdatePauseRequested(dsAnnotations map[string]string, rsName string) bool {
+	return dsAnnotations[datadoghqv1alpha1.ExtendedDaemonSetRollingUpdatePausedAnnotationKey] == rsName
+}
+
+// IsRollingUpdatePaused checks if the rolling update of the replicaset is paused.
+// It returns the pause reason.
+func IsRollingUpdatePaused(rs *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) (bool, datadoghqv1alpha1.ExtendedDaemonSetStatusReason) {
+	if rs == nil {
+		return false, ""
+	}
+	cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(&rs.Status, datadoghqv1alpha1.ConditionTypeRollingUpdatePaused)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		return false, ""
+	}
+	return true, datadoghqv1alpha1.ExtendedDaemonSetStatusReason(cond.Reason)
+}
+
 func getPodListFromReplicaSet(c client.Client, ds *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) (*corev1.PodList, error) {
 	podList := &corev1.PodList{}
 	podSelector := labels.Set{datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNameLabelKey: ds.Name}
 	podListOptions := []client.ListOption{
 		&client.MatchingLabelsSelector{Selector: podSelector.AsSelectorPreValidated()},
+		client.MatchingFields{podutils.ControllerIndexField: podutils.ControllerIndexValue("ExtendedDaemonSetReplicaSet", ds.Name)},
 	}
 	if err := c.List(context.TODO(), podList, podListOptions...); err != nil {
 		return nil, err
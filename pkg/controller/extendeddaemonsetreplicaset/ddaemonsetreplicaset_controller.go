@@ -25,7 +25,9 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	utilserrors "k8s.io/apimachinery/pkg/util/errors"
 
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -38,36 +40,57 @@ import (
 	"github.com/datadog/extendeddaemonset/pkg/config"
 	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
 	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils/enqueue"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/shard"
 )
 
 var log = logf.Log.WithName("ExtendedDaemonSetReplicaSet")
 
 // Add creates a new ExtendedDaemonSetReplicaSet Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, opts config.Options) error {
+	r, err := newReconciler(mgr, opts)
+	if err != nil {
+		return err
+	}
+	return add(mgr, r, opts)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, opts config.Options) (reconcile.Reconciler, error) {
+	kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, err
+	}
 	return &ReconcileExtendedDaemonSetReplicaSet{
 		client:                  mgr.GetClient(),
+		kubeClient:              kubeClient,
+		oldDaemonSetPods:        newOldDaemonSetPodInformers(kubeClient),
 		scheme:                  mgr.GetScheme(),
 		recorder:                mgr.GetEventRecorderFor("ExtendedDaemonSetReplicaSet"),
 		isNodeAffinitySupported: os.Getenv(config.NodeAffinityMatchSupportEnvVar) == "1",
-	}
+		podCreationBackoff:      newPodCreationBackoff(opts.PodCreationBackoff, opts.PodCreationMaxBackoff),
+		tuning:                  opts.Tuning,
+		shard:                   opts.Shard,
+	}, nil
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, opts config.Options) error {
 	// Create a new controller
-	c, err := controller.New("extendeddaemonsetreplicaset-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("extendeddaemonsetreplicaset-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: opts.ExtendedDaemonSetReplicaSet.MaxConcurrentReconciles})
 	if err != nil {
 		return err
 	}
 
+	// Index the pods by Node and by controller, to get them without listing all the pods
+	if err = podutils.AddFieldIndexes(mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+
 	// Watch for changes to primary resource ExtendedDaemonSetReplicaSet
 	err = c.Watch(&source.Kind{Type: &datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{}}, &handler.EnqueueRequestForObject{})
 	if err != nil {
@@ -75,7 +98,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	}
 
 	// Watch for changes to primary resource ExtendedDaemonSet
-	err = c.Watch(&source.Kind{Type: &datadoghqv1alpha1.ExtendedDaemonSet{}}, &enqueue.RequestForExtendedDaemonSetStatus{})
+	err = c.Watch(&source.Kind{Type: &datadoghqv1alpha1.ExtendedDaemonSet{}}, &enqueue.RequestForExtendedDaemonSetStatus{}, opts.Shard.Predicate())
 	if err != nil {
 		return err
 	}
@@ -95,8 +118,8 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	if !ok {
 		return fmt.Errorf("unable to cast Reconciler to ReconcileExtendedDaemonSetReplicaSet")
 	}
-	nodeEventHandler := enqueue.NewRequestForAllReplicaSetFromNodeEvent(rsReconciler.client)
-	err = c.Watch(&source.Kind{Type: &corev1.Node{}}, nodeEventHandler)
+	nodeEventHandler := enqueue.NewRequestForReplicaSetFromNodeEvent(rsReconciler.client)
+	err = c.Watch(&source.Kind{Type: &corev1.Node{}}, nodeEventHandler, enqueue.NodeSchedulingChangedPredicate())
 	if err != nil {
 		return err
 	}
@@ -111,11 +134,22 @@ var _ reconcile.Reconciler = &ReconcileExtendedDaemonSetReplicaSet{}
 type ReconcileExtendedDaemonSetReplicaSet struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client   client.Client
-	scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	client client.Client
+	// kubeClient is used for the requests not supported by client, like the pods evictions
+	kubeClient kubernetes.Interface
+	scheme     *runtime.Scheme
+	recorder   record.EventRecorder
+	// oldDaemonSetPods caches the old DaemonSet pods during the migration, they are not in the manager cache
+	oldDaemonSetPods *oldDaemonSetPodInformers
 
 	isNodeAffinitySupported bool
+
+	// podCreationBackoff delays the pod creation on the Nodes where it failed repeatedly
+	podCreationBackoff *flowcontrol.Backoff
+	// tuning gives the pods creation and deletion values, reloaded with the configuration file
+	tuning *config.TuningProvider
+	// shard selects the ExtendedDaemonSets whose replicasets are reconciled by this operator replica
+	shard *shard.Shard
 }
 
 // Reconcile reads that state of the cluster for a ExtendedDaemonSetReplicaSet object and makes changes based on the state read
@@ -130,6 +164,7 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) Reconcile(request reconcile.Reque
 	reqLogger.Info("Reconciling ExtendedDaemonSetReplicaSet")
 
 	now := metav1.NewTime(time.Now())
+	tuning := r.tuning.Get()
 	// Fetch the ExtendedDaemonSetReplicaSet replicaSetInstance
 	replicaSetInstance, needReturn, err := r.retrievedReplicaSet(request)
 	if needReturn {
@@ -141,6 +176,10 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) Reconcile(request reconcile.Reque
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if !r.shard.Contains(daemonsetInstance) {
+		reqLogger.V(1).Info("ExtendedDaemonSet reconciled by another shard", "extendeddaemonset", daemonsetInstance.Name)
+		return reconcile.Result{}, nil
+	}
 
 	lastResyncTimeStampCond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(&replicaSetInstance.Status, datadoghqv1alpha1.ConditionTypeLastFullSync)
 	if lastResyncTimeStampCond != nil {
@@ -153,11 +192,14 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) Reconcile(request reconcile.Reque
 	}
 
 	// retrieved and build information for the strategy
-	strategyParams, err := r.buildStrategyParams(reqLogger, daemonsetInstance, replicaSetInstance)
+	strategyParams, err := r.buildStrategyParams(reqLogger, daemonsetInstance, replicaSetInstance, tuning)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
+	// select the resources profile of each Node before applying the strategy
+	podsBlockedByResources := manageResourcesProfiles(reqLogger, daemonsetInstance, strategyParams, now.Time)
+
 	// now apply the strategy depending on the ReplicaSet state
 	strategyResult, err := r.applyStrategy(reqLogger, daemonsetInstance, now, strategyParams)
 	newStatus := strategyResult.NewStatus
@@ -179,12 +221,24 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) Reconcile(request reconcile.Reque
 	}
 	conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(newStatus, now, datadoghqv1alpha1.ConditionTypeUnschedule, status, desc, false, false)
 
+	errs = append(errs, deletePodList(reqLogger, r.client, daemonsetInstance.Name, podsBlockedByResources)...)
+
+	isCanary := strategy.ReplicaSetStatus(strategyParams.ReplicaSetStatus) == strategy.ReplicaSetStatusCanary
+	errs = append(errs, updateCanaryPodsLabel(reqLogger, r.client, replicaSetInstance, isCanary, strategyParams.PodByNodeName)...)
+
+	// the canary deployment is already paused when a canary pod is OOMKilled.
+	if strategy.ReplicaSetStatus(strategyParams.ReplicaSetStatus) == strategy.ReplicaSetStatusActive {
+		errs = append(errs, r.manageOOMKilledPods(reqLogger, daemonsetInstance, strategyParams.PodByNodeName)...)
+	}
+
 	// start actions on pods
 	lastPodDeletionCondition := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(newStatus, datadoghqv1alpha1.ConditionTypePodDeletion)
-	if lastPodDeletionCondition != nil && now.Sub(lastPodDeletionCondition.LastUpdateTime.Time) < 5*time.Second {
-		result.RequeueAfter = 5 * time.Second
+	if podDeletionInterval := tuning.PodDeletionInterval.Duration; lastPodDeletionCondition != nil && now.Sub(lastPodDeletionCondition.LastUpdateTime.Time) < podDeletionInterval {
+		result.RequeueAfter = podDeletionInterval
 	} else {
-		errs = append(errs, deletePods(reqLogger, r.client, strategyParams.PodByNodeName, strategyResult.PodsToDelete)...)
+		evictionBackoff, deleteErrs := deletePods(reqLogger, r.client, r.kubeClient, &daemonsetInstance.Spec.Strategy.RollingUpdate, daemonsetInstance.Name, strategyParams.PodByNodeName, strategyResult.PodsToDelete)
+		errs = append(errs, deleteErrs...)
+		result = utils.MergeResult(result, reconcile.Result{RequeueAfter: evictionBackoff})
 		if len(strategyResult.PodsToDelete) > 0 {
 			conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(newStatus, now, datadoghqv1alpha1.ConditionTypePodDeletion, corev1.ConditionTrue, "pods deleted", false, true)
 		}
@@ -194,7 +248,10 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) Reconcile(request reconcile.Reque
 	if lastPodCreationCondition != nil && now.Sub(lastPodCreationCondition.LastUpdateTime.Time) < daemonsetInstance.Spec.Strategy.ReconcileFrequency.Duration {
 		result.RequeueAfter = daemonsetInstance.Spec.Strategy.ReconcileFrequency.Duration
 	} else {
-		errs = append(errs, createPods(reqLogger, r.client, r.scheme, r.isNodeAffinitySupported, replicaSetInstance, strategyResult.PodsToCreate)...)
+		creation := createPods(reqLogger, r.client, r.scheme, r.isNodeAffinitySupported, r.podCreationBackoff, tuning.PodCreationWorkers, daemonsetInstance.Name, replicaSetInstance, strategyResult.PodsToCreate)
+		errs = append(errs, creation.errs...)
+		result = utils.MergeResult(result, reconcile.Result{RequeueAfter: creation.requeueAfter})
+		setPodCreationFailedCondition(newStatus, now, creation)
 		if len(strategyResult.PodsToCreate) > 0 {
 			conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(newStatus, now, datadoghqv1alpha1.ConditionTypePodCreation, corev1.ConditionTrue, "pods created", false, true)
 		}
@@ -208,7 +265,7 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) Reconcile(request reconcile.Reque
 	return result, err
 }
 
-func (r *ReconcileExtendedDaemonSetReplicaSet) buildStrategyParams(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet) (*strategy.Parameters, error) {
+func (r *ReconcileExtendedDaemonSetReplicaSet) buildStrategyParams(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, tuning config.Tuning) (*strategy.Parameters, error) {
 	rsStatus := retrieveReplicaSetStatus(daemonset, replicaset.Name)
 
 	// Retrieve the Node associated to the replicaset (with node selector)
@@ -224,6 +281,9 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) buildStrategyParams(logger logr.L
 		ReplicaSetStatus: string(rsStatus),
 		Logger:           logger.WithValues("strategy", rsStatus),
 		NewStatus:        replicaset.Status.DeepCopy(),
+
+		SchedulerIssueTimeout: tuning.SchedulerIssueTimeout.Duration,
+		KubeClient:            r.kubeClient,
 	}
 	var nodesFilter []string
 	if daemonset.Status.Canary != nil {
@@ -243,12 +303,16 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) applyStrategy(logger logr.Logger,
 	var strategyResult *strategy.Result
 	var err error
 
+	defer func(start time.Time) {
+		metrics.ObserveStrategyReconcileDuration(daemonset.Namespace, daemonset.Name, strategyParams.ReplicaSetStatus, time.Since(start))
+	}(time.Now())
+
 	switch strategy.ReplicaSetStatus(strategyParams.ReplicaSetStatus) {
 	case strategy.ReplicaSetStatusActive:
 		logger.Info("manage deployment")
 		conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(strategyParams.NewStatus, now, datadoghqv1alpha1.ConditionTypeActive, corev1.ConditionTrue, "", false, false)
 		conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(strategyParams.NewStatus, now, datadoghqv1alpha1.ConditionTypeCanary, corev1.ConditionFalse, "", false, false)
-		strategyResult, err = strategy.ManageDeployment(r.client, strategyParams)
+		strategyResult, err = strategy.ManageDeployment(r.client, daemonset, strategyParams)
 	case strategy.ReplicaSetStatusCanary:
 		conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(strategyParams.NewStatus, now, datadoghqv1alpha1.ConditionTypeCanary, corev1.ConditionTrue, "", false, false)
 		conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(strategyParams.NewStatus, now, datadoghqv1alpha1.ConditionTypeActive, corev1.ConditionFalse, "", false, false)
@@ -388,18 +452,26 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) getNodeList(eds *datadoghqv1alpha
 		return nil, err
 	}
 
+	// parse the settings selectors once, and not for each Node
+	var validSettings []*datadoghqv1alpha1.ExtendedDaemonsetSetting
+	var settingSelectors []labels.Selector
+	for _, edsNode := range extendedDaemonsetSettings {
+		if edsNode.Status.Status != datadoghqv1alpha1.ExtendedDaemonsetSettingStatusValid {
+			continue
+		}
+		selector, err2 := metav1.LabelSelectorAsSelector(&edsNode.Spec.NodeSelector)
+		if err2 != nil {
+			return nil, err2
+		}
+		validSettings = append(validSettings, edsNode)
+		settingSelectors = append(settingSelectors, selector)
+	}
+
 	for index, node := range nodeList.Items {
 		var edsNodeSelected *datadoghqv1alpha1.ExtendedDaemonsetSetting
-		for _, edsNode := range extendedDaemonsetSettings {
-			if edsNode.Status.Status != datadoghqv1alpha1.ExtendedDaemonsetSettingStatusValid {
-				continue
-			}
-			selector, err2 := metav1.LabelSelectorAsSelector(&edsNode.Spec.NodeSelector)
-			if err2 != nil {
-				return nil, err2
-			}
+		for id, selector := range settingSelectors {
 			if selector.Matches(labels.Set(node.Labels)) {
-				edsNodeSelected = edsNode
+				edsNodeSelected = validSettings[id]
 				break
 			}
 		}
@@ -410,9 +482,11 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) getNodeList(eds *datadoghqv1alpha
 
 func (r *ReconcileExtendedDaemonSetReplicaSet) getOldDaemonsetPodList(ds *datadoghqv1alpha1.ExtendedDaemonSet) (*corev1.PodList, error) {
 	podList := &corev1.PodList{}
+	edsKey := types.NamespacedName{Namespace: ds.Namespace, Name: ds.Name}
 
 	oldDsName, ok := ds.GetAnnotations()[datadoghqv1alpha1.ExtendedDaemonSetOldDaemonsetAnnotationKey]
 	if !ok {
+		r.oldDaemonSetPods.release(edsKey)
 		return podList, nil
 	}
 
@@ -420,45 +494,36 @@ func (r *ReconcileExtendedDaemonSetReplicaSet) getOldDaemonsetPodList(ds *datado
 	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: ds.Namespace, Name: oldDsName}, oldDaemonset)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			r.oldDaemonSetPods.release(edsKey)
 			return podList, nil
 		}
 		// Error reading the object - requeue the request.
 		return nil, err
 	}
-	podListOptions := []client.ListOption{}
+	selector := labels.Everything()
 	if oldDaemonset.Spec.Selector != nil {
-		selector, err2 := utils.ConvertLabelSelector(log, oldDaemonset.Spec.Selector)
-		if err2 != nil {
-			return nil, err2
-		}
-
-		podListOptions = []client.ListOption{
-			client.MatchingLabelsSelector{
-				Selector: selector,
-			},
+		selector, err = utils.ConvertLabelSelector(log, oldDaemonset.Spec.Selector)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	if err = r.client.List(context.TODO(), podList, podListOptions...); err != nil {
+	// the old DaemonSet pods are not in the manager cache, it only contains the ExtendedDaemonSet pods
+	pods, err := r.oldDaemonSetPods.list(edsKey, selector)
+	if err != nil {
 		return nil, err
 	}
 
 	// filter by ownerreferences,
 	// This is to prevent issue with label selector that match between DS and EDS
-	var filterPods []corev1.Pod
-	for id, pod := range podList.Items {
-		selected := false
+	for _, pod := range pods {
 		for _, ref := range pod.OwnerReferences {
 			if ref.Kind == "DaemonSet" && ref.Name == oldDsName {
-				selected = true
+				podList.Items = append(podList.Items, *pod.DeepCopy())
 				break
 			}
 		}
-		if selected {
-			filterPods = append(filterPods, podList.Items[id])
-		}
 	}
-	podList.Items = filterPods
 
 	return podList, nil
 }
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+)
+
+func Test_deletePods(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	log = logf.Log.WithName("Test_deletePods")
+
+	gracePeriod := int64(5)
+	node1 := strategy.NewNodeItem(commontest.NewNode("node1", nil), nil)
+	pod1 := commontest.NewPod("bar", "pod1", "node1", nil)
+	podByNodeName := map[*strategy.NodeItem]*corev1.Pod{node1: pod1}
+
+	tests := []struct {
+		name          string
+		rollingUpdate datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate
+		evictionErr   error
+		wantEvicted   bool
+		wantDeleted   bool
+		wantBackoff   time.Duration
+	}{
+		{
+			name:          "delete mode",
+			rollingUpdate: datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate{DeletionMode: datadoghqv1alpha1.ExtendedDaemonSetDeletionModeDelete},
+			wantDeleted:   true,
+		},
+		{
+			name: "evict mode",
+			rollingUpdate: datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate{
+				DeletionMode:                  datadoghqv1alpha1.ExtendedDaemonSetDeletionModeEvict,
+				TerminationGracePeriodSeconds: &gracePeriod,
+			},
+			wantEvicted: true,
+		},
+		{
+			name:          "evict mode, blocked by a PodDisruptionBudget",
+			rollingUpdate: datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate{DeletionMode: datadoghqv1alpha1.ExtendedDaemonSetDeletionModeEvict},
+			evictionErr:   errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0),
+			wantEvicted:   true,
+			wantBackoff:   podutils.DefaultEvictionBackoff,
+		},
+		{
+			name:          "evict mode, blocked with a suggested delay",
+			rollingUpdate: datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate{DeletionMode: datadoghqv1alpha1.ExtendedDaemonSetDeletionModeEvict},
+			evictionErr:   errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 30),
+			wantEvicted:   true,
+			wantBackoff:   30 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClient([]runtime.Object{pod1.DeepCopy()}...)
+			kubeClient := kubefake.NewSimpleClientset()
+			var eviction *policyv1beta1.Eviction
+			kubeClient.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "eviction" {
+					return false, nil, nil
+				}
+				eviction = action.(k8stesting.CreateAction).GetObject().(*policyv1beta1.Eviction)
+				return true, nil, tt.evictionErr
+			})
+
+			backoff, errs := deletePods(log, c, kubeClient, &tt.rollingUpdate, "foo", podByNodeName, []*strategy.NodeItem{node1})
+			if len(errs) > 0 {
+				t.Fatalf("deletePods() unexpected errors: %v", errs)
+			}
+			if backoff != tt.wantBackoff {
+				t.Errorf("deletePods() backoff = %v, want %v", backoff, tt.wantBackoff)
+			}
+			if (eviction != nil) != tt.wantEvicted {
+				t.Errorf("deletePods() evicted = %v, want %v", eviction != nil, tt.wantEvicted)
+			}
+			if eviction != nil && tt.rollingUpdate.TerminationGracePeriodSeconds != nil && *eviction.DeleteOptions.GracePeriodSeconds != *tt.rollingUpdate.TerminationGracePeriodSeconds {
+				t.Errorf("deletePods() eviction grace period = %d, want %d", *eviction.DeleteOptions.GracePeriodSeconds, *tt.rollingUpdate.TerminationGracePeriodSeconds)
+			}
+			err := c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "pod1"}, &corev1.Pod{})
+			if errors.IsNotFound(err) != tt.wantDeleted {
+				t.Errorf("deletePods() deleted = %v, want %v", errors.IsNotFound(err), tt.wantDeleted)
+			}
+		})
+	}
+}
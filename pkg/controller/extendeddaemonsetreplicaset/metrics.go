@@ -6,18 +6,15 @@
 package extendeddaemonsetreplicaset
 
 import (
+	"strings"
+
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils"
-	"github.com/datadog/extendeddaemonset/pkg/generated/clientset/versioned"
-	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
 
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/tools/cache"
 	ksmetric "k8s.io/kube-state-metrics/pkg/metric"
 )
 
@@ -29,32 +26,20 @@ const (
 	ersStatusAvailable                = "ers_status_available"
 	ersStatusIgnoredUnresponsiveNodes = "ers_status_ignored_unresponsive_nodes"
 	ersLabels                         = "ers_labels"
+	ersStatusCondition                = "ers_status_condition"
 )
 
 // AddMetrics add ExtentedDaemonSetReplicaset metrics
 func AddMetrics(mgr manager.Manager, h metrics.Handler) error {
 	families := generateMetricFamilies()
 
-	clientset, err := versioned.NewForConfig(mgr.GetConfig())
-	if err != nil {
-		return err
-	}
-
-	namespace, err := k8sutil.GetWatchNamespace()
+	// the metrics store is fed by the manager informer, to not duplicate the watch
+	informer, err := mgr.GetCache().GetInformer(&datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{})
 	if err != nil {
 		return err
 	}
 
-	lw := &cache.ListWatch{
-		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
-			return clientset.DatadoghqV1alpha1().ExtendedDaemonSetReplicaSets(namespace).List(opts)
-		},
-		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
-			return clientset.DatadoghqV1alpha1().ExtendedDaemonSetReplicaSets(namespace).Watch(opts)
-		},
-	}
-
-	return h.RegisterStore(families, &datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{}, lw)
+	return h.RegisterStore(families, informer)
 }
 
 func generateMetricFamilies() []ksmetric.FamilyGenerator {
@@ -186,5 +171,31 @@ func generateMetricFamilies() []ksmetric.FamilyGenerator {
 				}
 			},
 		},
+		{
+			Name: ersStatusCondition,
+			Type: ksmetric.Gauge,
+			Help: "The current status conditions of the ExtendedDaemonSetReplicaSet, set to 1 for the condition status",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				ers := obj.(*datadoghqv1alpha1.ExtendedDaemonSetReplicaSet)
+				labelKeys, labelValues := utils.GetLabelsValues(&ers.ObjectMeta)
+				conditionMetrics := make([]*ksmetric.Metric, 0, 3*len(ers.Status.Conditions))
+				for _, condition := range ers.Status.Conditions {
+					for _, status := range []corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown} {
+						val := float64(0)
+						if condition.Status == status {
+							val = 1
+						}
+						conditionMetrics = append(conditionMetrics, &ksmetric.Metric{
+							Value:       val,
+							LabelKeys:   append(labelKeys, "type", "status"),
+							LabelValues: append(labelValues, string(condition.Type), strings.ToLower(string(status))),
+						})
+					}
+				}
+				return &ksmetric.Family{
+					Metrics: conditionMetrics,
+				}
+			},
+		},
 	}
 }
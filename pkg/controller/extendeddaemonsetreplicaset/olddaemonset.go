@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+const (
+	// oldDaemonSetPodsSyncTimeout maximum duration waiting for the first list of the old DaemonSet pods
+	oldDaemonSetPodsSyncTimeout = 30 * time.Second
+	oldDaemonSetPodsResync      = 10 * time.Hour
+)
+
+// oldDaemonSetPodInformers caches the pods of the old DaemonSets while the ExtendedDaemonSets migrate from them.
+// The manager cache only contains the ExtendedDaemonSet pods, so an informer scoped to the ExtendedDaemonSet
+// namespace and to the old DaemonSet selector runs only while the old-daemonset annotation is set.
+type oldDaemonSetPodInformers struct {
+	kubeClient kubernetes.Interface
+
+	mutex     sync.Mutex
+	informers map[types.NamespacedName]*oldDaemonSetPodInformer
+}
+
+type oldDaemonSetPodInformer struct {
+	selector string
+	informer toolscache.SharedIndexInformer
+	stop     chan struct{}
+}
+
+func newOldDaemonSetPodInformers(kubeClient kubernetes.Interface) *oldDaemonSetPodInformers {
+	return &oldDaemonSetPodInformers{
+		kubeClient: kubeClient,
+		informers:  map[types.NamespacedName]*oldDaemonSetPodInformer{},
+	}
+}
+
+// list returns the pods of the eds namespace matching the old DaemonSet selector. The informer is started
+// on the first call, and restarted if the selector changed.
+func (i *oldDaemonSetPodInformers) list(eds types.NamespacedName, selector labels.Selector) ([]*corev1.Pod, error) {
+	i.mutex.Lock()
+	current, found := i.informers[eds]
+	if found && current.selector != selector.String() {
+		close(current.stop)
+		found = false
+	}
+	if !found {
+		current = i.newInformer(eds.Namespace, selector)
+		i.informers[eds] = current
+		go current.informer.Run(current.stop)
+	}
+	i.mutex.Unlock()
+
+	if !current.informer.HasSynced() {
+		timeout := make(chan struct{})
+		timer := time.AfterFunc(oldDaemonSetPodsSyncTimeout, func() { close(timeout) })
+		synced := toolscache.WaitForCacheSync(timeout, current.informer.HasSynced)
+		timer.Stop()
+		if !synced {
+			return nil, fmt.Errorf("timeout waiting for the old DaemonSet pods of %s to be listed", eds)
+		}
+	}
+
+	items := current.informer.GetStore().List()
+	pods := make([]*corev1.Pod, 0, len(items))
+	for _, item := range items {
+		if pod, ok := item.(*corev1.Pod); ok {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// release stops the informer of the eds, once the migration from the old DaemonSet is done
+func (i *oldDaemonSetPodInformers) release(eds types.NamespacedName) {
+	if i == nil {
+		return
+	}
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if current, found := i.informers[eds]; found {
+		close(current.stop)
+		delete(i.informers, eds)
+	}
+}
+
+func (i *oldDaemonSetPodInformers) newInformer(namespace string, selector labels.Selector) *oldDaemonSetPodInformer {
+	tweak := func(opts *metav1.ListOptions) {
+		opts.LabelSelector = selector.String()
+	}
+	lw := &toolscache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			tweak(&opts)
+			return i.kubeClient.CoreV1().Pods(namespace).List(opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			tweak(&opts)
+			return i.kubeClient.CoreV1().Pods(namespace).Watch(opts)
+		},
+	}
+	return &oldDaemonSetPodInformer{
+		selector: selector.String(),
+		informer: toolscache.NewSharedIndexInformer(lw, &corev1.Pod{}, oldDaemonSetPodsResync, toolscache.Indexers{}),
+		stop:     make(chan struct{}),
+	}
+}
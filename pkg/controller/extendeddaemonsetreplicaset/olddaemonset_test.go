@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func Test_oldDaemonSetPodInformers(t *testing.T) {
+	oldPod := commontest.NewPod("bar", "old-1", "node1", &commontest.NewPodOptions{Labels: map[string]string{"app": "old"}})
+	otherNamespacePod := commontest.NewPod("other", "old-2", "node2", &commontest.NewPodOptions{Labels: map[string]string{"app": "old"}})
+	otherPod := commontest.NewPod("bar", "other-1", "node1", &commontest.NewPodOptions{Labels: map[string]string{"app": "other"}})
+	informers := newOldDaemonSetPodInformers(kubefake.NewSimpleClientset(oldPod, otherNamespacePod, otherPod))
+	eds := types.NamespacedName{Namespace: "bar", Name: "foo"}
+
+	pods, err := informers.list(eds, labels.SelectorFromSet(labels.Set{"app": "old"}))
+	if err != nil {
+		t.Fatalf("list() error = %v", err)
+	}
+	if len(pods) != 1 || pods[0].Name != "old-1" {
+		t.Errorf("list() = %v, want only the pod old-1 of the ExtendedDaemonSet namespace", pods)
+	}
+
+	informers.release(eds)
+	if len(informers.informers) != 0 {
+		t.Errorf("release() kept %d informers, want 0", len(informers.informers))
+	}
+}
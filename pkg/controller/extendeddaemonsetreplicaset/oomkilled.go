@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/comparison"
+)
+
+// manageOOMKilledPods increases the memory of the containers repeatedly OOMKilled by updating the Node resources annotation.
+// The pods are then replaced by the strategy since their Node resources hash is not up-to-date anymore.
+func (r *ReconcileExtendedDaemonSetReplicaSet) manageOOMKilledPods(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, podByNodeName map[*strategy.NodeItem]*corev1.Pod) []error {
+	policy := daemonset.Spec.OOMKilledPolicy
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxMemory == nil || policy.MaxMemory.Sign() <= 0 {
+		return []error{fmt.Errorf("invalid oomKilledPolicy: maxMemory should be greater than 0")}
+	}
+
+	var errs []error
+	for nodeItem, pod := range podByNodeName {
+		if pod == nil || nodeItem == nil || nodeItem.Node == nil {
+			continue
+		}
+		newNode, containers, err := increaseOOMKilledContainersMemory(daemonset, pod, nodeItem.Node)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(containers) == 0 {
+			continue
+		}
+
+		logger.Info("Increase OOMKilled containers memory", "node", newNode.Name, "pod", pod.Name, "containers", containers)
+		// the cached Nodes are trimmed, so only the resources annotations are patched
+		if err = r.client.Patch(context.TODO(), newNode, client.MergeFrom(nodeItem.Node)); err != nil {
+			errs = append(errs, fmt.Errorf("unable to update node %s resources annotation, err: %v", newNode.Name, err))
+			continue
+		}
+		r.recorder.Event(daemonset, corev1.EventTypeWarning, "OOMKilled memory increase", fmt.Sprintf("node %s, containers %v", newNode.Name, containers))
+	}
+	return errs
+}
+
+// increaseOOMKilledContainersMemory returns an updated copy of the Node with the new resources annotations,
+// and the names of the containers which memory has been increased.
+func increaseOOMKilledContainersMemory(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, pod *corev1.Pod, node *corev1.Node) (*corev1.Node, []string, error) {
+	policy := daemonset.Spec.OOMKilledPolicy
+	if policy == nil || policy.MinRestartCount == nil || policy.MemoryIncreasePercent == nil || policy.MaxMemory == nil {
+		return nil, nil, nil
+	}
+
+	// the pod was not created with the latest Node resources annotations: wait for its replacement
+	nodeHash := comparison.GenerateHashFromEDSResourceNodeAnnotation(daemonset.Namespace, daemonset.Name, node.Annotations)
+	if pod.Annotations[datadoghqv1alpha1.MD5NodeExtendedDaemonSetAnnotationKey] != nodeHash {
+		return nil, nil, nil
+	}
+
+	var newNode *corev1.Node
+	var containers []string
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.RestartCount < *policy.MinRestartCount || status.LastTerminationState.Terminated == nil || status.LastTerminationState.Terminated.Reason != string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonOOM) {
+			continue
+		}
+
+		for _, container := range pod.Spec.Containers {
+			if container.Name != status.Name {
+				continue
+			}
+			resources, increased := increaseMemory(&container.Resources, *policy.MemoryIncreasePercent, *policy.MaxMemory)
+			if !increased {
+				break
+			}
+			value, err := json.Marshal(resources)
+			if err != nil {
+				return nil, nil, err
+			}
+			if newNode == nil {
+				newNode = node.DeepCopy()
+				if newNode.Annotations == nil {
+					newNode.Annotations = map[string]string{}
+				}
+			}
+			newNode.Annotations[fmt.Sprintf(datadoghqv1alpha1.ExtendedDaemonSetRessourceNodeAnnotationKey, daemonset.Namespace, daemonset.Name, container.Name)] = string(value)
+			containers = append(containers, container.Name)
+			break
+		}
+	}
+	return newNode, containers, nil
+}
+
+// increaseMemory returns a copy of the resources with the memory limit and request increased by the percentage,
+// the memory limit is capped to maxMemory. Returns false if the memory can't be increased.
+func increaseMemory(resources *corev1.ResourceRequirements, percent int32, maxMemory resource.Quantity) (*corev1.ResourceRequirements, bool) {
+	limit, hasLimit := resources.Limits[corev1.ResourceMemory]
+	request, hasRequest := resources.Requests[corev1.ResourceMemory]
+	if !hasLimit && !hasRequest {
+		return nil, false
+	}
+
+	newResources := resources.DeepCopy()
+	var newLimit int64
+	if hasLimit {
+		if limit.Cmp(maxMemory) >= 0 {
+			return nil, false
+		}
+		newLimit = increaseValue(limit.Value(), percent, maxMemory.Value())
+		newResources.Limits[corev1.ResourceMemory] = *resource.NewQuantity(newLimit, limit.Format)
+	}
+	if hasRequest {
+		maxRequest := maxMemory.Value()
+		if hasLimit {
+			maxRequest = newLimit
+		}
+		if request.Value() >= maxRequest {
+			return newResources, hasLimit
+		}
+		newResources.Requests[corev1.ResourceMemory] = *resource.NewQuantity(increaseValue(request.Value(), percent, maxRequest), request.Format)
+	}
+	return newResources, true
+}
+
+func increaseValue(value int64, percent int32, max int64) int64 {
+	newValue := value + value*int64(percent)/100
+	if newValue > max {
+		newValue = max
+	}
+	return newValue
+}
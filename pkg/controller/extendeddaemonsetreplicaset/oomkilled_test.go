@@ -0,0 +1,192 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	datadoghqv1alpha1test "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	ctrltest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/comparison"
+)
+
+func newOOMKilledPod(restartCount int32, reason string, resources corev1.ResourceRequirements, annotations map[string]string) *corev1.Pod {
+	pod := ctrltest.NewPod("bar", "agent", "node1", &ctrltest.NewPodOptions{Resources: resources, Annotations: annotations})
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name:         "agent",
+			RestartCount: restartCount,
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Reason: reason},
+			},
+		},
+	}
+	return pod
+}
+
+func newMemoryResources(request, limit string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(request)},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)},
+	}
+}
+
+func TestIncreaseOOMKilledContainersMemory(t *testing.T) {
+	eds := datadoghqv1alpha1test.NewExtendedDaemonSet("bar", "foo", nil)
+	maxMemory := resource.MustParse("1Gi")
+	eds.Spec.OOMKilledPolicy = datadoghqv1alpha1.DefaultExtendedDaemonSetSpecOOMKilledPolicy(&datadoghqv1alpha1.ExtendedDaemonSetSpecOOMKilledPolicy{
+		MaxMemory: &maxMemory,
+	})
+	annotationKey := fmt.Sprintf(datadoghqv1alpha1.ExtendedDaemonSetRessourceNodeAnnotationKey, "bar", "foo", "agent")
+
+	overwrittenNode := ctrltest.NewNode("node1", &ctrltest.NewNodeOptions{
+		Annotations: map[string]string{annotationKey: `{"limits":{"memory":"600Mi"},"requests":{"memory":"300Mi"}}`},
+	})
+	overwrittenNodeHash := comparison.GenerateHashFromEDSResourceNodeAnnotation("bar", "foo", overwrittenNode.Annotations)
+
+	tests := []struct {
+		name      string
+		pod       *corev1.Pod
+		node      *corev1.Node
+		want      *corev1.ResourceRequirements
+		wantEmpty bool
+	}{
+		{
+			name: "not enough restarts",
+			pod:  newOOMKilledPod(2, "OOMKilled", newMemoryResources("200Mi", "400Mi"), nil),
+			node: ctrltest.NewNode("node1", nil),
+			// default MinRestartCount is 3
+			wantEmpty: true,
+		},
+		{
+			name:      "restarts not due to OOMKilled",
+			pod:       newOOMKilledPod(5, "Error", newMemoryResources("200Mi", "400Mi"), nil),
+			node:      ctrltest.NewNode("node1", nil),
+			wantEmpty: true,
+		},
+		{
+			name: "memory increased by 50%",
+			pod:  newOOMKilledPod(3, "OOMKilled", newMemoryResources("200Mi", "400Mi"), nil),
+			node: ctrltest.NewNode("node1", nil),
+			want: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("300Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("600Mi")},
+			},
+		},
+		{
+			name: "memory capped to max memory",
+			pod:  newOOMKilledPod(3, "OOMKilled", newMemoryResources("600Mi", "800Mi"), nil),
+			node: ctrltest.NewNode("node1", nil),
+			want: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("900Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		},
+		{
+			name:      "max memory already reached",
+			pod:       newOOMKilledPod(3, "OOMKilled", newMemoryResources("600Mi", "1Gi"), nil),
+			node:      ctrltest.NewNode("node1", nil),
+			wantEmpty: true,
+		},
+		{
+			name:      "pod not created with the latest node annotations",
+			pod:       newOOMKilledPod(3, "OOMKilled", newMemoryResources("200Mi", "400Mi"), nil),
+			node:      overwrittenNode,
+			wantEmpty: true,
+		},
+		{
+			name: "pod created with the latest node annotations",
+			pod: newOOMKilledPod(3, "OOMKilled", newMemoryResources("300Mi", "600Mi"), map[string]string{
+				datadoghqv1alpha1.MD5NodeExtendedDaemonSetAnnotationKey: overwrittenNodeHash,
+			}),
+			node: overwrittenNode,
+			want: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("450Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("900Mi")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newNode, containers, err := increaseOOMKilledContainersMemory(eds, tt.pod, tt.node)
+			if err != nil {
+				t.Fatalf("increaseOOMKilledContainersMemory() error = %v", err)
+			}
+			if tt.wantEmpty {
+				if newNode != nil || len(containers) != 0 {
+					t.Errorf("increaseOOMKilledContainersMemory() should not update the node, containers: %v", containers)
+				}
+				return
+			}
+			if len(containers) != 1 || containers[0] != "agent" {
+				t.Fatalf("increaseOOMKilledContainersMemory() containers = %v, want [agent]", containers)
+			}
+			got := &corev1.ResourceRequirements{}
+			if err = json.Unmarshal([]byte(newNode.Annotations[annotationKey]), got); err != nil {
+				t.Fatalf("unable to decode the node annotation, err: %v", err)
+			}
+			for name, list := range map[string][2]corev1.ResourceList{"requests": {got.Requests, tt.want.Requests}, "limits": {got.Limits, tt.want.Limits}} {
+				gotMemory, wantMemory := list[0][corev1.ResourceMemory], list[1][corev1.ResourceMemory]
+				if gotMemory.Cmp(wantMemory) != 0 {
+					t.Errorf("%s.memory = %s, want %s", name, gotMemory.String(), wantMemory.String())
+				}
+			}
+		})
+	}
+}
+
+func TestManageOOMKilledPods(t *testing.T) {
+	eds := datadoghqv1alpha1test.NewExtendedDaemonSet("bar", "foo", nil)
+	maxMemory := resource.MustParse("1Gi")
+	eds.Spec.OOMKilledPolicy = datadoghqv1alpha1.DefaultExtendedDaemonSetSpecOOMKilledPolicy(&datadoghqv1alpha1.ExtendedDaemonSetSpecOOMKilledPolicy{
+		MaxMemory: &maxMemory,
+	})
+	annotationKey := fmt.Sprintf(datadoghqv1alpha1.ExtendedDaemonSetRessourceNodeAnnotationKey, "bar", "foo", "agent")
+
+	// the cached Node is trimmed: the images are only known by the API server
+	node := ctrltest.NewNode("node1", nil)
+	node.Status.Images = []corev1.ContainerImage{{Names: []string{"agent:1.0.0"}}}
+	cachedNode := node.DeepCopy()
+	cachedNode.Status.Images = nil
+	r := &ReconcileExtendedDaemonSetReplicaSet{
+		client:   fake.NewFakeClient(node),
+		recorder: record.NewFakeRecorder(10),
+	}
+	podByNodeName := map[*strategy.NodeItem]*corev1.Pod{
+		{Node: cachedNode}: newOOMKilledPod(3, "OOMKilled", newMemoryResources("200Mi", "400Mi"), nil),
+	}
+
+	if errs := r.manageOOMKilledPods(logf.Log, eds, podByNodeName); len(errs) != 0 {
+		t.Fatalf("manageOOMKilledPods() errors = %v", errs)
+	}
+	got := &corev1.Node{}
+	if err := r.client.Get(context.TODO(), client.ObjectKey{Name: "node1"}, got); err != nil {
+		t.Fatalf("unable to get the node, err: %v", err)
+	}
+	if got.Annotations[annotationKey] == "" {
+		t.Errorf("the node resources annotation is not set")
+	}
+	if len(got.Status.Images) != 1 {
+		t.Errorf("the node fields missing from the cache should not be overwritten, images: %v", got.Status.Images)
+	}
+
+	eds.Spec.OOMKilledPolicy.MaxMemory = nil
+	if errs := r.manageOOMKilledPods(logf.Log, eds, podByNodeName); len(errs) != 1 {
+		t.Errorf("manageOOMKilledPods() errors = %v, want an error without maxMemory", errs)
+	}
+}
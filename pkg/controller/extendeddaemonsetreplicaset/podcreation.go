@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/go-logr/logr"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/config"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+)
+
+const (
+	// maxNodesInConditionMessage maximum number of Nodes listed by failure reason in the PodCreationFailed condition
+	maxNodesInConditionMessage = 5
+)
+
+const (
+	podCreationFailedReasonTemplate = "PodTemplateError"
+	podCreationFailedReasonQuota    = "QuotaExceeded"
+	podCreationFailedReasonUnknown  = "Unknown"
+)
+
+// newPodCreationBackoff returns the backoff applied by Node to the repeated pod creation failures
+func newPodCreationBackoff(initial, max time.Duration) *flowcontrol.Backoff {
+	if initial <= 0 {
+		initial = config.DefaultPodCreationBackoff
+	}
+	if max <= 0 {
+		max = config.DefaultPodCreationMaxBackoff
+	}
+	return flowcontrol.NewBackOff(initial, max)
+}
+
+// podCreationResult summarizes the pods creation of a reconcile
+type podCreationResult struct {
+	// failedNodes Nodes where the pod creation failed, by failure reason
+	failedNodes map[string][]string
+	// errs errors not classified, like the apiserver timeouts, reported as reconcile errors
+	errs []error
+	// nbInBackoff number of pods not created because their Node is in backoff
+	nbInBackoff int
+	// requeueAfter delay before retrying the pods creation on the Nodes in backoff
+	requeueAfter time.Duration
+}
+
+// createPods creates the pods on the given Nodes with a bounded number of workers.
+// The Nodes where the pod creation failed recently are skipped until their backoff expires.
+func createPods(logger logr.Logger, c client.Client, scheme *runtime.Scheme, podAffinitySupported bool, backoff *flowcontrol.Backoff, workers int, edsName string, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, podsToCreate []*strategy.NodeItem) *podCreationResult {
+	result := &podCreationResult{failedNodes: map[string][]string{}}
+	if backoff == nil {
+		backoff = newPodCreationBackoff(0, 0)
+	}
+	backoff.GC()
+	now := backoff.Clock.Now()
+
+	var nodes []*strategy.NodeItem
+	for _, node := range podsToCreate {
+		key := podCreationBackoffKey(replicaset, node.Node.Name)
+		if backoff.IsInBackOffSinceUpdate(key, now) {
+			result.nbInBackoff++
+			if delay := backoff.Get(key); result.requeueAfter == 0 || delay < result.requeueAfter {
+				result.requeueAfter = delay
+			}
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	if result.nbInBackoff > 0 {
+		logger.Info("Pods creation delayed by the Nodes backoff", "nbPods", result.nbInBackoff, "requeueAfter", result.requeueAfter)
+	}
+
+	if workers <= 0 {
+		workers = config.DefaultPodCreationWorkers
+	}
+	if workers > len(nodes) {
+		workers = len(nodes)
+	}
+
+	type creation struct {
+		node *strategy.NodeItem
+		err  error
+	}
+	nodesChan := make(chan *strategy.NodeItem)
+	creationsChan := make(chan creation, len(nodes))
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for node := range nodesChan {
+				creationsChan <- creation{node: node, err: createPod(logger, c, scheme, podAffinitySupported, edsName, replicaset, node)}
+			}
+		}()
+	}
+	go func() {
+		for _, node := range nodes {
+			nodesChan <- node
+		}
+		close(nodesChan)
+		wg.Wait()
+		close(creationsChan)
+	}()
+
+	for cr := range creationsChan {
+		key := podCreationBackoffKey(replicaset, cr.node.Node.Name)
+		if cr.err == nil {
+			backoff.Reset(key)
+			continue
+		}
+		backoff.Next(key, now)
+		reason, classified := podCreationFailedReason(cr.err)
+		metrics.IncPodsCreationFailed(replicaset.Namespace, edsName, reason)
+		result.failedNodes[reason] = append(result.failedNodes[reason], cr.node.Node.Name)
+		if !classified {
+			result.errs = append(result.errs, cr.err)
+		}
+		if delay := backoff.Get(key); result.requeueAfter == 0 || delay < result.requeueAfter {
+			result.requeueAfter = delay
+		}
+	}
+	return result
+}
+
+func createPod(logger logr.Logger, c client.Client, scheme *runtime.Scheme, podAffinitySupported bool, edsName string, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, node *strategy.NodeItem) error {
+	newPod, err := podutils.CreatePodFromDaemonSetReplicaSet(scheme, replicaset, node.Node, node.ExtendedDaemonsetSetting, node.ResourcesProfile, podAffinitySupported)
+	if err != nil {
+		logger.Error(err, "Generate pod template failed", "node", node.Node.Name)
+		return &podTemplateError{err: err}
+	}
+	logger.V(1).Info("Create pod", "name", newPod.GenerateName, "node", node.Node.Name, "addAffinity", podAffinitySupported)
+	if err = c.Create(context.TODO(), newPod); err != nil {
+		logger.Error(err, "Create pod failed", "name", newPod.GenerateName, "node", node.Node.Name)
+		return err
+	}
+	metrics.IncPodsCreated(replicaset.Namespace, edsName)
+	return nil
+}
+
+func podCreationBackoffKey(replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, nodeName string) string {
+	return fmt.Sprintf("%s/%s/%s", replicaset.Namespace, replicaset.Name, nodeName)
+}
+
+// podTemplateError error returned when the pod can't be generated from the replicaset template
+type podTemplateError struct {
+	err error
+}
+
+func (e *podTemplateError) Error() string {
+	return e.err.Error()
+}
+
+// podCreationFailedReason returns the reason of a pod creation error, used as metric label and condition reason.
+// It returns false if the error is not classified, like the transient apiserver errors.
+func podCreationFailedReason(err error) (string, bool) {
+	if _, ok := err.(*podTemplateError); ok {
+		return podCreationFailedReasonTemplate, true
+	}
+	switch {
+	case errors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		return podCreationFailedReasonQuota, true
+	case errors.IsForbidden(err):
+		// also returned by the admission webhooks denying the pod
+		return string(metav1.StatusReasonForbidden), true
+	case errors.IsInvalid(err), errors.IsBadRequest(err):
+		return string(metav1.StatusReasonInvalid), true
+	}
+	if reason := errors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+		return string(reason), false
+	}
+	return podCreationFailedReasonUnknown, false
+}
+
+// setPodCreationFailedCondition reports the pods creation failures in the PodCreationFailed condition,
+// with the most frequent failure reason. The condition is only added on the first failure, it is kept
+// while the failing Nodes are in backoff and then moved to False.
+func setPodCreationFailedCondition(status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, now metav1.Time, result *podCreationResult) {
+	if len(result.failedNodes) == 0 {
+		if result.nbInBackoff == 0 {
+			if cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypePodCreationFailed); cond != nil {
+				conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(status, now, datadoghqv1alpha1.ConditionTypePodCreationFailed, corev1.ConditionFalse, "", false, false)
+				cond.Reason = ""
+			}
+		}
+		return
+	}
+
+	reasons := make([]string, 0, len(result.failedNodes))
+	for reason := range result.failedNodes {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	mainReason := reasons[0]
+	messages := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		nodes := result.failedNodes[reason]
+		if len(nodes) > len(result.failedNodes[mainReason]) {
+			mainReason = reason
+		}
+		sort.Strings(nodes)
+		if len(nodes) > maxNodesInConditionMessage {
+			nodes = append(nodes[:maxNodesInConditionMessage:maxNodesInConditionMessage], "...")
+		}
+		messages = append(messages, fmt.Sprintf("%s: %d pod(s) on nodes %s", reason, len(result.failedNodes[reason]), strings.Join(nodes, ",")))
+	}
+
+	conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(status, now, datadoghqv1alpha1.ConditionTypePodCreationFailed, corev1.ConditionTrue, strings.Join(messages, "; "), false, true)
+	conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypePodCreationFailed).Reason = mainReason
+}
@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+	"github.com/datadog/extendeddaemonset/pkg/config"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+// failingClient returns the configured error when creating a pod on the given Node
+type failingClient struct {
+	client.Client
+	errByNode map[string]error
+}
+
+func (c *failingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if pod, ok := obj.(*corev1.Pod); ok {
+		if err := c.errByNode[pod.Spec.NodeName]; err != nil {
+			return err
+		}
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func Test_createPods(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	log = logf.Log.WithName("Test_createPods")
+
+	s := scheme.Scheme
+	s.AddKnownTypes(datadoghqv1alpha1.SchemeGroupVersion, &datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{})
+	rs := test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", &test.NewExtendedDaemonSetReplicaSetOptions{Labels: map[string]string{datadoghqv1alpha1.ExtendedDaemonSetNameLabelKey: "foo"}})
+
+	var nodes []*strategy.NodeItem
+	for i := 1; i <= 4; i++ {
+		nodes = append(nodes, strategy.NewNodeItem(commontest.NewNode(fmt.Sprintf("node%d", i), nil), nil))
+	}
+	podsGR := schema.GroupResource{Resource: "pods"}
+	errByNode := map[string]error{
+		"node1": errors.NewForbidden(podsGR, "", fmt.Errorf("exceeded quota: compute-resources, requested: cpu=1, used: cpu=10, limited: cpu=10")),
+		"node2": errors.NewForbidden(podsGR, "", fmt.Errorf(`admission webhook "policy.example.com" denied the request`)),
+		"node3": errors.NewServerTimeout(podsGR, "create", 1),
+	}
+
+	tests := []struct {
+		name            string
+		workers         int
+		secondCall      time.Duration
+		wantFailedNodes map[string][]string
+		wantNbErrs      int
+		wantNbInBackoff int
+		wantNbPods      int
+		wantRequeue     time.Duration
+	}{
+		{
+			name:    "classified and transient failures",
+			workers: 2,
+			wantFailedNodes: map[string][]string{
+				podCreationFailedReasonQuota:             {"node1"},
+				string(metav1.StatusReasonForbidden):     {"node2"},
+				string(metav1.StatusReasonServerTimeout): {"node3"},
+			},
+			wantNbErrs:  1,
+			wantNbPods:  1,
+			wantRequeue: config.DefaultPodCreationBackoff,
+		},
+		{
+			name:            "failing nodes in backoff",
+			secondCall:      5 * time.Second,
+			wantFailedNodes: map[string][]string{},
+			wantNbInBackoff: 3,
+			wantNbPods:      2,
+			wantRequeue:     config.DefaultPodCreationBackoff,
+		},
+		{
+			name:       "failing nodes retried after the backoff, with a longer backoff",
+			secondCall: config.DefaultPodCreationBackoff,
+			wantFailedNodes: map[string][]string{
+				podCreationFailedReasonQuota:             {"node1"},
+				string(metav1.StatusReasonForbidden):     {"node2"},
+				string(metav1.StatusReasonServerTimeout): {"node3"},
+			},
+			wantNbErrs:  1,
+			wantNbPods:  2,
+			wantRequeue: 2 * config.DefaultPodCreationBackoff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewFakeClientWithScheme(s)
+			c := &failingClient{Client: fakeClient, errByNode: errByNode}
+			fakeClock := clock.NewFakeClock(time.Now())
+			backoff := flowcontrol.NewFakeBackOff(config.DefaultPodCreationBackoff, config.DefaultPodCreationMaxBackoff, fakeClock)
+
+			got := createPods(log, c, s, false, backoff, tt.workers, "foo", rs, nodes)
+			if tt.secondCall > 0 {
+				fakeClock.Step(tt.secondCall)
+				got = createPods(log, c, s, false, backoff, tt.workers, "foo", rs, nodes)
+			}
+
+			if !reflect.DeepEqual(got.failedNodes, tt.wantFailedNodes) {
+				t.Errorf("createPods() failedNodes = %v, want %v", got.failedNodes, tt.wantFailedNodes)
+			}
+			if len(got.errs) != tt.wantNbErrs {
+				t.Errorf("createPods() errs = %v, want %d errors", got.errs, tt.wantNbErrs)
+			}
+			if got.nbInBackoff != tt.wantNbInBackoff {
+				t.Errorf("createPods() nbInBackoff = %d, want %d", got.nbInBackoff, tt.wantNbInBackoff)
+			}
+			if got.requeueAfter != tt.wantRequeue {
+				t.Errorf("createPods() requeueAfter = %v, want %v", got.requeueAfter, tt.wantRequeue)
+			}
+			podList := &corev1.PodList{}
+			_ = fakeClient.List(context.TODO(), podList)
+			if len(podList.Items) != tt.wantNbPods {
+				t.Errorf("createPods() created %d pods, want %d", len(podList.Items), tt.wantNbPods)
+			}
+		})
+	}
+}
+
+func Test_setPodCreationFailedCondition(t *testing.T) {
+	now := metav1.Now()
+	status := &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{}
+
+	// no failure yet, the condition is not added
+	setPodCreationFailedCondition(status, now, &podCreationResult{})
+	if cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypePodCreationFailed); cond != nil {
+		t.Fatalf("setPodCreationFailedCondition() condition = %v, want nil before the first failure", cond)
+	}
+
+	setPodCreationFailedCondition(status, now, &podCreationResult{failedNodes: map[string][]string{
+		podCreationFailedReasonQuota:         {"node3", "node1", "node2", "node4", "node5", "node6"},
+		string(metav1.StatusReasonForbidden): {"node7"},
+	}})
+	cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypePodCreationFailed)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != podCreationFailedReasonQuota {
+		t.Fatalf("setPodCreationFailedCondition() condition = %v, want True with reason %s", cond, podCreationFailedReasonQuota)
+	}
+	wantMessage := "Forbidden: 1 pod(s) on nodes node7; QuotaExceeded: 6 pod(s) on nodes node1,node2,node3,node4,node5,..."
+	if cond.Message != wantMessage {
+		t.Errorf("setPodCreationFailedCondition() message = %q, want %q", cond.Message, wantMessage)
+	}
+
+	// the failing Nodes are in backoff, the condition is kept
+	setPodCreationFailedCondition(status, now, &podCreationResult{nbInBackoff: 7})
+	if cond = conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypePodCreationFailed); cond.Status != corev1.ConditionTrue {
+		t.Errorf("setPodCreationFailedCondition() status = %s, want True while the Nodes are in backoff", cond.Status)
+	}
+
+	setPodCreationFailedCondition(status, now, &podCreationResult{})
+	if cond = conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypePodCreationFailed); cond.Status != corev1.ConditionFalse || cond.Reason != "" {
+		t.Errorf("setPodCreationFailedCondition() condition = %v, want False without reason", cond)
+	}
+}
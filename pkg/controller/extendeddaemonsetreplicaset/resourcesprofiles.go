@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+)
+
+const (
+	// maxResourcesProfileFailedRetries number of failed retries of the previous profile after which a Node keeps its profile
+	maxResourcesProfileFailedRetries = 5
+)
+
+// manageResourcesProfiles selects the resources profile used on each Node and stores it in the new status.
+// A Node switches to the next profile when its pod stays blocked by resource constraints for more than
+// UnschedulableDuration, and goes back to the previous profile after RetryInterval. The retry interval doubles
+// after each failed retry, and the Node stops retrying after maxResourcesProfileFailedRetries. A retry succeeds once
+// the pod created with the retried profile runs, the Nodes back to the default resources are then removed from the status.
+// A new replicaset starts from the profiles of the active replicaset, mirrored in the ExtendedDaemonSet status.
+// It returns the pods to delete: they are recreated with the new profile.
+func manageResourcesProfiles(logger logr.Logger, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, params *strategy.Parameters, now time.Time) []*corev1.Pod {
+	spec := daemonset.Spec.ResourcesProfiles
+	if spec == nil || len(spec.Profiles) == 0 || spec.UnschedulableDuration == nil || spec.RetryInterval == nil {
+		params.NewStatus.NodesResourcesProfile = nil
+		return nil
+	}
+
+	profileIndex := map[string]int{}
+	for id, profile := range spec.Profiles {
+		profileIndex[profile.Name] = id
+	}
+
+	currentNodesProfile := params.NewStatus.NodesResourcesProfile
+	if currentNodesProfile == nil && conditions.GetExtendedDaemonSetReplicaSetStatusCondition(params.NewStatus, datadoghqv1alpha1.ConditionTypeLastFullSync) == nil {
+		// first reconcile of the replicaset
+		currentNodesProfile = daemonset.Status.NodesResourcesProfile
+	}
+
+	// retrieve the current profile of each Node, step back to the previous profile after the retry interval
+	nodesProfile := map[string]datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{}
+	for _, nodeProfile := range currentNodesProfile {
+		id, ok := profileIndex[nodeProfile.Profile]
+		if nodeProfile.Profile == "" {
+			// default resources restored by a retry
+			id, ok = -1, true
+		}
+		if _, nodeExists := params.NodeByName[nodeProfile.NodeName]; !ok || !nodeExists {
+			continue
+		}
+		if id >= 0 && nodeProfile.FailedRetries < maxResourcesProfileFailedRetries && nodeProfile.LastTransitionTime.Add(resourcesProfileRetryInterval(spec.RetryInterval.Duration, nodeProfile.FailedRetries)).Before(now) {
+			nodeProfile.Profile = ""
+			if id > 0 {
+				nodeProfile.Profile = spec.Profiles[id-1].Name
+			}
+			logger.Info("Retry the previous resources profile", "node", nodeProfile.NodeName, "profile", nodeProfile.Profile, "failedRetries", nodeProfile.FailedRetries)
+			nodeProfile.Retry = true
+			nodeProfile.LastTransitionTime = metav1.NewTime(now)
+		}
+		nodesProfile[nodeProfile.NodeName] = nodeProfile
+	}
+
+	// switch to the next profile on the Nodes where the pod doesn't fit
+	var podsToDelete []*corev1.Pod
+	pods := append([]*corev1.Pod{}, params.UnscheduledPods...)
+	for _, pod := range params.PodByNodeName {
+		if pod != nil {
+			pods = append(pods, pod)
+		}
+	}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		blocked, since := podutils.IsPodBlockedByResourcesConstraints(pod)
+		if !blocked || since.Add(spec.UnschedulableDuration.Duration).After(now) {
+			continue
+		}
+		nodeName, _ := podutils.IsPodScheduled(pod)
+		if pod.Spec.NodeName != "" {
+			nodeName = pod.Spec.NodeName
+		}
+		nodeItem, ok := params.NodeByName[nodeName]
+		if !ok {
+			continue
+		}
+
+		current := nodesProfile[nodeName]
+		currentProfile := current.Profile
+		if pod.Annotations[datadoghqv1alpha1.ResourcesProfileAnnotationKey] != currentProfile {
+			// the pod will be replaced with the current profile
+			continue
+		}
+		nextID := 0
+		if currentProfile != "" {
+			nextID = profileIndex[currentProfile] + 1
+		}
+		if nextID >= len(spec.Profiles) {
+			logger.Info("No smaller resources profile available", "node", nodeName, "pod", pod.Name)
+			continue
+		}
+
+		failedRetries := current.FailedRetries
+		if current.Retry {
+			failedRetries++
+		}
+		logger.Info("Pod blocked by resources constraints, use the next resources profile", "node", nodeName, "pod", pod.Name, "profile", spec.Profiles[nextID].Name, "failedRetries", failedRetries)
+		nodesProfile[nodeName] = datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+			NodeName:           nodeName,
+			Profile:            spec.Profiles[nextID].Name,
+			LastTransitionTime: metav1.NewTime(now),
+			FailedRetries:      failedRetries,
+		}
+		podsToDelete = append(podsToDelete, pod)
+		if params.PodByNodeName[nodeItem] == pod {
+			// let the strategy create the new pod
+			params.PodByNodeName[nodeItem] = nil
+		}
+	}
+
+	// clear the retries whose pod runs, so a later resources constraint isn't counted as a failed retry
+	for nodeName, nodeProfile := range nodesProfile {
+		if !nodeProfile.Retry || !isResourcesProfileRetrySucceeded(params.PodByNodeName[params.NodeByName[nodeName]], &nodeProfile) {
+			continue
+		}
+		if nodeProfile.Profile == "" {
+			// default resources restored, nothing to keep for this Node
+			delete(nodesProfile, nodeName)
+			continue
+		}
+		nodeProfile.Retry = false
+		nodesProfile[nodeName] = nodeProfile
+	}
+
+	// update the status and the NodeItems
+	var newNodesProfile []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile
+	for nodeName, nodeProfile := range nodesProfile {
+		newNodesProfile = append(newNodesProfile, nodeProfile)
+		if nodeProfile.Profile != "" {
+			params.NodeByName[nodeName].ResourcesProfile = &spec.Profiles[profileIndex[nodeProfile.Profile]]
+		}
+	}
+	sort.Slice(newNodesProfile, func(i, j int) bool { return newNodesProfile[i].NodeName < newNodesProfile[j].NodeName })
+	params.NewStatus.NodesResourcesProfile = newNodesProfile
+
+	return podsToDelete
+}
+
+// resourcesProfileRetryInterval returns the retry interval doubled after each failed retry
+func resourcesProfileRetryInterval(interval time.Duration, failedRetries int32) time.Duration {
+	for i := int32(0); i < failedRetries; i++ {
+		interval *= 2
+	}
+	return interval
+}
+
+// isResourcesProfileRetrySucceeded returns true if the pod has been created with the retried profile and runs
+func isResourcesProfileRetrySucceeded(pod *corev1.Pod, nodeProfile *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile) bool {
+	if pod == nil || pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	if pod.Annotations[datadoghqv1alpha1.ResourcesProfileAnnotationKey] != nodeProfile.Profile {
+		return false
+	}
+	return !pod.CreationTimestamp.Before(&nodeProfile.LastTransitionTime)
+}
@@ -0,0 +1,269 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"testing"
+	"time"
+
+	cmp "github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	datadoghqv1alpha1test "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	ctrltest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func Test_manageResourcesProfiles(t *testing.T) {
+	logger := logf.Log.WithName("test")
+	now := time.Now()
+	eds := datadoghqv1alpha1test.NewExtendedDaemonSet("bar", "foo", nil)
+	eds.Spec.ResourcesProfiles = datadoghqv1alpha1.DefaultExtendedDaemonSetSpecResourcesProfiles(&datadoghqv1alpha1.ExtendedDaemonSetSpecResourcesProfiles{
+		Profiles: []datadoghqv1alpha1.ExtendedDaemonSetResourcesProfile{{Name: "medium"}, {Name: "small"}},
+	})
+
+	newBlockedPod := func(name, nodeName, profile string) *corev1.Pod {
+		pod := ctrltest.NewPod("bar", name, nodeName, &ctrltest.NewPodOptions{
+			CreationTimestamp: metav1.NewTime(now.Add(-10 * time.Minute)),
+			Phase:             corev1.PodFailed,
+			Reason:            "OutOfcpu",
+		})
+		if profile != "" {
+			pod.Annotations[datadoghqv1alpha1.ResourcesProfileAnnotationKey] = profile
+		}
+		return pod
+	}
+	newRunningPod := func(name, nodeName, profile string) *corev1.Pod {
+		pod := ctrltest.NewPod("bar", name, nodeName, &ctrltest.NewPodOptions{
+			CreationTimestamp: metav1.NewTime(now.Add(-5 * time.Minute)),
+			Phase:             corev1.PodRunning,
+		})
+		if profile != "" {
+			pod.Annotations[datadoghqv1alpha1.ResourcesProfileAnnotationKey] = profile
+		}
+		return pod
+	}
+	recentlyBlockedPod := newBlockedPod("pod-recent", "node1", "")
+	recentlyBlockedPod.CreationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+
+	tests := []struct {
+		name           string
+		status         []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile
+		pod            *corev1.Pod
+		wantStatus     []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile
+		wantPodDeleted bool
+	}{
+		{
+			name: "running pod, no profile",
+			pod:  ctrltest.NewPod("bar", "pod1", "node1", nil),
+		},
+		{
+			name: "pod blocked for less than the unschedulable duration",
+			pod:  recentlyBlockedPod,
+		},
+		{
+			name: "pod blocked, use the first profile",
+			pod:  newBlockedPod("pod1", "node1", ""),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now)},
+			},
+			wantPodDeleted: true,
+		},
+		{
+			name: "pod blocked with the first profile, use the next profile",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))},
+			},
+			pod: newBlockedPod("pod1", "node1", "medium"),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "small", LastTransitionTime: metav1.NewTime(now)},
+			},
+			wantPodDeleted: true,
+		},
+		{
+			name: "pod blocked with the last profile",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "small", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))},
+			},
+			pod: newBlockedPod("pod1", "node1", "small"),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "small", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))},
+			},
+		},
+		{
+			name: "pod blocked but not created with the current profile",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))},
+			},
+			pod: newBlockedPod("pod1", "node1", ""),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))},
+			},
+		},
+		{
+			name: "retry interval reached, use the previous profile",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "small", LastTransitionTime: metav1.NewTime(now.Add(-2 * time.Hour))},
+			},
+			pod: ctrltest.NewPod("bar", "pod1", "node1", nil),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now), Retry: true},
+			},
+		},
+		{
+			name: "retry interval reached, use the default resources",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-2 * time.Hour))},
+			},
+			pod: ctrltest.NewPod("bar", "pod1", "node1", nil),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "", LastTransitionTime: metav1.NewTime(now), Retry: true},
+			},
+		},
+		{
+			name: "pod blocked after a retry, count the failed retry",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)), Retry: true, FailedRetries: 1},
+			},
+			pod: newBlockedPod("pod1", "node1", ""),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now), FailedRetries: 2},
+			},
+			wantPodDeleted: true,
+		},
+		{
+			name: "pod runs with the retried profile, clear the retry",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)), Retry: true, FailedRetries: 1},
+			},
+			pod: newRunningPod("pod1", "node1", "medium"),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)), FailedRetries: 1},
+			},
+		},
+		{
+			name: "pod runs with the default resources after a retry, remove the node",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)), Retry: true},
+			},
+			pod: newRunningPod("pod1", "node1", ""),
+		},
+		{
+			name: "pod created before the retry, keep the retry",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "", LastTransitionTime: metav1.NewTime(now), Retry: true},
+			},
+			pod: newRunningPod("pod1", "node1", ""),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "", LastTransitionTime: metav1.NewTime(now), Retry: true},
+			},
+		},
+		{
+			name: "retry interval doubled after a failed retry",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-90 * time.Minute)), FailedRetries: 1},
+			},
+			pod: ctrltest.NewPod("bar", "pod1", "node1", nil),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-90 * time.Minute)), FailedRetries: 1},
+			},
+		},
+		{
+			name: "too many failed retries, keep the profile",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-1000 * time.Hour)), FailedRetries: maxResourcesProfileFailedRetries},
+			},
+			pod: ctrltest.NewPod("bar", "pod1", "node1", nil),
+			wantStatus: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node1", Profile: "medium", LastTransitionTime: metav1.NewTime(now.Add(-1000 * time.Hour)), FailedRetries: maxResourcesProfileFailedRetries},
+			},
+		},
+		{
+			name: "unknown node is removed",
+			status: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+				{NodeName: "node2", Profile: "medium", LastTransitionTime: metav1.NewTime(now)},
+			},
+			pod: ctrltest.NewPod("bar", "pod1", "node1", nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeItem := &strategy.NodeItem{Node: ctrltest.NewNode("node1", nil)}
+			params := &strategy.Parameters{
+				NodeByName:    map[string]*strategy.NodeItem{"node1": nodeItem},
+				PodByNodeName: map[*strategy.NodeItem]*corev1.Pod{nodeItem: tt.pod},
+				NewStatus:     &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{NodesResourcesProfile: tt.status},
+			}
+
+			podsToDelete := manageResourcesProfiles(logger, eds, params, now)
+
+			if diff := cmp.Diff(tt.wantStatus, params.NewStatus.NodesResourcesProfile); diff != "" {
+				t.Errorf("manageResourcesProfiles() status mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantPodDeleted != (len(podsToDelete) == 1) {
+				t.Errorf("manageResourcesProfiles() podsToDelete = %v, wantPodDeleted %v", podsToDelete, tt.wantPodDeleted)
+			}
+			if tt.wantPodDeleted && params.PodByNodeName[nodeItem] != nil {
+				t.Errorf("manageResourcesProfiles() the deleted pod should be removed from PodByNodeName")
+			}
+			var wantProfile string
+			if len(tt.wantStatus) > 0 {
+				wantProfile = tt.wantStatus[0].Profile
+			}
+			var gotProfile string
+			if nodeItem.ResourcesProfile != nil {
+				gotProfile = nodeItem.ResourcesProfile.Name
+			}
+			if gotProfile != wantProfile {
+				t.Errorf("manageResourcesProfiles() node profile = %s, want %s", gotProfile, wantProfile)
+			}
+		})
+	}
+}
+
+func Test_manageResourcesProfiles_newReplicaSet(t *testing.T) {
+	logger := logf.Log.WithName("test")
+	now := time.Now()
+	eds := datadoghqv1alpha1test.NewExtendedDaemonSet("bar", "foo", nil)
+	eds.Spec.ResourcesProfiles = datadoghqv1alpha1.DefaultExtendedDaemonSetSpecResourcesProfiles(&datadoghqv1alpha1.ExtendedDaemonSetSpecResourcesProfiles{
+		Profiles: []datadoghqv1alpha1.ExtendedDaemonSetResourcesProfile{{Name: "medium"}, {Name: "small"}},
+	})
+	// profiles mirrored from the previous active replicaset
+	eds.Status.NodesResourcesProfile = []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNodeResourcesProfile{
+		{NodeName: "node1", Profile: "small", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))},
+	}
+
+	newParams := func(status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus) (*strategy.Parameters, *strategy.NodeItem) {
+		nodeItem := &strategy.NodeItem{Node: ctrltest.NewNode("node1", nil)}
+		return &strategy.Parameters{
+			NodeByName:    map[string]*strategy.NodeItem{"node1": nodeItem},
+			PodByNodeName: map[*strategy.NodeItem]*corev1.Pod{nodeItem: nil},
+			NewStatus:     status,
+		}, nodeItem
+	}
+
+	params, nodeItem := newParams(&datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{})
+	manageResourcesProfiles(logger, eds, params, now)
+	if diff := cmp.Diff(eds.Status.NodesResourcesProfile, params.NewStatus.NodesResourcesProfile); diff != "" {
+		t.Errorf("manageResourcesProfiles() new replicaset status mismatch (-want +got):\n%s", diff)
+	}
+	if nodeItem.ResourcesProfile == nil || nodeItem.ResourcesProfile.Name != "small" {
+		t.Errorf("manageResourcesProfiles() new replicaset node profile = %v, want small", nodeItem.ResourcesProfile)
+	}
+
+	// the replicaset profiles are not reset after its first reconcile
+	status := &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{}
+	conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(status, metav1.NewTime(now), datadoghqv1alpha1.ConditionTypeLastFullSync, corev1.ConditionTrue, "full sync", true, true)
+	params, nodeItem = newParams(status)
+	manageResourcesProfiles(logger, eds, params, now)
+	if len(params.NewStatus.NodesResourcesProfile) != 0 || nodeItem.ResourcesProfile != nil {
+		t.Errorf("manageResourcesProfiles() status = %v, want the default resources", params.NewStatus.NodesResourcesProfile)
+	}
+}
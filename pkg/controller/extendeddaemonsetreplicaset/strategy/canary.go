@@ -41,7 +41,13 @@ func ManageCanaryDeployment(client client.Client, daemonset *v1alpha1.ExtendedDa
 					needRequeue = true
 					continue
 				}
-				if !compareCurrentPodWithNewPod(params, pod, node) && pod.DeletionTimestamp == nil {
+				isUpToDate, compareErr := compareCurrentPodWithNewPod(params, pod, node)
+				if compareErr != nil {
+					// the pod can't be created on this Node either, so the current pod is kept
+					params.Logger.Error(compareErr, "Unable to compare the pod with the expected one", "pod_name", pod.Name, "node", nodeName)
+					continue
+				}
+				if !isUpToDate {
 					result.PodsToDelete = append(result.PodsToDelete, node)
 				} else {
 					currentPods++
@@ -74,13 +80,17 @@ func ManageCanaryDeployment(client client.Client, daemonset *v1alpha1.ExtendedDa
 	result.NewStatus.Available = availablePods
 	result.NewStatus.Current = currentPods
 	params.Logger.V(1).Info("NewStatus", "Desired", desiredPods, "Ready", readyPods, "Available", availablePods)
+
+	if updateProgressingCondition(params.Strategy, &params.Replicaset.Status, result.NewStatus, metaNow) {
+		manageCanaryProgressDeadline(client, daemonset, params, isPaused)
+	}
 	params.Logger.V(1).Info("Result", "PodsToCreate", result.PodsToCreate, "PodsToDelete", result.PodsToDelete)
 
 	// Populate list of unscheduled pods on nodes due to resource limitation
 	result.UnscheduledNodesDueToResourcesConstraints = manageUnscheduledPodNodes(params.UnscheduledPods)
 
 	// Cleanup Pods
-	result.NewStatus, result.Result, err = cleanupPods(client, params.Logger, result.NewStatus, params.PodToCleanUp)
+	result.NewStatus, result.Result, err = cleanupPods(client, params, result.NewStatus, params.PodToCleanUp)
 	if result.NewStatus.Desired != result.NewStatus.Ready || needRequeue {
 		result.Result.Requeue = true
 		result.Result.RequeueAfter = time.Second
@@ -88,3 +98,27 @@ func ManageCanaryDeployment(client client.Client, daemonset *v1alpha1.ExtendedDa
 
 	return result, err
 }
+
+// manageCanaryProgressDeadline executes the progress deadline action on the Canary deployment: pause or fail it
+func manageCanaryProgressDeadline(client client.Client, daemonset *v1alpha1.ExtendedDaemonSet, params *Parameters, isPaused bool) {
+	var err error
+	switch params.Strategy.ProgressDeadlineAction {
+	case v1alpha1.ExtendedDaemonSetProgressDeadlineActionPause:
+		if isPaused {
+			return
+		}
+		err = pauseCanaryDeployment(client, daemonset, v1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded)
+	case v1alpha1.ExtendedDaemonSetProgressDeadlineActionRollback:
+		if eds.IsCanaryDeploymentFailed(daemonset.GetAnnotations()) {
+			return
+		}
+		err = failCanaryDeployment(client, daemonset, v1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded)
+	default:
+		return
+	}
+	if err != nil {
+		params.Logger.Error(err, "Failed to execute the progress deadline action on the canary deployment", "action", params.Strategy.ProgressDeadlineAction)
+	} else {
+		params.Logger.Info("Canary deployment progress deadline exceeded", "action", params.Strategy.ProgressDeadlineAction)
+	}
+}
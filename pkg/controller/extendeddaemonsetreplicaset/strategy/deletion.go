@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package strategy
+
+import (
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+)
+
+// isPodUnhealthy returns true if the pod is not ready or in CrashLoopBackOff.
+// Replacing such a pod doesn't reduce the number of available pods.
+func isPodUnhealthy(pod *corev1.Pod) bool {
+	return !podutils.IsPodReady(pod) || podutils.IsPodInCrashLoopBackOff(pod)
+}
+
+// sortNodesByDeletionPriority sorts the Nodes of the pods to replace: the unhealthy pods first,
+// then following the rolling update deletion order. Ties are broken by Node name.
+func sortNodesByDeletionPriority(rollingUpdate *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate, nodes []*NodeItem, podByNode map[*NodeItem]*corev1.Pod) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		podI, podJ := podByNode[nodes[i]], podByNode[nodes[j]]
+		if unhealthyI, unhealthyJ := isPodUnhealthy(podI), isPodUnhealthy(podJ); unhealthyI != unhealthyJ {
+			return unhealthyI
+		}
+		if rollingUpdate.DeletionOrder == datadoghqv1alpha1.ExtendedDaemonSetDeletionOrderNodeLabel && rollingUpdate.DeletionOrderNodeLabelKey != "" {
+			valueI, foundI := nodes[i].Node.Labels[rollingUpdate.DeletionOrderNodeLabelKey]
+			valueJ, foundJ := nodes[j].Node.Labels[rollingUpdate.DeletionOrderNodeLabelKey]
+			if foundI != foundJ {
+				return foundI
+			}
+			if valueI != valueJ {
+				return lessLabelValue(valueI, valueJ)
+			}
+		}
+		if !podI.CreationTimestamp.Equal(&podJ.CreationTimestamp) {
+			return podI.CreationTimestamp.Before(&podJ.CreationTimestamp)
+		}
+		return nodes[i].Node.Name < nodes[j].Node.Name
+	})
+}
+
+// lessLabelValue compares two label values as numbers if both are integers, else as strings
+func lessLabelValue(a, b string) bool {
+	intA, errA := strconv.ParseInt(a, 10, 64)
+	intB, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return intA < intB
+	}
+	return a < b
+}
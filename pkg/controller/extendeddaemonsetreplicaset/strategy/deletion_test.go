@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package strategy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func Test_sortNodesByDeletionPriority(t *testing.T) {
+	now := time.Now()
+	podByNode := map[*NodeItem]*corev1.Pod{}
+	newNode := func(name, priority string, age time.Duration, ready bool, crashLooping bool) *NodeItem {
+		labels := map[string]string{}
+		if priority != "" {
+			labels["priority"] = priority
+		}
+		node := NewNodeItem(commontest.NewNode(name, &commontest.NewNodeOptions{Labels: labels}), nil)
+		pod := commontest.NewPod("bar", name, name, &commontest.NewPodOptions{
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			Phase:             corev1.PodRunning,
+		})
+		if ready {
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		}
+		if crashLooping {
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: name, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}}}
+		}
+		podByNode[node] = pod
+		return node
+	}
+
+	node1 := newNode("node1", "10", time.Hour, true, false)
+	node2 := newNode("node2", "2", 2*time.Hour, true, false)
+	node3 := newNode("node3", "", 3*time.Hour, true, false)
+	node4 := newNode("node4", "1", time.Minute, false, false)
+	node5 := newNode("node5", "3", time.Minute, true, true)
+
+	tests := []struct {
+		name          string
+		rollingUpdate datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate
+		want          []*NodeItem
+	}{
+		{
+			name:          "oldest pod",
+			rollingUpdate: datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate{DeletionOrder: datadoghqv1alpha1.ExtendedDaemonSetDeletionOrderOldestPod},
+			want:          []*NodeItem{node4, node5, node3, node2, node1},
+		},
+		{
+			name: "node label",
+			rollingUpdate: datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate{
+				DeletionOrder:             datadoghqv1alpha1.ExtendedDaemonSetDeletionOrderNodeLabel,
+				DeletionOrderNodeLabelKey: "priority",
+			},
+			want: []*NodeItem{node4, node5, node2, node1, node3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := []*NodeItem{node1, node2, node3, node4, node5}
+			sortNodesByDeletionPriority(&tt.rollingUpdate, nodes, podByNode)
+			if !reflect.DeepEqual(nodes, tt.want) {
+				var got []string
+				for _, node := range nodes {
+					got = append(got, node.Node.Name)
+				}
+				t.Errorf("sortNodesByDeletionPriority() = %v", got)
+			}
+		})
+	}
+}
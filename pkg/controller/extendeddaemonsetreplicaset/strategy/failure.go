@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+)
+
+// checkRollingUpdateFailure returns true, with the reason and a message, if the number of failed pods
+// of the new version exceeds the failure policy limit.
+// A pod is failed if it is not ready after the policy deadline, or if its containers restarted too many times.
+func checkRollingUpdateFailure(policy *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy, newPods []*corev1.Pod, nbNodes int, now time.Time) (bool, datadoghqv1alpha1.ExtendedDaemonSetStatusReason, string, error) {
+	if policy == nil {
+		return false, "", "", nil
+	}
+	maxFailedPods, err := intstrutil.GetValueFromIntOrPercent(policy.MaxFailedPods, nbNodes, true)
+	if err != nil {
+		return false, "", "", err
+	}
+
+	var nbRestarting, nbUnavailable int
+	for _, pod := range newPods {
+		switch {
+		case policy.MaxRestarts != nil && podutils.GetMaxContainerRestartCount(pod) > *policy.MaxRestarts:
+			nbRestarting++
+		case policy.UnavailableDeadline != nil && !podutils.IsPodReady(pod) && now.Sub(pod.CreationTimestamp.Time) > policy.UnavailableDeadline.Duration:
+			nbUnavailable++
+		}
+	}
+	if nbRestarting+nbUnavailable <= maxFailedPods {
+		return false, "", "", nil
+	}
+
+	reason := datadoghqv1alpha1.ExtendedDaemonSetStatusReasonPodsUnavailable
+	if nbRestarting >= nbUnavailable {
+		reason = datadoghqv1alpha1.ExtendedDaemonSetStatusReasonPodsRestarting
+	}
+	message := fmt.Sprintf("%d pods of the new version failed, maximum: %d (restarting: %d, unavailable: %d)", nbRestarting+nbUnavailable, maxFailedPods, nbRestarting, nbUnavailable)
+	return true, reason, message, nil
+}
+
+// setRollingUpdateFailedCondition sets the RollingUpdateFailed condition with its reason
+func setRollingUpdateFailedCondition(status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, now metav1.Time, reason datadoghqv1alpha1.ExtendedDaemonSetStatusReason, message string) {
+	conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(status, now, datadoghqv1alpha1.ConditionTypeRollingUpdateFailed, corev1.ConditionTrue, message, false, false)
+	conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypeRollingUpdateFailed).Reason = string(reason)
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func Test_checkRollingUpdateFailure(t *testing.T) {
+	now := time.Now()
+	maxFailedPods := intstr.FromInt(1)
+	maxRestarts := int32(5)
+	policy := &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy{
+		MaxFailedPods:       &maxFailedPods,
+		UnavailableDeadline: &metav1.Duration{Duration: 10 * time.Minute},
+		MaxRestarts:         &maxRestarts,
+	}
+
+	newPod := func(name string, age time.Duration, ready bool, restartCount int32) *corev1.Pod {
+		pod := commontest.NewPod("bar", name, name, &commontest.NewPodOptions{
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			Phase:             corev1.PodRunning,
+		})
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: name, RestartCount: restartCount}}
+		if ready {
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		}
+		return pod
+	}
+
+	tests := []struct {
+		name       string
+		policy     *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdateFailurePolicy
+		newPods    []*corev1.Pod
+		want       bool
+		wantReason datadoghqv1alpha1.ExtendedDaemonSetStatusReason
+	}{
+		{
+			name:    "no policy",
+			newPods: []*corev1.Pod{newPod("node1", time.Hour, false, 10), newPod("node2", time.Hour, false, 10)},
+		},
+		{
+			name:    "healthy pods",
+			policy:  policy,
+			newPods: []*corev1.Pod{newPod("node1", time.Hour, true, 0), newPod("node2", time.Minute, false, 0)},
+		},
+		{
+			name:    "failed pods under the limit",
+			policy:  policy,
+			newPods: []*corev1.Pod{newPod("node1", time.Hour, false, 0), newPod("node2", time.Hour, true, 1)},
+		},
+		{
+			name:       "restarting pods",
+			policy:     policy,
+			newPods:    []*corev1.Pod{newPod("node1", time.Minute, true, 6), newPod("node2", time.Minute, false, 10), newPod("node3", time.Minute, true, 0)},
+			want:       true,
+			wantReason: datadoghqv1alpha1.ExtendedDaemonSetStatusReasonPodsRestarting,
+		},
+		{
+			name:       "pods unavailable after the deadline",
+			policy:     policy,
+			newPods:    []*corev1.Pod{newPod("node1", time.Hour, false, 0), newPod("node2", 11*time.Minute, false, 1), newPod("node3", time.Hour, true, 0)},
+			want:       true,
+			wantReason: datadoghqv1alpha1.ExtendedDaemonSetStatusReasonPodsUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotReason, _, err := checkRollingUpdateFailure(tt.policy, tt.newPods, 10, now)
+			if err != nil {
+				t.Fatalf("checkRollingUpdateFailure() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("checkRollingUpdateFailure() got = %v, want %v", got, tt.want)
+			}
+			if gotReason != tt.wantReason {
+				t.Errorf("checkRollingUpdateFailure() reason = %v, want %v", gotReason, tt.wantReason)
+			}
+		})
+	}
+}
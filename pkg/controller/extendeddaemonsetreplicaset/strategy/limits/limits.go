@@ -42,3 +42,39 @@ func CalculatePodToCreateAndDelete(params Parameters) (nbCreation, nbDeletion in
 
 	return nbCreation, nbDeletion
 }
+
+// SlowStartMaxPodCreation returns the maximum number of pods created in parallel during the slow start interval nbSlot:
+// it increases by startValue at each interval, up to maxParallelPodCreation.
+func SlowStartMaxPodCreation(startValue, maxParallelPodCreation, nbSlot int) int {
+	result := (1 + nbSlot) * startValue
+	if result > maxParallelPodCreation {
+		result = maxParallelPodCreation
+	}
+	return result
+}
+
+// EstimateSlowStartSlots returns the number of slow start intervals needed to update nbPods pods, starting from the
+// interval currentSlot. Each interval updates at most SlowStartMaxPodCreation pods, and at most podsPerSlot pods
+// when podsPerSlot (the observed update rate) is positive. Returns -1 if the pods can't be updated.
+func EstimateSlowStartSlots(nbPods, startValue, maxParallelPodCreation, currentSlot int, podsPerSlot float64) float64 {
+	remaining := float64(nbPods)
+	var nbSlots float64
+	for slot := currentSlot; remaining > 0; slot++ {
+		maxCreation := SlowStartMaxPodCreation(startValue, maxParallelPodCreation, slot)
+		perSlot := float64(maxCreation)
+		constantRate := maxCreation == maxParallelPodCreation
+		if podsPerSlot > 0 && podsPerSlot <= perSlot {
+			perSlot = podsPerSlot
+			constantRate = true
+		}
+		if perSlot <= 0 {
+			return -1
+		}
+		if remaining <= perSlot || constantRate {
+			return nbSlots + remaining/perSlot
+		}
+		remaining -= perSlot
+		nbSlots++
+	}
+	return nbSlots
+}
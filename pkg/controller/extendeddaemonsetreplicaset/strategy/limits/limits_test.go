@@ -208,3 +208,69 @@ func TestCalculatePodToCreateAndDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestEstimateSlowStartSlots(t *testing.T) {
+	tests := []struct {
+		name                   string
+		nbPods                 int
+		startValue             int
+		maxParallelPodCreation int
+		currentSlot            int
+		podsPerSlot            float64
+		want                   float64
+	}{
+		{
+			name:                   "no pods",
+			nbPods:                 0,
+			startValue:             5,
+			maxParallelPodCreation: 250,
+			want:                   0,
+		},
+		{
+			name:                   "slow start: 5+10+15",
+			nbPods:                 30,
+			startValue:             5,
+			maxParallelPodCreation: 250,
+			want:                   3,
+		},
+		{
+			name:                   "slow start from the second slot: 10+15, half of 10",
+			nbPods:                 30,
+			startValue:             5,
+			maxParallelPodCreation: 250,
+			currentSlot:            1,
+			want:                   2.25,
+		},
+		{
+			name:                   "max parallel pod creation reached",
+			nbPods:                 1000,
+			startValue:             5,
+			maxParallelPodCreation: 250,
+			currentSlot:            100,
+			want:                   4,
+		},
+		{
+			name:                   "observed rate slower than the slow start",
+			nbPods:                 100,
+			startValue:             5,
+			maxParallelPodCreation: 250,
+			currentSlot:            10,
+			podsPerSlot:            20,
+			want:                   5,
+		},
+		{
+			name:                   "no pod creation",
+			nbPods:                 100,
+			startValue:             0,
+			maxParallelPodCreation: 250,
+			want:                   -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateSlowStartSlots(tt.nbPods, tt.startValue, tt.maxParallelPodCreation, tt.currentSlot, tt.podsPerSlot); got != tt.want {
+				t.Errorf("EstimateSlowStartSlots() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
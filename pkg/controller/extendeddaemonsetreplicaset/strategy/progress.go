@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+)
+
+const (
+	// progressingReasonReplicaSetUpdated an additional pod became available
+	progressingReasonReplicaSetUpdated = "ReplicaSetUpdated"
+	// progressingReasonReplicaSetAvailable all the pods are available
+	progressingReasonReplicaSetAvailable = "ReplicaSetAvailable"
+)
+
+// updateProgressingCondition updates the Progressing condition of the ExtendedDaemonSetReplicaSet status.
+// The condition is refreshed each time an additional pod becomes available, and is set to False with
+// the ProgressDeadlineExceeded reason if no pod became available within the progress deadline.
+// Returns true if the progress deadline is exceeded.
+func updateProgressingCondition(strategy *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategy, oldStatus, status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, now metav1.Time) bool {
+	if strategy.ProgressDeadlineSeconds == nil {
+		return false
+	}
+	deadline := time.Duration(*strategy.ProgressDeadlineSeconds) * time.Second
+	cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypeProgressing)
+
+	switch {
+	case status.Available >= status.Desired:
+		setProgressingCondition(status, now, corev1.ConditionTrue, progressingReasonReplicaSetAvailable, "all the pods are available", cond == nil || cond.Reason != progressingReasonReplicaSetAvailable)
+		return false
+	case cond == nil || cond.Reason == progressingReasonReplicaSetAvailable || status.Available > oldStatus.Available:
+		// the deadline restarts when the rollout starts, or when an additional pod becomes available
+		setProgressingCondition(status, now, corev1.ConditionTrue, progressingReasonReplicaSetUpdated, fmt.Sprintf("%d/%d pods available", status.Available, status.Desired), true)
+		return false
+	case cond.Status == corev1.ConditionFalse:
+		return true
+	case now.Sub(cond.LastUpdateTime.Time) > deadline:
+		message := fmt.Sprintf("no additional pod became available in the last %v, %d/%d pods available", deadline, status.Available, status.Desired)
+		setProgressingCondition(status, now, corev1.ConditionFalse, string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded), message, false)
+		return true
+	}
+	return false
+}
+
+// setProgressingCondition sets the Progressing condition with its reason
+func setProgressingCondition(status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, now metav1.Time, conditionStatus corev1.ConditionStatus, reason, message string, supportLastUpdate bool) {
+	conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(status, now, datadoghqv1alpha1.ConditionTypeProgressing, conditionStatus, message, false, supportLastUpdate)
+	conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypeProgressing).Reason = reason
+}
+
+// isProgressDeadlineExceeded returns true if the Progressing condition is set to False by the progress deadline
+func isProgressDeadlineExceeded(status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus) bool {
+	cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypeProgressing)
+	return cond != nil && cond.Status == corev1.ConditionFalse && cond.Reason == string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded)
+}
+
+// updateRollingUpdatePausedCondition sets the RollingUpdatePaused condition while the rolling update is paused.
+// When the rolling update is resumed, the condition is set to False and the progress deadline restarts.
+// Returns true if the rolling update was just resumed.
+func updateRollingUpdatePausedCondition(status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, now metav1.Time, isPaused bool) bool {
+	cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypeRollingUpdatePaused)
+	if isPaused {
+		reason := datadoghqv1alpha1.ExtendedDaemonSetStatusReasonUnknown
+		if isProgressDeadlineExceeded(status) {
+			reason = datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded
+		}
+		if cond == nil || cond.Status != corev1.ConditionTrue {
+			conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(status, now, datadoghqv1alpha1.ConditionTypeRollingUpdatePaused, corev1.ConditionTrue, "rolling update paused", false, false)
+			conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypeRollingUpdatePaused).Reason = string(reason)
+		}
+		return false
+	}
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		return false
+	}
+
+	conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(status, now, datadoghqv1alpha1.ConditionTypeRollingUpdatePaused, corev1.ConditionFalse, "rolling update resumed", false, false)
+	conditions.GetExtendedDaemonSetReplicaSetStatusCondition(status, datadoghqv1alpha1.ConditionTypeRollingUpdatePaused).Reason = "Resumed"
+	if isProgressDeadlineExceeded(status) {
+		setProgressingCondition(status, now, corev1.ConditionTrue, progressingReasonReplicaSetUpdated, fmt.Sprintf("rolling update resumed, %d/%d pods available", status.Available, status.Desired), true)
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+)
+
+func Test_updateProgressingCondition(t *testing.T) {
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	before := metav1.NewTime(now.Add(-15 * time.Minute))
+	progressDeadlineSeconds := int32(600)
+	strategy := &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategy{ProgressDeadlineSeconds: &progressDeadlineSeconds}
+
+	newStatus := func(desired, available int32, condStatus corev1.ConditionStatus, reason string) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus {
+		status := &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{Desired: desired, Available: available}
+		if reason != "" {
+			status.Conditions = []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{
+				{
+					Type:               datadoghqv1alpha1.ConditionTypeProgressing,
+					Status:             condStatus,
+					LastTransitionTime: before,
+					LastUpdateTime:     before,
+					Reason:             reason,
+				},
+			}
+		}
+		return status
+	}
+	const exceeded = string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded)
+
+	tests := []struct {
+		name           string
+		strategy       *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategy
+		oldStatus      *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus
+		status         *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus
+		want           bool
+		wantStatus     corev1.ConditionStatus
+		wantReason     string
+		wantUpdateTime metav1.Time
+	}{
+		{
+			name:      "progress deadline disabled",
+			strategy:  &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategy{},
+			oldStatus: newStatus(10, 2, "", ""),
+			status:    newStatus(10, 2, "", ""),
+		},
+		{
+			name:           "rollout started",
+			strategy:       strategy,
+			oldStatus:      newStatus(10, 0, "", ""),
+			status:         newStatus(10, 0, "", ""),
+			wantStatus:     corev1.ConditionTrue,
+			wantReason:     progressingReasonReplicaSetUpdated,
+			wantUpdateTime: now,
+		},
+		{
+			name:           "rollout restarted after all the pods were available",
+			strategy:       strategy,
+			oldStatus:      newStatus(10, 10, corev1.ConditionTrue, progressingReasonReplicaSetAvailable),
+			status:         newStatus(12, 10, corev1.ConditionTrue, progressingReasonReplicaSetAvailable),
+			wantStatus:     corev1.ConditionTrue,
+			wantReason:     progressingReasonReplicaSetUpdated,
+			wantUpdateTime: now,
+		},
+		{
+			name:           "additional pod available",
+			strategy:       strategy,
+			oldStatus:      newStatus(10, 2, corev1.ConditionTrue, progressingReasonReplicaSetUpdated),
+			status:         newStatus(10, 3, corev1.ConditionTrue, progressingReasonReplicaSetUpdated),
+			wantStatus:     corev1.ConditionTrue,
+			wantReason:     progressingReasonReplicaSetUpdated,
+			wantUpdateTime: now,
+		},
+		{
+			name:           "all the pods available",
+			strategy:       strategy,
+			oldStatus:      newStatus(10, 9, corev1.ConditionFalse, exceeded),
+			status:         newStatus(10, 10, corev1.ConditionFalse, exceeded),
+			wantStatus:     corev1.ConditionTrue,
+			wantReason:     progressingReasonReplicaSetAvailable,
+			wantUpdateTime: now,
+		},
+		{
+			name:           "progress deadline exceeded",
+			strategy:       strategy,
+			oldStatus:      newStatus(10, 2, corev1.ConditionTrue, progressingReasonReplicaSetUpdated),
+			status:         newStatus(10, 2, corev1.ConditionTrue, progressingReasonReplicaSetUpdated),
+			want:           true,
+			wantStatus:     corev1.ConditionFalse,
+			wantReason:     exceeded,
+			wantUpdateTime: now,
+		},
+		{
+			name:           "progress deadline already exceeded",
+			strategy:       strategy,
+			oldStatus:      newStatus(10, 2, corev1.ConditionFalse, exceeded),
+			status:         newStatus(10, 2, corev1.ConditionFalse, exceeded),
+			want:           true,
+			wantStatus:     corev1.ConditionFalse,
+			wantReason:     exceeded,
+			wantUpdateTime: before,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := updateProgressingCondition(tt.strategy, tt.oldStatus, tt.status, now)
+			if got != tt.want {
+				t.Errorf("updateProgressingCondition() = %v, want %v", got, tt.want)
+			}
+			cond := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(tt.status, datadoghqv1alpha1.ConditionTypeProgressing)
+			if tt.wantReason == "" {
+				if cond != nil {
+					t.Errorf("updateProgressingCondition() unexpected condition %#v", cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatalf("updateProgressingCondition() condition not set")
+			}
+			if cond.Status != tt.wantStatus || cond.Reason != tt.wantReason || !cond.LastUpdateTime.Equal(&tt.wantUpdateTime) {
+				t.Errorf("updateProgressingCondition() condition = %#v, want status %s, reason %s, lastUpdateTime %v", cond, tt.wantStatus, tt.wantReason, tt.wantUpdateTime)
+			}
+		})
+	}
+}
+
+func Test_updateRollingUpdatePausedCondition(t *testing.T) {
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	before := metav1.NewTime(now.Add(-15 * time.Minute))
+	const exceeded = string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded)
+
+	newStatus := func(pausedStatus corev1.ConditionStatus) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus {
+		status := &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{
+			Desired:   10,
+			Available: 2,
+			Conditions: []datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{
+				{
+					Type:               datadoghqv1alpha1.ConditionTypeProgressing,
+					Status:             corev1.ConditionFalse,
+					LastTransitionTime: before,
+					LastUpdateTime:     before,
+					Reason:             exceeded,
+				},
+			},
+		}
+		if pausedStatus != "" {
+			status.Conditions = append(status.Conditions, datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCondition{
+				Type:               datadoghqv1alpha1.ConditionTypeRollingUpdatePaused,
+				Status:             pausedStatus,
+				LastTransitionTime: before,
+				LastUpdateTime:     before,
+				Reason:             exceeded,
+			})
+		}
+		return status
+	}
+
+	tests := []struct {
+		name              string
+		status            *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus
+		isPaused          bool
+		want              bool
+		wantPausedStatus  corev1.ConditionStatus
+		wantPausedReason  string
+		wantProgressing   corev1.ConditionStatus
+		wantProgressingAt metav1.Time
+	}{
+		{
+			name:              "not paused",
+			status:            newStatus(""),
+			wantProgressing:   corev1.ConditionFalse,
+			wantProgressingAt: before,
+		},
+		{
+			name:              "paused by the progress deadline",
+			status:            newStatus(""),
+			isPaused:          true,
+			wantPausedStatus:  corev1.ConditionTrue,
+			wantPausedReason:  exceeded,
+			wantProgressing:   corev1.ConditionFalse,
+			wantProgressingAt: before,
+		},
+		{
+			name:              "resumed, the progress deadline restarts",
+			status:            newStatus(corev1.ConditionTrue),
+			want:              true,
+			wantPausedStatus:  corev1.ConditionFalse,
+			wantPausedReason:  "Resumed",
+			wantProgressing:   corev1.ConditionTrue,
+			wantProgressingAt: now,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateRollingUpdatePausedCondition(tt.status, now, tt.isPaused); got != tt.want {
+				t.Errorf("updateRollingUpdatePausedCondition() = %v, want %v", got, tt.want)
+			}
+			paused := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(tt.status, datadoghqv1alpha1.ConditionTypeRollingUpdatePaused)
+			switch {
+			case tt.wantPausedStatus == "" && paused != nil:
+				t.Errorf("updateRollingUpdatePausedCondition() unexpected condition %#v", paused)
+			case tt.wantPausedStatus != "" && (paused == nil || paused.Status != tt.wantPausedStatus || paused.Reason != tt.wantPausedReason):
+				t.Errorf("updateRollingUpdatePausedCondition() condition = %#v, want status %s, reason %s", paused, tt.wantPausedStatus, tt.wantPausedReason)
+			}
+			progressing := conditions.GetExtendedDaemonSetReplicaSetStatusCondition(tt.status, datadoghqv1alpha1.ConditionTypeProgressing)
+			if progressing.Status != tt.wantProgressing || !progressing.LastUpdateTime.Equal(&tt.wantProgressingAt) {
+				t.Errorf("updateRollingUpdatePausedCondition() Progressing condition = %#v, want status %s, lastUpdateTime %v", progressing, tt.wantProgressing, tt.wantProgressingAt)
+			}
+		})
+	}
+}
@@ -13,8 +13,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	eds "github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonset"
 	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
 	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy/limits"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils"
@@ -22,7 +24,7 @@ import (
 )
 
 // ManageDeployment used to manage ReplicaSet in rollingupdate state
-func ManageDeployment(client client.Client, params *Parameters) (*Result, error) {
+func ManageDeployment(client client.Client, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, params *Parameters) (*Result, error) {
 	result := &Result{}
 
 	// remove canary node if define
@@ -31,10 +33,12 @@ func ManageDeployment(client client.Client, params *Parameters) (*Result, error)
 	}
 	now := time.Now()
 	metaNow := metav1.NewTime(now)
-	var desiredPods, availablePods, readyPods, currentPods, oldAvailablePods, podsTerminating, nbIgnoredUnresponsiveNodes int32
+	var desiredPods, availablePods, readyPods, currentPods, oldAvailablePods, oldUnhealthyPods, podsTerminating, nbIgnoredUnresponsiveNodes int32
 
 	allPodToCreate := []*NodeItem{}
 	allPodToDelete := []*NodeItem{}
+	var settingsPodToDelete []*NodeItem
+	var newPods []*corev1.Pod
 
 	nbNodes := len(params.PodByNodeName)
 
@@ -49,22 +53,29 @@ func ManageDeployment(client client.Client, params *Parameters) (*Result, error)
 		if pod == nil {
 			allPodToCreate = append(allPodToCreate, node)
 		} else {
-			if podutils.HasPodSchedulerIssue(pod) && int(nbIgnoredUnresponsiveNodes) < maxPodSchedulerFailure {
+			if podutils.HasPodSchedulerIssue(pod, params.SchedulerIssueTimeout) && int(nbIgnoredUnresponsiveNodes) < maxPodSchedulerFailure {
 				nbIgnoredUnresponsiveNodes++
 				continue
 			}
-			if !compareCurrentPodWithNewPod(params, pod, node) {
-				if pod.DeletionTimestamp == nil {
-					allPodToDelete = append(allPodToDelete, node)
-				} else {
+			isUpToDate, err := compareCurrentPodWithNewPod(params, pod, node)
+			if err != nil {
+				// the pod can't be created on this Node either, so the current pod is kept
+				params.Logger.Error(err, "Unable to compare the pod with the expected one", "pod_name", pod.Name, "node", node.Node.Name)
+				continue
+			}
+			if !isUpToDate {
+				if pod.DeletionTimestamp != nil {
 					podsTerminating++
 					continue
 				}
-				if podutils.IsPodAvailable(pod, 0, metaNow) {
-					oldAvailablePods++
+				if isSettingsCanaryEnabled(params.Strategy) && isSettingsChangeOnly(params, pod, node) {
+					settingsPodToDelete = append(settingsPodToDelete, node)
+				} else {
+					allPodToDelete = append(allPodToDelete, node)
 				}
 			} else {
 				currentPods++
+				newPods = append(newPods, pod)
 				if podutils.IsPodAvailable(pod, 0, metaNow) {
 					availablePods++
 				}
@@ -75,13 +86,35 @@ func ManageDeployment(client client.Client, params *Parameters) (*Result, error)
 		}
 	}
 
+	// The pods outdated only by a settings change are replaced on the settings canary Nodes first
+	var settingsCanary *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary
+	var settingsPodHeld []*NodeItem
+	var settingsCanaryRequeue time.Duration
+	if isSettingsCanaryEnabled(params.Strategy) {
+		var settingsPodReleased []*NodeItem
+		settingsCanary, settingsPodReleased, settingsPodHeld, settingsCanaryRequeue = manageSettingsCanary(client, daemonset, params, settingsPodToDelete, now)
+		allPodToDelete = append(allPodToDelete, settingsPodReleased...)
+	}
+	for _, node := range allPodToDelete {
+		if isPodUnhealthy(params.PodByNodeName[node]) {
+			oldUnhealthyPods++
+		} else {
+			oldAvailablePods++
+		}
+	}
+	for _, node := range settingsPodHeld {
+		if !isPodUnhealthy(params.PodByNodeName[node]) {
+			oldAvailablePods++
+		}
+	}
+
 	// Retrieves parameters for calculation
 	maxUnavailable, err := intstrutil.GetValueFromIntOrPercent(params.Strategy.RollingUpdate.MaxUnavailable, nbNodes, true)
 	if err != nil {
 		params.Logger.Error(err, "unable to retrieve maxUnavailable pod from the strategy.RollingUpdate.MaxUnavailable parameter")
 		return result, err
 	}
-	params.Logger.V(1).Info("Parameters", "nbNodes", nbNodes, "createdPod", currentPods, "nbPodReady", readyPods, "availablePods", availablePods, "oldAvailablePods", oldAvailablePods, "maxUnavailable", maxUnavailable, "nbPodToCreate", len(allPodToCreate), "nbPodToDelete", len(allPodToDelete), "podsTerminating", podsTerminating)
+	params.Logger.V(1).Info("Parameters", "nbNodes", nbNodes, "createdPod", currentPods, "nbPodReady", readyPods, "availablePods", availablePods, "oldAvailablePods", oldAvailablePods, "oldUnhealthyPods", oldUnhealthyPods, "maxUnavailable", maxUnavailable, "nbPodToCreate", len(allPodToCreate), "nbPodToDelete", len(allPodToDelete), "podsTerminating", podsTerminating)
 
 	rollingUpdateStartTime := getRollingUpdateStartTime(&params.Replicaset.Status, now)
 	maxCreation, err := calculateMaxCreation(&params.Strategy.RollingUpdate, nbNodes, rollingUpdateStartTime, now)
@@ -101,29 +134,75 @@ func ManageDeployment(client client.Client, params *Parameters) (*Result, error)
 		MaxPodCreation:     maxCreation,
 	}
 	nbPodToCreate, nbPodToDelete := limits.CalculatePodToCreateAndDelete(limitParams)
-	nbPodToDeleteWithConstraint := utils.MinInt(nbPodToDelete, len(allPodToDelete))
+	// The unhealthy pods of the previous version are replaced first, without consuming maxUnavailable
+	sortNodesByDeletionPriority(&params.Strategy.RollingUpdate, allPodToDelete, params.PodByNodeName)
+	nbPodToDeleteWithConstraint := utils.MinInt(int(oldUnhealthyPods)+nbPodToDelete, len(allPodToDelete))
 	nbPodToCreateWithConstraint := utils.MinInt(nbPodToCreate, len(allPodToCreate))
+
+	result.NewStatus = params.NewStatus.DeepCopy()
+	result.NewStatus.Status = string(ReplicaSetStatusActive)
+	result.NewStatus.Desired = desiredPods
+	result.NewStatus.Ready = readyPods
+	result.NewStatus.Current = currentPods
+	result.NewStatus.Available = availablePods
+	result.NewStatus.IgnoredUnresponsiveNodes = nbIgnoredUnresponsiveNodes
+	result.NewStatus.SettingsCanary = settingsCanary
+	// The RollingUpdatePaused condition follows the pause annotation once it is observed. It is updated before the
+	// Progressing condition, so that the progress deadline restarts when the rolling update is resumed.
+	isPaused := eds.IsRollingUpdatePauseRequested(daemonset.GetAnnotations(), params.Replicaset.Name)
+	if updateRollingUpdatePausedCondition(result.NewStatus, metaNow, isPaused) {
+		params.Logger.Info("Rolling update resumed")
+	}
+	progressDeadlineExceeded := updateProgressingCondition(params.Strategy, &params.Replicaset.Status, result.NewStatus, metaNow)
+
+	// The failure policy and the progress deadline action are only checked while pods of the previous version remain,
+	// once failed the rolling update is halted.
+	isFailed, _ := eds.IsRollingUpdateFailed(params.Replicaset)
+	var failureReason datadoghqv1alpha1.ExtendedDaemonSetStatusReason
+	var failureMessage string
+	if !isFailed && len(allPodToDelete)+int(podsTerminating) > 0 {
+		isFailed, failureReason, failureMessage, err = checkRollingUpdateFailure(params.Strategy.RollingUpdate.FailurePolicy, newPods, nbNodes, now)
+		if err != nil {
+			params.Logger.Error(err, "unable to retrieve maxFailedPods from the strategy.RollingUpdate.FailurePolicy.MaxFailedPods parameter")
+			return result, err
+		}
+		// a paused rolling update doesn't progress, the progress deadline action isn't executed
+		if !isFailed && !isPaused && progressDeadlineExceeded {
+			switch params.Strategy.ProgressDeadlineAction {
+			case datadoghqv1alpha1.ExtendedDaemonSetProgressDeadlineActionRollback:
+				isFailed, failureReason = true, datadoghqv1alpha1.ExtendedDaemonSetStatusReasonProgressDeadlineExceeded
+				failureMessage = conditions.GetExtendedDaemonSetReplicaSetStatusCondition(result.NewStatus, datadoghqv1alpha1.ConditionTypeProgressing).Message
+			case datadoghqv1alpha1.ExtendedDaemonSetProgressDeadlineActionPause:
+				if err = pauseRollingUpdate(client, daemonset, params.Replicaset.Name); err != nil {
+					params.Logger.Error(err, "Failed to pause the rolling update")
+				} else {
+					params.Logger.Info("Rolling update progress deadline exceeded, pausing the deployment")
+				}
+				isPaused = true
+			}
+		}
+	}
+	if failureReason != "" {
+		params.Logger.Info("Rolling update failed, halting the deployment", "reason", failureReason, "message", failureMessage)
+		setRollingUpdateFailedCondition(result.NewStatus, metaNow, failureReason, failureMessage)
+	}
+	if isFailed || isPaused {
+		nbPodToDeleteWithConstraint = 0
+		nbPodToCreateWithConstraint = 0
+	}
 	params.Logger.V(1).Info("Pods actions with limits", "nbPodToDelete", nbPodToDelete, "nbPodToCreate", nbPodToCreate, "nbPodToDeleteWithConstraint", nbPodToDeleteWithConstraint, "nbPodToCreateWithConstraint", nbPodToCreateWithConstraint)
 
 	result.PodsToDelete = allPodToDelete[:nbPodToDeleteWithConstraint]
 	result.PodsToCreate = allPodToCreate[:nbPodToCreateWithConstraint]
-	{
-		result.NewStatus = params.NewStatus.DeepCopy()
-		result.NewStatus.Status = string(ReplicaSetStatusActive)
-		result.NewStatus.Desired = desiredPods
-		result.NewStatus.Ready = readyPods
-		result.NewStatus.Current = currentPods
-		result.NewStatus.Available = availablePods
-		result.NewStatus.IgnoredUnresponsiveNodes = nbIgnoredUnresponsiveNodes
-	}
 
 	// Populate list of unscheduled pods on nodes due to resource limitation
 	result.UnscheduledNodesDueToResourcesConstraints = manageUnscheduledPodNodes(params.UnscheduledPods)
 	// Cleanup Pods
-	result.NewStatus, result.Result, err = cleanupPods(client, params.Logger, result.NewStatus, params.PodToCleanUp)
+	result.NewStatus, result.Result, err = cleanupPods(client, params, result.NewStatus, params.PodToCleanUp)
 	if result.NewStatus.Desired != result.NewStatus.Ready {
 		result.Result.Requeue = true
 	}
+	result.Result = utils.MergeResult(result.Result, reconcile.Result{RequeueAfter: settingsCanaryRequeue})
 
 	return result, err
 }
@@ -149,10 +228,6 @@ func calculateMaxCreation(params *datadoghqv1alpha1.ExtendedDaemonSetSpecStrateg
 	}
 	rollingUpdateDuration := now.Sub(rsStartTime)
 	nbSlowStartSlot := int(rollingUpdateDuration / params.SlowStartIntervalDuration.Duration)
-	result := (1 + nbSlowStartSlot) * startValue
-	if result > int(*params.MaxParallelPodCreation) {
-		result = int(*params.MaxParallelPodCreation)
-	}
 
-	return result, nil
+	return limits.SlowStartMaxPodCreation(startValue, int(*params.MaxParallelPodCreation), nbSlowStartSlot), nil
 }
@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package strategy
+
+import (
+	"crypto/md5" // #nosec
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	eds "github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonset"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/comparison"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+)
+
+// isSettingsCanaryEnabled returns true if the ExtendedDaemonsetSettings and Node resources overwrite changes
+// are deployed with a canary phase
+func isSettingsCanaryEnabled(strategy *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategy) bool {
+	return strategy.Canary != nil && strategy.Canary.SettingsChanges
+}
+
+// isSettingsChangeOnly returns true if the pod only differs from the expected one by the ExtendedDaemonsetSetting
+// or the Node resources overwrite. The resources profiles are selected by the controller and are not part of the
+// settings changes.
+func isSettingsChangeOnly(params *Parameters, pod *corev1.Pod, node *NodeItem) bool {
+	return compareSpecTemplateMD5Hash(params.Replicaset.Spec.TemplateGeneration, pod) && compareResourcesProfile(pod, node)
+}
+
+// computeSettingsHash returns the hash of the ExtendedDaemonsetSettings and of the Node resources overwrites applied
+// on the Nodes of the replicaset. A new Node using the same settings doesn't change it.
+func computeSettingsHash(params *Parameters) string {
+	settings := map[string]bool{}
+	for node := range params.PodByNodeName {
+		if node.ResourcesProfile == nil && node.ExtendedDaemonsetSetting != nil {
+			b, _ := json.Marshal(node.ExtendedDaemonsetSetting.Spec.Containers)
+			settings[fmt.Sprintf("setting:%s:%s", node.ExtendedDaemonsetSetting.Name, b)] = true
+		}
+		if nodeHash := comparison.GenerateHashFromEDSResourceNodeAnnotation(params.Replicaset.Namespace, params.EDSName, node.Node.GetAnnotations()); nodeHash != "" {
+			settings[fmt.Sprintf("node:%s", nodeHash)] = true
+		}
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	/* #nosec */
+	hash := md5.New()
+	for _, key := range keys {
+		_, _ = hash.Write([]byte(key))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// manageSettingsCanary deploys a settings change on the canary Nodes first. It returns the settings canary status,
+// the Nodes where the pod can be replaced, the Nodes held until the end of the settings canary,
+// and the remaining duration of the settings canary.
+func manageSettingsCanary(c client.Client, daemonset *datadoghqv1alpha1.ExtendedDaemonSet, params *Parameters, nodes []*NodeItem, now time.Time) (*datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary, []*NodeItem, []*NodeItem, time.Duration) {
+	hash := computeSettingsHash(params)
+	settingsCanary := params.NewStatus.SettingsCanary.DeepCopy()
+	if settingsCanary == nil || settingsCanary.Hash != hash {
+		if len(nodes) == 0 {
+			return nil, nodes, nil, 0
+		}
+		settingsCanary = newSettingsCanary(params.Strategy.Canary, hash, nodes, now)
+		params.Logger.Info("Start the settings canary deployment", "hash", hash, "nodes", settingsCanary.Nodes)
+	}
+	if settingsCanary.State == datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated {
+		return settingsCanary, nodes, nil, 0
+	}
+
+	annotations := daemonset.GetAnnotations()
+	isPaused, _ := eds.IsCanaryDeploymentPaused(annotations)
+	if !isPaused {
+		// Check if the settings canary should be paused due to restarts of the pods already updated
+		for _, nodeName := range settingsCanary.Nodes {
+			node := params.NodeByName[nodeName]
+			pod := params.PodByNodeName[node]
+			if pod == nil {
+				continue
+			}
+			if isUpToDate, err := compareCurrentPodWithNewPod(params, pod, node); err != nil || !isUpToDate {
+				continue
+			}
+			if isRestarting, reason := podutils.IsPodRestarting(pod); isRestarting {
+				if err := pauseCanaryDeployment(c, daemonset, reason); err != nil {
+					params.Logger.Error(err, "Failed to pause settings canary deployment")
+				} else {
+					params.Logger.V(1).Info("Settings canary deployment paused")
+				}
+				isPaused = true
+				break
+			}
+		}
+	}
+
+	var requeueAfter time.Duration
+	switch {
+	case eds.IsCanaryDeploymentFailed(annotations):
+		settingsCanary.State = datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateFailed
+	case eds.IsCanaryDeploymentValid(annotations, settingsCanary.Hash):
+		settingsCanary.State = datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated
+	case isPaused:
+		settingsCanary.State = datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStatePaused
+	default:
+		var ended bool
+		ended, requeueAfter = isSettingsCanaryEnded(params.Strategy.Canary, settingsCanary, now)
+		settingsCanary.State = datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateRunning
+		if ended {
+			settingsCanary.State = datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated
+			requeueAfter = 0
+		}
+	}
+
+	switch settingsCanary.State {
+	case datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated:
+		params.Logger.Info("Settings canary deployment validated", "hash", settingsCanary.Hash)
+		return settingsCanary, nodes, nil, 0
+	case datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateFailed:
+		// the settings change is not deployed on other Nodes until the settings are changed again
+		return settingsCanary, nil, nodes, 0
+	}
+
+	var released, held []*NodeItem
+	for _, node := range nodes {
+		if comparison.StringsContains(settingsCanary.Nodes, node.Node.Name) {
+			released = append(released, node)
+		} else {
+			held = append(held, node)
+		}
+	}
+	return settingsCanary, released, held, requeueAfter
+}
+
+// newSettingsCanary selects the canary Nodes among the Nodes where the settings changed
+func newSettingsCanary(specCanary *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyCanary, hash string, nodes []*NodeItem, now time.Time) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary {
+	nodeNames := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		nodeNames = append(nodeNames, node.Node.Name)
+	}
+	sort.Strings(nodeNames)
+
+	nbCanaryNodes := 1
+	if specCanary.Replicas != nil {
+		if value, err := intstrutil.GetValueFromIntOrPercent(specCanary.Replicas, len(nodeNames), true); err == nil && value > 1 {
+			nbCanaryNodes = value
+		}
+	}
+	if nbCanaryNodes > len(nodeNames) {
+		nbCanaryNodes = len(nodeNames)
+	}
+
+	return &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary{
+		Hash:      hash,
+		State:     datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateRunning,
+		Nodes:     nodeNames[:nbCanaryNodes],
+		StartTime: metav1.NewTime(now),
+	}
+}
+
+// isSettingsCanaryEnded used to know if the settings canary duration has finished.
+// If the duration is not completed, it also returns the remaining duration.
+func isSettingsCanaryEnded(specCanary *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyCanary, settingsCanary *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary, now time.Time) (bool, time.Duration) {
+	if specCanary.Duration == nil {
+		// in this case, it means the canary never ends
+		return false, 0
+	}
+	pendingDuration := settingsCanary.StartTime.Add(specCanary.Duration.Duration).Sub(now)
+	if pendingDuration >= 0 {
+		return false, pendingDuration
+	}
+	return true, 0
+}
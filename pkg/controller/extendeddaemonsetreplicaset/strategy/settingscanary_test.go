@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package strategy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func Test_manageSettingsCanary(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	now := time.Now()
+
+	setting := &datadoghqv1alpha1.ExtendedDaemonsetSetting{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "setting"},
+	}
+	node1 := NewNodeItem(commontest.NewNode("node1", nil), setting)
+	node2 := NewNodeItem(commontest.NewNode("node2", nil), setting)
+	node3 := NewNodeItem(commontest.NewNode("node3", nil), setting)
+	nodes := []*NodeItem{node3, node1, node2}
+
+	duration := metav1.Duration{Duration: 10 * time.Minute}
+	replicas := intstr.FromInt(1)
+	newParams := func(settingsCanary *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary) *Parameters {
+		return &Parameters{
+			EDSName: "foo",
+			Strategy: &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategy{
+				Canary: &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyCanary{Replicas: &replicas, Duration: &duration, SettingsChanges: true},
+			},
+			Replicaset: test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", nil),
+			NewStatus:  &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus{SettingsCanary: settingsCanary},
+			NodeByName: map[string]*NodeItem{"node1": node1, "node2": node2, "node3": node3},
+			PodByNodeName: map[*NodeItem]*corev1.Pod{
+				node1: nil,
+				node2: nil,
+				node3: nil,
+			},
+			Logger: logf.Log.WithName("Test_manageSettingsCanary"),
+		}
+	}
+	hash := computeSettingsHash(newParams(nil))
+	running := func(startTime time.Time) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary {
+		return &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary{
+			Hash:      hash,
+			State:     datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateRunning,
+			Nodes:     []string{"node1"},
+			StartTime: metav1.NewTime(startTime),
+		}
+	}
+
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		status       *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary
+		nodes        []*NodeItem
+		wantState    datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryState
+		wantReleased []*NodeItem
+		wantHeld     []*NodeItem
+		wantRequeue  bool
+	}{
+		{
+			name:  "no settings change",
+			nodes: []*NodeItem{},
+		},
+		{
+			name:         "settings change, start the settings canary",
+			nodes:        nodes,
+			wantState:    datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateRunning,
+			wantReleased: []*NodeItem{node1},
+			wantHeld:     []*NodeItem{node3, node2},
+			wantRequeue:  true,
+		},
+		{
+			name: "settings changed again, restart the settings canary",
+			status: &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanary{
+				Hash:      "previous",
+				State:     datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated,
+				Nodes:     []string{"node3"},
+				StartTime: metav1.NewTime(now.Add(-time.Hour)),
+			},
+			nodes:        nodes,
+			wantState:    datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateRunning,
+			wantReleased: []*NodeItem{node1},
+			wantHeld:     []*NodeItem{node3, node2},
+			wantRequeue:  true,
+		},
+		{
+			name:         "settings canary duration elapsed",
+			status:       running(now.Add(-time.Hour)),
+			nodes:        []*NodeItem{node3, node2},
+			wantState:    datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated,
+			wantReleased: []*NodeItem{node3, node2},
+		},
+		{
+			name:         "settings canary validated",
+			annotations:  map[string]string{datadoghqv1alpha1.ExtendedDaemonSetCanaryValidAnnotationKey: hash},
+			status:       running(now),
+			nodes:        []*NodeItem{node3, node2},
+			wantState:    datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated,
+			wantReleased: []*NodeItem{node3, node2},
+		},
+		{
+			name:        "settings canary paused",
+			annotations: map[string]string{datadoghqv1alpha1.ExtendedDaemonSetCanaryPausedAnnotationKey: "true"},
+			status:      running(now.Add(-time.Hour)),
+			nodes:       []*NodeItem{node3, node2},
+			wantState:   datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStatePaused,
+			wantHeld:    []*NodeItem{node3, node2},
+		},
+		{
+			name:        "settings canary failed",
+			annotations: map[string]string{datadoghqv1alpha1.ExtendedDaemonSetCanaryFailedAnnotationKey: "true"},
+			status:      running(now),
+			nodes:       nodes,
+			wantState:   datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateFailed,
+			wantHeld:    nodes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemonset := test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{Annotations: tt.annotations})
+			c := fake.NewFakeClient()
+			settingsCanary, released, held, requeueAfter := manageSettingsCanary(c, daemonset, newParams(tt.status), tt.nodes, now)
+
+			var state datadoghqv1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryState
+			if settingsCanary != nil {
+				state = settingsCanary.State
+				if settingsCanary.Hash != hash {
+					t.Errorf("manageSettingsCanary() hash = %s, want %s", settingsCanary.Hash, hash)
+				}
+			}
+			if state != tt.wantState {
+				t.Errorf("manageSettingsCanary() state = %s, want %s", state, tt.wantState)
+			}
+			if len(released)+len(tt.wantReleased) > 0 && !reflect.DeepEqual(released, tt.wantReleased) {
+				t.Errorf("manageSettingsCanary() released = %v, want %v", released, tt.wantReleased)
+			}
+			if len(held)+len(tt.wantHeld) > 0 && !reflect.DeepEqual(held, tt.wantHeld) {
+				t.Errorf("manageSettingsCanary() held = %v, want %v", held, tt.wantHeld)
+			}
+			if (requeueAfter > 0) != tt.wantRequeue {
+				t.Errorf("manageSettingsCanary() requeueAfter = %v, want requeue %v", requeueAfter, tt.wantRequeue)
+			}
+		})
+	}
+}
@@ -6,10 +6,13 @@
 package strategy
 
 import (
+	"time"
+
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 )
@@ -45,6 +48,12 @@ type Parameters struct {
 	PodToCleanUp    []*corev1.Pod
 	UnscheduledPods []*corev1.Pod
 
+	// SchedulerIssueTimeout duration after which a pod not scheduled, or not terminated, has a scheduler issue
+	SchedulerIssueTimeout time.Duration
+
+	// KubeClient is used to evict the pods to clean up, see podutils.RemovePod
+	KubeClient kubernetes.Interface
+
 	Logger logr.Logger
 }
 
@@ -70,6 +79,8 @@ type NodeList struct {
 type NodeItem struct {
 	Node                     *corev1.Node
 	ExtendedDaemonsetSetting *datadoghqv1alpha1.ExtendedDaemonsetSetting
+	// ResourcesProfile fallback resources profile used on this Node, if any
+	ResourcesProfile *datadoghqv1alpha1.ExtendedDaemonSetResourcesProfile
 }
 
 // NewNodeItem used to create new NodeItem instance
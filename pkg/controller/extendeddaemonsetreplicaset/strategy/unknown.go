@@ -35,8 +35,12 @@ func ManageUnknown(client client.Client, params *Parameters) (*Result, error) {
 	for node, pod := range params.PodByNodeName {
 		desiredPods++
 		if pod != nil {
-			if compareCurrentPodWithNewPod(params, pod, node) {
-				if podutils.HasPodSchedulerIssue(pod) && int(nbIgnoredUnresponsiveNodes) < maxPodSchedulerFailure {
+			isUpToDate, compareErr := compareCurrentPodWithNewPod(params, pod, node)
+			if compareErr != nil {
+				params.Logger.Error(compareErr, "Unable to compare the pod with the expected one", "pod_name", pod.Name, "node", node.Node.Name)
+			}
+			if isUpToDate {
+				if podutils.HasPodSchedulerIssue(pod, params.SchedulerIssueTimeout) && int(nbIgnoredUnresponsiveNodes) < maxPodSchedulerFailure {
 					nbIgnoredUnresponsiveNodes++
 					continue
 				}
@@ -11,8 +11,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-logr/logr"
-
 	corev1 "k8s.io/api/core/v1"
 
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
@@ -24,25 +22,34 @@ import (
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/conditions"
+	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
 	podaffinity "github.com/datadog/extendeddaemonset/pkg/controller/utils/affinity"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils/comparison"
 	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
 )
 
-const pausedValueTrue = "true"
+const (
+	pausedValueTrue = "true"
+	failedValueTrue = "true"
+)
 
-func compareCurrentPodWithNewPod(params *Parameters, pod *corev1.Pod, node *NodeItem) bool {
+// compareCurrentPodWithNewPod returns true if the pod is up to date on its Node. It returns an error, as the pod creation,
+// if the pod resources can't be computed for the Node: the pod is then neither up to date nor replaceable.
+func compareCurrentPodWithNewPod(params *Parameters, pod *corev1.Pod, node *NodeItem) (bool, error) {
 	// check that the pod corresponds to the replicaset. if not return false
 	if !compareSpecTemplateMD5Hash(params.Replicaset.Spec.TemplateGeneration, pod) {
-		return false
+		return false, nil
+	}
+	if !compareResourcesProfile(pod, node) {
+		return false, nil
 	}
-	if !compareWithExtendedDaemonsetSettingOverwrite(pod, node) {
-		return false
+	if isEqual, err := compareWithExtendedDaemonsetSettingOverwrite(pod, node); err != nil || !isEqual {
+		return false, err
 	}
 	if !compareNodeResourcesOverwriteMD5Hash(params.EDSName, params.Replicaset, pod, node) {
-		return false
+		return false, nil
 	}
-	return true
+	return true, nil
 }
 
 func compareNodeResourcesOverwriteMD5Hash(edsName string, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, pod *corev1.Pod, node *NodeItem) bool {
@@ -53,28 +60,60 @@ func compareNodeResourcesOverwriteMD5Hash(edsName string, replicaset *datadoghqv
 	return false
 }
 
-func compareWithExtendedDaemonsetSettingOverwrite(pod *corev1.Pod, node *NodeItem) bool {
-	if node.ExtendedDaemonsetSetting != nil {
+func compareResourcesProfile(pod *corev1.Pod, node *NodeItem) bool {
+	var profileName string
+	if node.ResourcesProfile != nil {
+		profileName = node.ResourcesProfile.Name
+	}
+	return pod.Annotations[datadoghqv1alpha1.ResourcesProfileAnnotationKey] == profileName
+}
+
+func compareWithExtendedDaemonsetSettingOverwrite(pod *corev1.Pod, node *NodeItem) (bool, error) {
+	// the resources profile takes precedence over the ExtendedDaemonsetSetting
+	var containers []datadoghqv1alpha1.ExtendedDaemonsetSettingContainerSpec
+	if node.ResourcesProfile != nil {
+		containers = node.ResourcesProfile.Containers
+	} else if node.ExtendedDaemonsetSetting != nil {
+		containers = node.ExtendedDaemonsetSetting.Spec.Containers
+	}
+
+	if containers != nil {
+		var errs []error
 		specCopy := pod.Spec.DeepCopy()
 		for id, container := range specCopy.Containers {
-			for _, container2 := range node.ExtendedDaemonsetSetting.Spec.Containers {
+			for _, container2 := range containers {
 				if container.Name == container2.Name {
+					resources := &specCopy.Containers[id].Resources
+					if len(container2.Resources.Limits) > 0 && resources.Limits == nil {
+						resources.Limits = corev1.ResourceList{}
+					}
 					for key, val := range container2.Resources.Limits {
-						specCopy.Containers[id].Resources.Limits[key] = val
+						resources.Limits[key] = val
+					}
+					if len(container2.Resources.Requests) > 0 && resources.Requests == nil {
+						resources.Requests = corev1.ResourceList{}
 					}
 					for key, val := range container2.Resources.Requests {
-						specCopy.Containers[id].Resources.Requests[key] = val
+						resources.Requests[key] = val
+					}
+					// computed resources are evaluated against the current Node properties, so a Node
+					// resize also triggers the pod update.
+					if err := podutils.ApplyResourcesFormulas(resources, container2.ResourcesFormulas, node.Node); err != nil {
+						errs = append(errs, fmt.Errorf("unable to compute the container %s resources, err: %v", container.Name, err))
 					}
 					break
 				}
 			}
 		}
+		if len(errs) > 0 {
+			return false, utilserrors.NewAggregate(errs)
+		}
 		if !apiequality.Semantic.DeepEqual(&pod.Spec, specCopy) {
-			return false
+			return false, nil
 		}
 	}
 
-	return true
+	return true, nil
 }
 
 func compareSpecTemplateMD5Hash(hash string, pod *corev1.Pod) bool {
@@ -84,21 +123,25 @@ func compareSpecTemplateMD5Hash(hash string, pod *corev1.Pod) bool {
 	return false
 }
 
-func cleanupPods(client client.Client, logger logr.Logger, status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, pods []*corev1.Pod) (*datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, reconcile.Result, error) {
-	errs := deletePodSlice(client, logger, pods)
+func cleanupPods(client client.Client, params *Parameters, status *datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, pods []*corev1.Pod) (*datadoghqv1alpha1.ExtendedDaemonSetReplicaSetStatus, reconcile.Result, error) {
+	backoff, errs := deletePodSlice(client, params, pods)
 	now := metav1.NewTime(time.Now())
 	conditionStatus := corev1.ConditionTrue
-	if len(errs) > 0 {
+	if len(errs) > 0 || backoff > 0 {
 		conditionStatus = corev1.ConditionFalse
 	}
 	if len(pods) != 0 {
 		conditions.UpdateExtendedDaemonSetReplicaSetStatusCondition(status, now, datadoghqv1alpha1.ConditionTypePodsCleanupDone, conditionStatus, "", false, false)
 	}
-	return status, reconcile.Result{}, utilserrors.NewAggregate(errs)
+	return status, reconcile.Result{RequeueAfter: backoff}, utilserrors.NewAggregate(errs)
 }
 
-func deletePodSlice(client client.Client, logger logr.Logger, podsToDelete []*corev1.Pod) []error {
+// deletePodSlice removes the pods to clean up, with the deletion mode of the rolling update strategy.
+// If evictions are rejected by a PodDisruptionBudget, it returns the delay before retrying them.
+func deletePodSlice(client client.Client, params *Parameters, podsToDelete []*corev1.Pod) (time.Duration, []error) {
 	var errs []error
+	var backoff time.Duration
+	var mutex sync.Mutex
 	var wg sync.WaitGroup
 	for id, pod := range podsToDelete {
 		if pod.DeletionTimestamp != nil {
@@ -109,15 +152,36 @@ func deletePodSlice(client client.Client, logger logr.Logger, podsToDelete []*co
 		go func(id int) {
 			defer wg.Done()
 			pod := podsToDelete[id]
-			logger.Info("cleanupPods delete pod", "pod_name", pod.Name)
-			err := client.Delete(context.TODO(), pod)
+			params.Logger.Info("cleanupPods delete pod", "pod_name", pod.Name, "mode", params.Strategy.RollingUpdate.DeletionMode)
+			err := podutils.RemovePod(client, params.KubeClient, &params.Strategy.RollingUpdate, pod)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if isBlocked, delay := podutils.IsEvictionBlocked(err); isBlocked {
+				params.Logger.Info("Pod eviction blocked by a PodDisruptionBudget", "pod_name", pod.Name, "retryAfter", delay)
+				metrics.IncPodsEvictionBlocked(pod.Namespace, params.EDSName)
+				if delay > backoff {
+					backoff = delay
+				}
+				return
+			}
 			if err != nil {
 				errs = append(errs, err)
+				return
 			}
+			metrics.IncPodsCleanedUp(pod.Namespace, params.EDSName, cleanupReason(params.NodeByName, pod))
 		}(id)
 	}
 	wg.Wait()
-	return errs
+	return backoff, errs
+}
+
+// cleanupReason returns why a pod is cleaned up: its Node is still known, so another pod runs on it,
+// or its Node is missing
+func cleanupReason(nodeByName map[string]*NodeItem, pod *corev1.Pod) string {
+	if _, ok := nodeByName[pod.Spec.NodeName]; ok {
+		return metrics.CleanupReasonDuplicated
+	}
+	return metrics.CleanupReasonMissingNode
 }
 
 func manageUnscheduledPodNodes(pods []*corev1.Pod) []string {
@@ -152,9 +216,40 @@ func pauseCanaryDeployment(client client.Client, eds *datadoghqv1alpha1.Extended
 	}
 	newEds.Annotations[datadoghqv1alpha1.ExtendedDaemonSetCanaryPausedAnnotationKey] = pausedValueTrue
 	newEds.Annotations[datadoghqv1alpha1.ExtendedDaemonSetCanaryPausedReasonAnnotationKey] = string(reason)
+	datadoghqv1alpha1.SetExtendedDaemonSetActionAnnotations(newEds, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryPaused, datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController, string(reason))
 
 	if err := client.Update(context.TODO(), newEds); err != nil {
 		return err
 	}
+	metrics.IncCanaryPaused(eds.Namespace, eds.Name, string(reason))
 	return nil
 }
+
+// pauseRollingUpdate updates the annotation so that the rolling update of the replicaset is marked as paused
+func pauseRollingUpdate(client client.Client, eds *datadoghqv1alpha1.ExtendedDaemonSet, rsName string) error {
+	newEds := eds.DeepCopy()
+	if newEds.Annotations == nil {
+		newEds.Annotations = make(map[string]string)
+	}
+	newEds.Annotations[datadoghqv1alpha1.ExtendedDaemonSetRollingUpdatePausedAnnotationKey] = rsName
+
+	return client.Update(context.TODO(), newEds)
+}
+
+// failCanaryDeployment updates the annotations so that the Canary deployment is marked as failed, along with a reason
+func failCanaryDeployment(client client.Client, eds *datadoghqv1alpha1.ExtendedDaemonSet, reason datadoghqv1alpha1.ExtendedDaemonSetStatusReason) error {
+	newEds := eds.DeepCopy()
+	if newEds.Annotations == nil {
+		newEds.Annotations = make(map[string]string)
+	}
+
+	if isFailed, ok := newEds.Annotations[datadoghqv1alpha1.ExtendedDaemonSetCanaryFailedAnnotationKey]; ok {
+		if isFailed == failedValueTrue {
+			return fmt.Errorf("canary deployment already failed")
+		}
+	}
+	newEds.Annotations[datadoghqv1alpha1.ExtendedDaemonSetCanaryFailedAnnotationKey] = failedValueTrue
+	datadoghqv1alpha1.SetExtendedDaemonSetActionAnnotations(newEds, datadoghqv1alpha1.ExtendedDaemonSetHistoryActionCanaryFailed, datadoghqv1alpha1.ExtendedDaemonSetHistoryActorController, string(reason))
+
+	return client.Update(context.TODO(), newEds)
+}
@@ -6,17 +6,25 @@
 package strategy
 
 import (
+	"context"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
 	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
 )
 
 func Test_compareWithExtendedDaemonsetSettingOverwrite(t *testing.T) {
@@ -52,15 +60,63 @@ func Test_compareWithExtendedDaemonsetSettingOverwrite(t *testing.T) {
 	}
 	extendedDaemonsetSetting2 := test.NewExtendedDaemonsetSetting("bar", "foo", "foo", edsNode2Options)
 
+	perCPU := resource.MustParse("250m")
+	edsNode3Options := &test.NewExtendedDaemonsetSettingOptions{
+		Formulas: map[string]*datadoghqv1alpha1.ExtendedDaemonsetSettingResourcesFormulas{
+			"pod1": {
+				Requests: []datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+					{Name: corev1.ResourceCPU, PerCPU: &perCPU},
+				},
+			},
+		},
+	}
+	extendedDaemonsetSetting3 := test.NewExtendedDaemonsetSetting("bar", "foo", "foo", edsNode3Options)
+	edsNode4Options := &test.NewExtendedDaemonsetSettingOptions{
+		Formulas: map[string]*datadoghqv1alpha1.ExtendedDaemonsetSettingResourcesFormulas{
+			"pod1": {
+				Requests: []datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+					{Name: corev1.ResourceCPU, PerCPU: &perCPU, NodeResourceSource: "foo"},
+				},
+			},
+		},
+	}
+	extendedDaemonsetSetting4 := test.NewExtendedDaemonsetSetting("bar", "foo", "foo", edsNode4Options)
+	node4CPU := commontest.NewNode("node-4cpu", &commontest.NewNodeOptions{
+		Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+	})
+	node8CPU := commontest.NewNode("node-8cpu", &commontest.NewNodeOptions{
+		Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")},
+	})
+
+	profile1 := &datadoghqv1alpha1.ExtendedDaemonSetResourcesProfile{
+		Name: "small",
+		Containers: []datadoghqv1alpha1.ExtendedDaemonsetSettingContainerSpec{
+			{Name: "pod1", Resources: *resource1},
+		},
+	}
+
 	type args struct {
 		pod  *corev1.Pod
 		node *NodeItem
 	}
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name    string
+		args    args
+		want    bool
+		wantErr bool
 	}{
+		{
+			name: "resources profile takes precedence over the ExtendedDaemonsetSetting",
+			args: args{
+				pod: pod1,
+				node: &NodeItem{
+					Node:                     node1,
+					ExtendedDaemonsetSetting: extendedDaemonsetSetting2,
+					ResourcesProfile:         profile1,
+				},
+			},
+			want: true,
+		},
 		{
 			name: "empty ExtendedDaemonsetSetting",
 			args: args{
@@ -93,10 +149,48 @@ func Test_compareWithExtendedDaemonsetSettingOverwrite(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "ExtendedDaemonsetSetting formulas that match the node",
+			args: args{
+				pod: pod1,
+				node: &NodeItem{
+					Node:                     node4CPU,
+					ExtendedDaemonsetSetting: extendedDaemonsetSetting3,
+				},
+			},
+			want: true,
+		},
+		{
+			name: "ExtendedDaemonsetSetting formulas that don't match the node",
+			args: args{
+				pod: pod1,
+				node: &NodeItem{
+					Node:                     node8CPU,
+					ExtendedDaemonsetSetting: extendedDaemonsetSetting3,
+				},
+			},
+			want: false,
+		},
+		{
+			name: "invalid ExtendedDaemonsetSetting formulas",
+			args: args{
+				pod: pod1,
+				node: &NodeItem{
+					Node:                     node4CPU,
+					ExtendedDaemonsetSetting: extendedDaemonsetSetting4,
+				},
+			},
+			want:    false,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := compareWithExtendedDaemonsetSettingOverwrite(tt.args.pod, tt.args.node); got != tt.want {
+			got, err := compareWithExtendedDaemonsetSettingOverwrite(tt.args.pod, tt.args.node)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("compareWithExtendedDaemonsetSettingOverwrite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
 				t.Errorf("compareWithExtendedDaemonsetSettingOverwrite() = %v, want %v", got, tt.want)
 			}
 		})
@@ -151,3 +245,99 @@ func Test_pauseCanaryDeployment(t *testing.T) {
 		})
 	}
 }
+
+func Test_cleanupReason(t *testing.T) {
+	nodeByName := map[string]*NodeItem{
+		"node1": NewNodeItem(commontest.NewNode("node1", nil), nil),
+	}
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want string
+	}{
+		{
+			name: "pod on a known node",
+			pod:  commontest.NewPod("bar", "foo-1", "node1", nil),
+			want: "duplicated",
+		},
+		{
+			name: "pod on a missing node",
+			pod:  commontest.NewPod("bar", "foo-2", "node2", nil),
+			want: "missing_node",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanupReason(nodeByName, tt.pod); got != tt.want {
+				t.Errorf("cleanupReason() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deletePodSlice(t *testing.T) {
+	pod1 := commontest.NewPod("bar", "pod1", "node1", nil)
+
+	tests := []struct {
+		name        string
+		mode        datadoghqv1alpha1.ExtendedDaemonSetDeletionMode
+		evictionErr error
+		wantEvicted bool
+		wantDeleted bool
+		wantBackoff bool
+	}{
+		{
+			name:        "delete mode",
+			mode:        datadoghqv1alpha1.ExtendedDaemonSetDeletionModeDelete,
+			wantDeleted: true,
+		},
+		{
+			name:        "evict mode",
+			mode:        datadoghqv1alpha1.ExtendedDaemonSetDeletionModeEvict,
+			wantEvicted: true,
+		},
+		{
+			name:        "evict mode, blocked by a PodDisruptionBudget",
+			mode:        datadoghqv1alpha1.ExtendedDaemonSetDeletionModeEvict,
+			evictionErr: errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0),
+			wantEvicted: true,
+			wantBackoff: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClient(pod1.DeepCopy())
+			kubeClient := kubefake.NewSimpleClientset()
+			evicted := false
+			kubeClient.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "eviction" {
+					return false, nil, nil
+				}
+				evicted = true
+				return true, nil, tt.evictionErr
+			})
+			params := &Parameters{
+				EDSName:    "foo",
+				Strategy:   &datadoghqv1alpha1.ExtendedDaemonSetSpecStrategy{RollingUpdate: datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate{DeletionMode: tt.mode}},
+				KubeClient: kubeClient,
+				Logger:     logf.Log.WithName("Test_deletePodSlice"),
+			}
+
+			backoff, errs := deletePodSlice(c, params, []*corev1.Pod{pod1})
+			if len(errs) > 0 {
+				t.Fatalf("deletePodSlice() unexpected errors: %v", errs)
+			}
+			if (backoff == podutils.DefaultEvictionBackoff) != tt.wantBackoff {
+				t.Errorf("deletePodSlice() backoff = %v, wantBackoff %v", backoff, tt.wantBackoff)
+			}
+			if evicted != tt.wantEvicted {
+				t.Errorf("deletePodSlice() evicted = %v, want %v", evicted, tt.wantEvicted)
+			}
+			err := c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "pod1"}, &corev1.Pod{})
+			if errors.IsNotFound(err) != tt.wantDeleted {
+				t.Errorf("deletePodSlice() deleted = %v, want %v", errors.IsNotFound(err), tt.wantDeleted)
+			}
+		})
+	}
+}
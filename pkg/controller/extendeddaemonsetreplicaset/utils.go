@@ -8,40 +8,52 @@ package extendeddaemonsetreplicaset
 import (
 	"context"
 	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	"k8s.io/apimachinery/pkg/runtime"
-
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
 	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
 	"github.com/go-logr/logr"
 )
 
-func createPods(logger logr.Logger, client client.Client, scheme *runtime.Scheme, podAffinitySupported bool, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, podsToCreate []*strategy.NodeItem) []error {
+// deletePods removes the pods of the previous version on the given nodes.
+// If evictions are rejected by a PodDisruptionBudget, it returns the delay before retrying them.
+func deletePods(logger logr.Logger, c client.Client, kubeClient kubernetes.Interface, rollingUpdate *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate, edsName string, podByNodeName map[*strategy.NodeItem]*corev1.Pod, nodes []*strategy.NodeItem) (time.Duration, []error) {
 	var errs []error
 	var wg sync.WaitGroup
-	errsChan := make(chan error, len(podsToCreate))
-	for id := range podsToCreate {
+	var backoffLock sync.Mutex
+	var backoff time.Duration
+	errsChan := make(chan error, len(nodes))
+	for _, node := range nodes {
 		wg.Add(1)
-		go func(id int) {
+		go func(n *strategy.NodeItem) {
 			defer wg.Done()
-			nodeItem := podsToCreate[id]
-			newPod, err := podutils.CreatePodFromDaemonSetReplicaSet(scheme, replicaset, nodeItem.Node, nodeItem.ExtendedDaemonsetSetting, podAffinitySupported)
-			if err != nil {
-				logger.Error(err, "Generate pod template failed", "name", newPod.GenerateName)
-				errsChan <- err
+			pod := podByNodeName[n]
+			logger.V(1).Info("Delete pod", "name", pod.Name, "node", n.Node.Name, "mode", rollingUpdate.DeletionMode)
+			err := podutils.RemovePod(c, kubeClient, rollingUpdate, pod)
+			if isBlocked, delay := podutils.IsEvictionBlocked(err); isBlocked {
+				logger.Info("Pod eviction blocked by a PodDisruptionBudget", "name", pod.Name, "node", n.Node.Name, "retryAfter", delay)
+				metrics.IncPodsEvictionBlocked(pod.Namespace, edsName)
+				backoffLock.Lock()
+				defer backoffLock.Unlock()
+				if delay > backoff {
+					backoff = delay
+				}
+				return
 			}
-			logger.V(1).Info("Create pod", "name", newPod.GenerateName, "node", podsToCreate[id], "addAffinity", podAffinitySupported)
-			err = client.Create(context.TODO(), newPod)
 			if err != nil {
-				logger.Error(err, "Create pod failed", "name", newPod.GenerateName)
 				errsChan <- err
+				return
 			}
-		}(id)
+			metrics.IncPodsDeleted(pod.Namespace, edsName)
+		}(node)
 	}
 	go func() {
 		wg.Wait()
@@ -53,31 +65,44 @@ func createPods(logger logr.Logger, client client.Client, scheme *runtime.Scheme
 			errs = append(errs, err)
 		}
 	}
-	return errs
+	return backoff, errs
 }
 
-func deletePods(logger logr.Logger, c client.Client, podByNodeName map[*strategy.NodeItem]*corev1.Pod, nodes []*strategy.NodeItem) []error {
+func deletePodList(logger logr.Logger, c client.Client, edsName string, pods []*corev1.Pod) []error {
 	var errs []error
-	var wg sync.WaitGroup
-	errsChan := make(chan error, len(nodes))
-	for _, node := range nodes {
-		wg.Add(1)
-		go func(n *strategy.NodeItem) {
-			defer wg.Done()
-			logger.V(1).Info("Delete pod", "name", podByNodeName[n].Name, "node", n.Node.Name)
-			err := c.Delete(context.TODO(), podByNodeName[n])
-			if err != nil {
-				errsChan <- err
+	for _, pod := range pods {
+		logger.V(1).Info("Delete pod", "name", pod.Name, "node", pod.Spec.NodeName)
+		if err := c.Delete(context.TODO(), pod); err != nil {
+			if !errors.IsNotFound(err) {
+				errs = append(errs, err)
 			}
-		}(node)
+			continue
+		}
+		metrics.IncPodsDeleted(pod.Namespace, edsName)
 	}
-	go func() {
-		wg.Wait()
-		close(errsChan)
-	}()
+	return errs
+}
 
-	for err := range errsChan {
-		if err != nil {
+// updateCanaryPodsLabel sets the canary label on the pods of the replicaset during the canary deployment,
+// and removes it once the canary deployment ended.
+func updateCanaryPodsLabel(logger logr.Logger, c client.Client, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, isCanary bool, podByNodeName map[*strategy.NodeItem]*corev1.Pod) []error {
+	var errs []error
+	for _, pod := range podByNodeName {
+		if pod == nil || pod.DeletionTimestamp != nil || pod.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNameLabelKey] != replicaset.Name {
+			continue
+		}
+		_, hasLabel := pod.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCanaryLabelKey]
+		if hasLabel == isCanary {
+			continue
+		}
+		newPod := pod.DeepCopy()
+		if isCanary {
+			newPod.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCanaryLabelKey] = "true"
+		} else {
+			delete(newPod.Labels, datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCanaryLabelKey)
+		}
+		logger.V(1).Info("Update pod canary label", "name", pod.Name, "canary", isCanary)
+		if err := c.Patch(context.TODO(), newPod, client.MergeFrom(pod)); err != nil && !errors.IsNotFound(err) {
 			errs = append(errs, err)
 		}
 	}
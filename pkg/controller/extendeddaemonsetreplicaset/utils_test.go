@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetreplicaset
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+	"github.com/datadog/extendeddaemonset/pkg/controller/extendeddaemonsetreplicaset/strategy"
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func Test_updateCanaryPodsLabel(t *testing.T) {
+	logger := logf.Log.WithName("Test_updateCanaryPodsLabel")
+	replicaset := test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", nil)
+	newPod := func(name, rsName string, canary bool) *corev1.Pod {
+		pod := commontest.NewPod("bar", name, "node1", &commontest.NewPodOptions{
+			Labels: map[string]string{datadoghqv1alpha1.ExtendedDaemonSetReplicaSetNameLabelKey: rsName},
+		})
+		if canary {
+			pod.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCanaryLabelKey] = "true"
+		}
+		return pod
+	}
+
+	tests := []struct {
+		name       string
+		pod        *corev1.Pod
+		isCanary   bool
+		wantCanary bool
+	}{
+		{
+			name:       "canary pod labeled",
+			pod:        newPod("foo-1-a", "foo-1", false),
+			isCanary:   true,
+			wantCanary: true,
+		},
+		{
+			name:       "canary ended, label removed",
+			pod:        newPod("foo-1-a", "foo-1", true),
+			isCanary:   false,
+			wantCanary: false,
+		},
+		{
+			name:       "pod of another replicaset not labeled",
+			pod:        newPod("foo-0-a", "foo-0", false),
+			isCanary:   true,
+			wantCanary: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClient(tt.pod.DeepCopy())
+			node := strategy.NewNodeItem(commontest.NewNode("node1", nil), nil)
+
+			if errs := updateCanaryPodsLabel(logger, c, replicaset, tt.isCanary, map[*strategy.NodeItem]*corev1.Pod{node: tt.pod}); len(errs) > 0 {
+				t.Fatalf("updateCanaryPodsLabel() unexpected errors: %v", errs)
+			}
+			pod := &corev1.Pod{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: tt.pod.Name}, pod); err != nil {
+				t.Fatalf("unable to get the pod: %v", err)
+			}
+			if gotCanary := pod.Labels[datadoghqv1alpha1.ExtendedDaemonSetReplicaSetCanaryLabelKey] == "true"; gotCanary != tt.wantCanary {
+				t.Errorf("updateCanaryPodsLabel() canary label = %v, want %v", gotCanary, tt.wantCanary)
+			}
+		})
+	}
+}
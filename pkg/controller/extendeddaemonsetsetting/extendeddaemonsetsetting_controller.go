@@ -17,6 +17,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilserrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -27,6 +29,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/config"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils/shard"
 )
 
 var log = logf.Log.WithName("controller_extendeddaemonsetsetting")
@@ -38,19 +43,19 @@ var log = logf.Log.WithName("controller_extendeddaemonsetsetting")
 
 // Add creates a new ExtendedDaemonsetSetting Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, opts config.Options) error {
+	return add(mgr, newReconciler(mgr, opts), opts)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileExtendedDaemonsetSetting{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, opts config.Options) reconcile.Reconciler {
+	return &ReconcileExtendedDaemonsetSetting{client: mgr.GetClient(), scheme: mgr.GetScheme(), shard: opts.Shard}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, opts config.Options) error {
 	// Create a new controller
-	c, err := controller.New("extendeddaemonsetsetting-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("extendeddaemonsetsetting-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: opts.ExtendedDaemonsetSetting.MaxConcurrentReconciles})
 	if err != nil {
 		return err
 	}
@@ -73,6 +78,8 @@ type ReconcileExtendedDaemonsetSetting struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	// shard selects the ExtendedDaemonSets whose settings are reconciled by this operator replica
+	shard *shard.Shard
 }
 
 // Reconcile reads that state of the cluster for a ExtendedDaemonsetSetting object and makes changes based on the state read
@@ -110,6 +117,16 @@ func (r *ReconcileExtendedDaemonsetSetting) Reconcile(request reconcile.Request)
 		return r.updateExtendedDaemonsetSetting(instance, newStatus)
 	}
 
+	if inShard, err := r.isReferenceInShard(instance); err != nil || !inShard {
+		return reconcile.Result{}, err
+	}
+
+	if err = validateResourcesFormulas(instance); err != nil {
+		newStatus.Status = datadoghqv1alpha1.ExtendedDaemonsetSettingStatusError
+		newStatus.Error = fmt.Sprintf("invalid resources formulas: %v", err)
+		return r.updateExtendedDaemonsetSetting(instance, newStatus)
+	}
+
 	edsNodesList := &datadoghqv1alpha1.ExtendedDaemonsetSettingList{}
 	if err = r.client.List(context.TODO(), edsNodesList, &client.ListOptions{Namespace: instance.Namespace}); err != nil {
 		return r.updateExtendedDaemonsetSetting(instance, newStatus)
@@ -121,6 +138,11 @@ func (r *ReconcileExtendedDaemonsetSetting) Reconcile(request reconcile.Request)
 		newStatus.Error = fmt.Sprintf("unable to get nodes, err:%v", err)
 	}
 
+	if newStatus.MatchingNodes, err = countMatchingNodes(instance, nodesList); err != nil {
+		newStatus.Status = datadoghqv1alpha1.ExtendedDaemonsetSettingStatusError
+		newStatus.Error = fmt.Sprintf("invalid node selector, err:%v", err)
+	}
+
 	var otherEdsNode string
 	otherEdsNode, err = searchPossibleConflict(instance, nodesList, edsNodesList)
 	if err != nil {
@@ -146,6 +168,57 @@ func (r *ReconcileExtendedDaemonsetSetting) updateExtendedDaemonsetSetting(edsNo
 	return reconcile.Result{}, err
 }
 
+// isReferenceInShard returns true if the referenced ExtendedDaemonSet is reconciled by this operator replica.
+// The settings referencing a missing ExtendedDaemonSet are reconciled by all the replicas.
+func (r *ReconcileExtendedDaemonsetSetting) isReferenceInShard(instance *datadoghqv1alpha1.ExtendedDaemonsetSetting) (bool, error) {
+	if !r.shard.IsEnabled() {
+		return true, nil
+	}
+	eds := &datadoghqv1alpha1.ExtendedDaemonSet{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.Reference.Name}, eds); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return r.shard.Contains(eds), nil
+}
+
+func validateResourcesFormulas(instance *datadoghqv1alpha1.ExtendedDaemonsetSetting) error {
+	var errs []error
+	for _, container := range instance.Spec.Containers {
+		if container.ResourcesFormulas == nil {
+			continue
+		}
+		for id := range container.ResourcesFormulas.Requests {
+			if err := podutils.ValidateResourceFormula(&container.ResourcesFormulas.Requests[id]); err != nil {
+				errs = append(errs, fmt.Errorf("container %s requests: %v", container.Name, err))
+			}
+		}
+		for id := range container.ResourcesFormulas.Limits {
+			if err := podutils.ValidateResourceFormula(&container.ResourcesFormulas.Limits[id]); err != nil {
+				errs = append(errs, fmt.Errorf("container %s limits: %v", container.Name, err))
+			}
+		}
+	}
+	return utilserrors.NewAggregate(errs)
+}
+
+// countMatchingNodes returns the number of Nodes selected by the ExtendedDaemonsetSetting NodeSelector
+func countMatchingNodes(instance *datadoghqv1alpha1.ExtendedDaemonsetSetting, nodeList *corev1.NodeList) (int32, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&instance.Spec.NodeSelector)
+	if err != nil {
+		return 0, err
+	}
+	var nb int32
+	for _, node := range nodeList.Items {
+		if selector.Matches(labels.Set(node.Labels)) {
+			nb++
+		}
+	}
+	return nb, nil
+}
+
 func searchPossibleConflict(instance *datadoghqv1alpha1.ExtendedDaemonsetSetting, nodeList *corev1.NodeList, edsNodeList *datadoghqv1alpha1.ExtendedDaemonsetSettingList) (string, error) {
 	var edsNodes edsNodeByCreationTimestampAndPhase
 	for id := range edsNodeList.Items {
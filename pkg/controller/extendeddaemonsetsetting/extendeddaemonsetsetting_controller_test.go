@@ -106,3 +106,25 @@ func Test_searchPossibleConflict(t *testing.T) {
 		})
 	}
 }
+
+func Test_countMatchingNodes(t *testing.T) {
+	setting := test.NewExtendedDaemonsetSetting("bar", "foo", "app", &test.NewExtendedDaemonsetSettingOptions{
+		Selector: map[string]string{"test": "bigmemory"},
+	})
+	nodeList := &corev1.NodeList{
+		Items: []corev1.Node{
+			*commontest.NewNode("node1", &commontest.NewNodeOptions{Labels: map[string]string{"test": "bigmemory"}}),
+			*commontest.NewNode("node2", &commontest.NewNodeOptions{Labels: map[string]string{"test": "bigmemory", "zone": "a"}}),
+			*commontest.NewNode("node3", &commontest.NewNodeOptions{Labels: map[string]string{"test": "smallmemory"}}),
+			*commontest.NewNode("node4", nil),
+		},
+	}
+
+	got, err := countMatchingNodes(setting, nodeList)
+	if err != nil {
+		t.Fatalf("countMatchingNodes() unexpected error: %v", err)
+	}
+	if got != 2 {
+		t.Errorf("countMatchingNodes() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package extendeddaemonsetsetting
+
+import (
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/metrics"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	ksmetric "k8s.io/kube-state-metrics/pkg/metric"
+)
+
+const (
+	extendeddaemonsetsettingCreated             = "extendeddaemonsetsetting_created"
+	extendeddaemonsetsettingStatus              = "extendeddaemonsetsetting_status"
+	extendeddaemonsetsettingStatusMatchingNodes = "extendeddaemonsetsetting_status_matching_nodes"
+)
+
+// AddMetrics add ExtendedDaemonsetSetting metrics
+func AddMetrics(mgr manager.Manager, h metrics.Handler) error {
+	families := generateMetricFamilies()
+
+	// the metrics store is fed by the manager informer, to not duplicate the watch
+	informer, err := mgr.GetCache().GetInformer(&datadoghqv1alpha1.ExtendedDaemonsetSetting{})
+	if err != nil {
+		return err
+	}
+
+	return h.RegisterStore(families, informer)
+}
+
+func generateMetricFamilies() []ksmetric.FamilyGenerator {
+	return []ksmetric.FamilyGenerator{
+		{
+			Name: extendeddaemonsetsettingCreated,
+			Type: ksmetric.Gauge,
+			Help: "Unix creation timestamp",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				setting := obj.(*datadoghqv1alpha1.ExtendedDaemonsetSetting)
+				labelKeys, labelValues := utils.GetLabelsValues(&setting.ObjectMeta)
+				return &ksmetric.Family{
+					Metrics: []*ksmetric.Metric{
+						{
+							Value:       float64(setting.CreationTimestamp.Unix()),
+							LabelKeys:   labelKeys,
+							LabelValues: labelValues,
+						},
+					},
+				}
+			},
+		},
+		{
+			Name: extendeddaemonsetsettingStatus,
+			Type: ksmetric.Gauge,
+			Help: "The status of the ExtendedDaemonsetSetting, set to 1 for the current status",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				setting := obj.(*datadoghqv1alpha1.ExtendedDaemonsetSetting)
+				labelKeys, labelValues := utils.GetLabelsValues(&setting.ObjectMeta)
+				reference := ""
+				if setting.Spec.Reference != nil {
+					reference = setting.Spec.Reference.Name
+				}
+				statusMetrics := make([]*ksmetric.Metric, 0, 2)
+				for _, status := range []datadoghqv1alpha1.ExtendedDaemonsetSettingStatusStatus{datadoghqv1alpha1.ExtendedDaemonsetSettingStatusValid, datadoghqv1alpha1.ExtendedDaemonsetSettingStatusError} {
+					val := float64(0)
+					if setting.Status.Status == status {
+						val = 1
+					}
+					statusMetrics = append(statusMetrics, &ksmetric.Metric{
+						Value:       val,
+						LabelKeys:   append(labelKeys, "extendeddaemonset", "status"),
+						LabelValues: append(labelValues, reference, string(status)),
+					})
+				}
+				return &ksmetric.Family{
+					Metrics: statusMetrics,
+				}
+			},
+		},
+		{
+			Name: extendeddaemonsetsettingStatusMatchingNodes,
+			Type: ksmetric.Gauge,
+			Help: "The number of nodes selected by the ExtendedDaemonsetSetting node selector",
+			GenerateFunc: func(obj interface{}) *ksmetric.Family {
+				setting := obj.(*datadoghqv1alpha1.ExtendedDaemonsetSetting)
+				labelKeys, labelValues := utils.GetLabelsValues(&setting.ObjectMeta)
+				return &ksmetric.Family{
+					Metrics: []*ksmetric.Metric{
+						{
+							Value:       float64(setting.Status.MatchingNodes),
+							LabelKeys:   labelKeys,
+							LabelValues: labelValues,
+						},
+					},
+				}
+			},
+		},
+	}
+}
@@ -0,0 +1,290 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	edsNamespaceLabel = "namespace"
+	edsNameLabel      = "name"
+	reasonLabel       = "reason"
+	phaseLabel        = "phase"
+	hookLabel         = "hook"
+
+	// CleanupReasonDuplicated reason used when a pod is deleted because another pod runs on the same Node
+	CleanupReasonDuplicated = "duplicated"
+	// CleanupReasonMissingNode reason used when a pod is deleted because its Node doesn't exist or is not selected anymore
+	CleanupReasonMissingNode = "missing_node"
+)
+
+var (
+	podsCreated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_pods_created_total",
+			Help: "Number of pods created by the controller",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	podsCreationFailed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_pods_creation_failed_total",
+			Help: "Number of pods the controller failed to create, by reason",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel, reasonLabel},
+	)
+	podsDeleted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_pods_deleted_total",
+			Help: "Number of pods deleted by the controller",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	podsEvictionBlocked = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_pods_eviction_blocked_total",
+			Help: "Number of pod evictions rejected by a PodDisruptionBudget",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	podsCleanedUp = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_pods_cleaned_up_total",
+			Help: "Number of pods cleaned up by the controller, by reason: duplicated or missing_node",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel, reasonLabel},
+	)
+	canaryStarted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_canary_started_total",
+			Help: "Number of canary deployments started",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	canaryValidated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_canary_validated_total",
+			Help: "Number of canary deployments validated",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	canaryFailed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_canary_failed_total",
+			Help: "Number of canary deployments failed",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	canaryPaused = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_canary_paused_total",
+			Help: "Number of canary deployments automatically paused, by reason",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel, reasonLabel},
+	)
+	canaryNodesReselected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_canary_nodes_reselected_total",
+			Help: "Number of canary nodes replaced by another node",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	rollingUpdateFailed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_rolling_update_failed_total",
+			Help: "Number of rolling updates halted by the failure policy, by reason",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel, reasonLabel},
+	)
+	rollbacks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_rollbacks_total",
+			Help: "Number of automatic rollbacks to the previous ExtendedDaemonSetReplicaSet",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	progressDeadlineExceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_progress_deadline_exceeded_total",
+			Help: "Number of rollouts without an additional pod available within the progress deadline",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+	hooksCompleted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eds_controller_hooks_total",
+			Help: "Number of lifecycle hooks completed, by hook and phase: Succeeded or Failed",
+		},
+		[]string{edsNamespaceLabel, edsNameLabel, hookLabel, phaseLabel},
+	)
+	strategyReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "eds_controller_strategy_reconcile_duration_seconds",
+			Help:    "Time spent by a reconcile to apply the ExtendedDaemonSetReplicaSet strategy, by phase: active, canary or unknown",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{edsNamespaceLabel, edsNameLabel, phaseLabel},
+	)
+	rolloutPhaseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "eds_controller_rollout_phase_duration_seconds",
+			Help:    "Duration of the rollout phases, observed when the rollout leaves the phase, by phase: Canary or Rolling",
+			Buckets: prometheus.ExponentialBuckets(60, 2, 10),
+		},
+		[]string{edsNamespaceLabel, edsNameLabel, phaseLabel},
+	)
+	rolloutDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "eds_controller_rollout_duration_seconds",
+			Help:    "Duration of the completed rollouts, from the ExtendedDaemonSetReplicaSet creation to its availability on all the nodes",
+			Buckets: prometheus.ExponentialBuckets(60, 2, 10),
+		},
+		[]string{edsNamespaceLabel, edsNameLabel},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		podsCreated,
+		podsCreationFailed,
+		podsDeleted,
+		podsEvictionBlocked,
+		podsCleanedUp,
+		canaryStarted,
+		canaryValidated,
+		canaryFailed,
+		canaryPaused,
+		canaryNodesReselected,
+		rollingUpdateFailed,
+		rollbacks,
+		progressDeadlineExceeded,
+		hooksCompleted,
+		strategyReconcileDuration,
+		rolloutPhaseDuration,
+		rolloutDuration,
+	)
+}
+
+// IncPodsCreated increments the number of pods created for an ExtendedDaemonSet
+func IncPodsCreated(namespace, edsName string) {
+	podsCreated.WithLabelValues(namespace, edsName).Inc()
+	forwardCount("controller.pods_created", namespace, edsName, 1)
+}
+
+// IncPodsCreationFailed increments the number of pods creation failures for an ExtendedDaemonSet
+func IncPodsCreationFailed(namespace, edsName, reason string) {
+	podsCreationFailed.WithLabelValues(namespace, edsName, reason).Inc()
+	forwardCount("controller.pods_creation_failed", namespace, edsName, 1, "reason:"+reason)
+}
+
+// IncPodsDeleted increments the number of pods deleted for an ExtendedDaemonSet
+func IncPodsDeleted(namespace, edsName string) {
+	podsDeleted.WithLabelValues(namespace, edsName).Inc()
+	forwardCount("controller.pods_deleted", namespace, edsName, 1)
+}
+
+// IncPodsEvictionBlocked increments the number of pod evictions rejected by a PodDisruptionBudget for an ExtendedDaemonSet
+func IncPodsEvictionBlocked(namespace, edsName string) {
+	podsEvictionBlocked.WithLabelValues(namespace, edsName).Inc()
+	forwardCount("controller.pods_eviction_blocked", namespace, edsName, 1)
+}
+
+// IncPodsCleanedUp increments the number of pods cleaned up for an ExtendedDaemonSet
+func IncPodsCleanedUp(namespace, edsName, reason string) {
+	podsCleanedUp.WithLabelValues(namespace, edsName, reason).Inc()
+	forwardCount("controller.pods_cleaned_up", namespace, edsName, 1, "reason:"+reason)
+}
+
+// IncCanaryStarted increments the number of canary deployments started for an ExtendedDaemonSet
+func IncCanaryStarted(namespace, edsName, replicaSet string) {
+	canaryStarted.WithLabelValues(namespace, edsName).Inc()
+	forwardCount("controller.canary_started", namespace, edsName, 1)
+	forwardEvent(namespace, edsName, "canary deployment started", fmt.Sprintf("The canary deployment of the ExtendedDaemonSetReplicaSet %s started", replicaSet), eventAlertInfo)
+}
+
+// IncCanaryValidated increments the number of canary deployments validated for an ExtendedDaemonSet
+func IncCanaryValidated(namespace, edsName string) {
+	canaryValidated.WithLabelValues(namespace, edsName).Inc()
+	forwardCount("controller.canary_validated", namespace, edsName, 1)
+	forwardEvent(namespace, edsName, "canary deployment validated", "The canary deployment is validated, the rolling update starts", eventAlertSuccess)
+}
+
+// IncCanaryFailed increments the number of canary deployments failed for an ExtendedDaemonSet
+func IncCanaryFailed(namespace, edsName string) {
+	canaryFailed.WithLabelValues(namespace, edsName).Inc()
+	forwardCount("controller.canary_failed", namespace, edsName, 1)
+	forwardEvent(namespace, edsName, "canary deployment failed", "The canary deployment failed, the previous version is restored", eventAlertError)
+}
+
+// IncCanaryPaused increments the number of canary deployments automatically paused for an ExtendedDaemonSet
+func IncCanaryPaused(namespace, edsName, reason string) {
+	canaryPaused.WithLabelValues(namespace, edsName, reason).Inc()
+	forwardCount("controller.canary_paused", namespace, edsName, 1, "reason:"+reason)
+	forwardEvent(namespace, edsName, "canary deployment paused", fmt.Sprintf("The canary deployment is automatically paused, reason: %s", reason), eventAlertWarning)
+}
+
+// AddCanaryNodesReselected adds the number of canary nodes replaced for an ExtendedDaemonSet
+func AddCanaryNodesReselected(namespace, edsName string, nbNodes int) {
+	canaryNodesReselected.WithLabelValues(namespace, edsName).Add(float64(nbNodes))
+	forwardCount("controller.canary_nodes_reselected", namespace, edsName, int64(nbNodes))
+}
+
+// IncRollingUpdateFailed increments the number of rolling updates halted by the failure policy for an ExtendedDaemonSet
+func IncRollingUpdateFailed(namespace, edsName, reason string) {
+	rollingUpdateFailed.WithLabelValues(namespace, edsName, reason).Inc()
+	forwardCount("controller.rolling_update_failed", namespace, edsName, 1, "reason:"+reason)
+	forwardEvent(namespace, edsName, "rolling update failed", fmt.Sprintf("The rolling update is halted by the failure policy, reason: %s", reason), eventAlertError)
+}
+
+// IncRollback increments the number of automatic rollbacks for an ExtendedDaemonSet
+func IncRollback(namespace, edsName, replicaSet string) {
+	rollbacks.WithLabelValues(namespace, edsName).Inc()
+	forwardCount("controller.rollbacks", namespace, edsName, 1)
+	forwardEvent(namespace, edsName, "rollback started", fmt.Sprintf("The ExtendedDaemonSet is rolled back to %s", replicaSet), eventAlertWarning)
+}
+
+// IncProgressDeadlineExceeded increments the number of rollouts exceeding the progress deadline for an ExtendedDaemonSet
+func IncProgressDeadlineExceeded(namespace, edsName, replicaSet string) {
+	progressDeadlineExceeded.WithLabelValues(namespace, edsName).Inc()
+	forwardCount("controller.progress_deadline_exceeded", namespace, edsName, 1)
+	forwardEvent(namespace, edsName, "progress deadline exceeded", fmt.Sprintf("No additional pod of %s became available within the progress deadline", replicaSet), eventAlertWarning)
+}
+
+// IncHookCompleted increments the number of lifecycle hooks completed for an ExtendedDaemonSet
+func IncHookCompleted(namespace, edsName, hook, replicaSet string, succeeded bool) {
+	phase, alertType := "Succeeded", eventAlertSuccess
+	if !succeeded {
+		phase, alertType = "Failed", eventAlertError
+	}
+	hooksCompleted.WithLabelValues(namespace, edsName, hook, phase).Inc()
+	forwardCount("controller.hooks", namespace, edsName, 1, "hook:"+hook, "phase:"+phase)
+	forwardEvent(namespace, edsName, "lifecycle hook completed", fmt.Sprintf("The %s hook of %s completed, phase: %s", hook, replicaSet, phase), alertType)
+}
+
+// ObserveStrategyReconcileDuration records the time spent by a reconcile to apply the strategy of an ExtendedDaemonSet
+func ObserveStrategyReconcileDuration(namespace, edsName, phase string, duration time.Duration) {
+	strategyReconcileDuration.WithLabelValues(namespace, edsName, phase).Observe(duration.Seconds())
+	forwardHistogram("controller.strategy_reconcile_duration", namespace, edsName, duration.Seconds(), "phase:"+phase)
+}
+
+// ObserveRolloutPhaseDuration records the duration of a rollout phase left by an ExtendedDaemonSet
+func ObserveRolloutPhaseDuration(namespace, edsName, phase string, duration time.Duration) {
+	rolloutPhaseDuration.WithLabelValues(namespace, edsName, phase).Observe(duration.Seconds())
+	forwardHistogram("rollout.phase_duration", namespace, edsName, duration.Seconds(), "phase:"+phase)
+}
+
+// ObserveRolloutDuration records the duration of a completed rollout for an ExtendedDaemonSet
+func ObserveRolloutDuration(namespace, edsName string, duration time.Duration) {
+	rolloutDuration.WithLabelValues(namespace, edsName).Observe(duration.Seconds())
+	forwardHistogram("rollout.duration", namespace, edsName, duration.Seconds())
+	forwardEvent(namespace, edsName, "rollout complete", fmt.Sprintf("The new version is available on all the nodes, the rollout took %s", duration.Round(time.Second)), eventAlertSuccess)
+}
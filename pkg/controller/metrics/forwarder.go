@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+// Forwarder pushes the controller metrics and the rollout lifecycle events to another monitoring system,
+// in addition to the Prometheus registry. Implemented by the dogstatsd.Client.
+type Forwarder interface {
+	Count(name string, value int64, tags []string) error
+	Gauge(name string, value float64, tags []string) error
+	Histogram(name string, value float64, tags []string) error
+	Event(title, text, alertType, aggregationKey string, tags []string) error
+}
+
+const (
+	eventAlertInfo    = "info"
+	eventAlertSuccess = "success"
+	eventAlertWarning = "warning"
+	eventAlertError   = "error"
+)
+
+var forwarder Forwarder
+
+// SetForwarder sets the Forwarder used by the metrics helpers.
+// It should be called before the controllers are started.
+func SetForwarder(f Forwarder) {
+	forwarder = f
+}
+
+// ForwardRolloutStatus pushes the rollout progress of an ExtendedDaemonSet.
+// The same information is available in Prometheus format with the eds_status_rollout_* metrics.
+func ForwardRolloutStatus(namespace, edsName string, rollout *datadoghqv1alpha1.ExtendedDaemonSetStatusRollout, now time.Time) {
+	if forwarder == nil || rollout == nil {
+		return
+	}
+	tags := forwarderTags(namespace, edsName, "replicaset:"+rollout.ReplicaSet, "phase:"+string(rollout.Phase))
+	_ = forwarder.Gauge("rollout.percent_complete", float64(rollout.PercentComplete), tags)
+	if rollout.EstimatedCompletionTime != nil {
+		_ = forwarder.Gauge("rollout.estimated_remaining_seconds", rollout.EstimatedCompletionTime.Sub(now).Seconds(), tags)
+	}
+}
+
+func forwardCount(name, namespace, edsName string, value int64, extraTags ...string) {
+	if forwarder == nil {
+		return
+	}
+	_ = forwarder.Count(name, value, forwarderTags(namespace, edsName, extraTags...))
+}
+
+func forwardHistogram(name, namespace, edsName string, value float64, extraTags ...string) {
+	if forwarder == nil {
+		return
+	}
+	_ = forwarder.Histogram(name, value, forwarderTags(namespace, edsName, extraTags...))
+}
+
+func forwardEvent(namespace, edsName, title, text, alertType string) {
+	if forwarder == nil {
+		return
+	}
+	title = fmt.Sprintf("ExtendedDaemonSet %s/%s: %s", namespace, edsName, title)
+	_ = forwarder.Event(title, text, alertType, fmt.Sprintf("extendeddaemonset:%s/%s", namespace, edsName), forwarderTags(namespace, edsName))
+}
+
+func forwarderTags(namespace, edsName string, extraTags ...string) []string {
+	return append([]string{"kube_namespace:" + namespace, "extendeddaemonset:" + edsName}, extraTags...)
+}
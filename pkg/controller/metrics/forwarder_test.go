@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+type fakeForwarder struct {
+	calls []string
+}
+
+func (f *fakeForwarder) Count(name string, value int64, tags []string) error {
+	f.calls = append(f.calls, fmt.Sprintf("count %s %d %v", name, value, tags))
+	return nil
+}
+
+func (f *fakeForwarder) Gauge(name string, value float64, tags []string) error {
+	f.calls = append(f.calls, fmt.Sprintf("gauge %s %v %v", name, value, tags))
+	return nil
+}
+
+func (f *fakeForwarder) Histogram(name string, value float64, tags []string) error {
+	f.calls = append(f.calls, fmt.Sprintf("histogram %s %v %v", name, value, tags))
+	return nil
+}
+
+func (f *fakeForwarder) Event(title, text, alertType, aggregationKey string, tags []string) error {
+	f.calls = append(f.calls, fmt.Sprintf("event %s|%s|%s|%s %v", title, text, alertType, aggregationKey, tags))
+	return nil
+}
+
+func TestForwarder(t *testing.T) {
+	now := time.Now()
+	eta := metav1.NewTime(now.Add(90 * time.Second))
+	rollout := &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{
+		ReplicaSet:              "foo-1",
+		Phase:                   datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling,
+		PercentComplete:         40,
+		EstimatedCompletionTime: &eta,
+	}
+
+	tests := []struct {
+		name   string
+		record func()
+		want   []string
+	}{
+		{
+			name:   "count",
+			record: func() { IncPodsCleanedUp("bar", "foo", CleanupReasonDuplicated) },
+			want:   []string{"count controller.pods_cleaned_up 1 [kube_namespace:bar extendeddaemonset:foo reason:duplicated]"},
+		},
+		{
+			name:   "count and event",
+			record: func() { IncCanaryPaused("bar", "foo", "CrashLoopBackOff") },
+			want: []string{
+				"count controller.canary_paused 1 [kube_namespace:bar extendeddaemonset:foo reason:CrashLoopBackOff]",
+				"event ExtendedDaemonSet bar/foo: canary deployment paused|The canary deployment is automatically paused, reason: CrashLoopBackOff|warning|extendeddaemonset:bar/foo [kube_namespace:bar extendeddaemonset:foo]",
+			},
+		},
+		{
+			name:   "rollout status",
+			record: func() { ForwardRolloutStatus("bar", "foo", rollout, now) },
+			want: []string{
+				"gauge rollout.percent_complete 40 [kube_namespace:bar extendeddaemonset:foo replicaset:foo-1 phase:Rolling]",
+				"gauge rollout.estimated_remaining_seconds 90 [kube_namespace:bar extendeddaemonset:foo replicaset:foo-1 phase:Rolling]",
+			},
+		},
+	}
+
+	defer SetForwarder(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeForwarder{}
+			SetForwarder(f)
+			tt.record()
+			if diff := cmp.Diff(tt.want, f.calls); diff != "" {
+				t.Errorf("forwarded calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,11 +1,11 @@
 package metrics
 
 import (
-	"k8s.io/client-go/tools/cache"
 	ksmetric "k8s.io/kube-state-metrics/pkg/metric"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 )
 
 // Handler use to registry controller metrics
 type Handler interface {
-	RegisterStore(generators []ksmetric.FamilyGenerator, expectedType interface{}, lw cache.ListerWatcher) error
+	RegisterStore(generators []ksmetric.FamilyGenerator, informer cache.Informer) error
 }
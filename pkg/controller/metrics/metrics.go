@@ -1,32 +1,37 @@
 package metrics
 
 import (
-	"context"
-
 	ksmetric "k8s.io/kube-state-metrics/pkg/metric"
 	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
 
 	"k8s.io/client-go/tools/cache"
+	crcache "sigs.k8s.io/controller-runtime/pkg/cache"
 )
 
-// newMetricsStore return new metrics store
-func newMetricsStore(generators []ksmetric.FamilyGenerator, expectedType interface{}, lw cache.ListerWatcher) *metricsstore.MetricsStore {
-
-	// Generate collector per namespace.
+// newMetricsStore return new metrics store, fed by the informer events
+func newMetricsStore(generators []ksmetric.FamilyGenerator, informer crcache.Informer) *metricsstore.MetricsStore {
 	composedMetricGenFuncs := ksmetric.ComposeMetricGenFuncs(generators)
 	headers := ksmetric.ExtractMetricFamilyHeaders(generators)
 	store := metricsstore.NewMetricsStore(headers, composedMetricGenFuncs)
-	reflectorPerNamespace(context.TODO(), lw, expectedType, store)
+	informer.AddEventHandler(newStoreEventHandler(store))
 
 	return store
 }
 
-func reflectorPerNamespace(
-	ctx context.Context,
-	lw cache.ListerWatcher,
-	expectedType interface{},
-	store cache.Store,
-) {
-	reflector := cache.NewReflector(lw, expectedType, store, 0)
-	go reflector.Run(ctx.Done())
+// newStoreEventHandler returns an event handler forwarding the informer events to the store
+func newStoreEventHandler(store cache.Store) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			_ = store.Add(obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			_ = store.Update(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			_ = store.Delete(obj)
+		},
+	}
 }
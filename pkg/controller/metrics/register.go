@@ -10,9 +10,9 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	"k8s.io/client-go/tools/cache"
 	ksmetric "k8s.io/kube-state-metrics/pkg/metric"
 	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/datadog/extendeddaemonset/pkg/controller/httpserver"
@@ -44,8 +44,8 @@ func (h *storesHandler) serveKsmHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *storesHandler) RegisterStore(generators []ksmetric.FamilyGenerator, expectedType interface{}, lw cache.ListerWatcher) error {
-	store := newMetricsStore(generators, expectedType, lw)
+func (h *storesHandler) RegisterStore(generators []ksmetric.FamilyGenerator, informer cache.Informer) error {
+	store := newMetricsStore(generators, informer)
 
 	h.stores = append(h.stores, store)
 	return nil
@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+// DefaultTemplate default template of the notification messages
+const DefaultTemplate = "ExtendedDaemonSet {{ .Namespace }}/{{ .Name }}: {{ .Summary }}"
+
+// Event ExtendedDaemonSet state transition
+type Event struct {
+	Namespace            string                                                `json:"namespace"`
+	Name                 string                                                `json:"name"`
+	PreviousState        datadoghqv1alpha1.ExtendedDaemonSetStatusState        `json:"previousState,omitempty"`
+	State                datadoghqv1alpha1.ExtendedDaemonSetStatusState        `json:"state"`
+	Reason               datadoghqv1alpha1.ExtendedDaemonSetStatusReason       `json:"reason,omitempty"`
+	ActiveReplicaSet     string                                                `json:"activeReplicaSet,omitempty"`
+	CanaryReplicaSet     string                                                `json:"canaryReplicaSet,omitempty"`
+	PreviousRolloutPhase datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhase `json:"previousRolloutPhase,omitempty"`
+	RolloutPhase         datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhase `json:"rolloutPhase,omitempty"`
+	RolloutReplicaSet    string                                                `json:"rolloutReplicaSet,omitempty"`
+	PercentComplete      int32                                                 `json:"percentComplete"`
+	Time                 time.Time                                             `json:"time"`
+}
+
+// NewEvent returns the Event corresponding to the status transition of an ExtendedDaemonSet,
+// or nil if the transition doesn't need to be notified.
+func NewEvent(daemonset *datadoghqv1alpha1.ExtendedDaemonSet, newStatus *datadoghqv1alpha1.ExtendedDaemonSetStatus, now time.Time) *Event {
+	oldStatus := &daemonset.Status
+	event := &Event{
+		Namespace:        daemonset.Namespace,
+		Name:             daemonset.Name,
+		PreviousState:    oldStatus.State,
+		State:            newStatus.State,
+		Reason:           newStatus.Reason,
+		ActiveReplicaSet: newStatus.ActiveReplicaSet,
+		Time:             now,
+	}
+	if newStatus.Canary != nil {
+		event.CanaryReplicaSet = newStatus.Canary.ReplicaSet
+	}
+	if oldStatus.Rollout != nil {
+		event.PreviousRolloutPhase = oldStatus.Rollout.Phase
+	}
+	if newStatus.Rollout != nil {
+		event.RolloutPhase = newStatus.Rollout.Phase
+		event.RolloutReplicaSet = newStatus.Rollout.ReplicaSet
+		event.PercentComplete = newStatus.Rollout.PercentComplete
+	}
+
+	switch {
+	case event.isRolloutComplete():
+		return event
+	case oldStatus.State == "" || oldStatus.State == newStatus.State && oldStatus.Reason == newStatus.Reason:
+		if newStatus.Canary == nil || oldStatus.Canary == nil || oldStatus.Canary.ReplicaSet == newStatus.Canary.ReplicaSet {
+			return nil
+		}
+	}
+	return event
+}
+
+// isRolloutComplete returns true if the event is the end of a rollout: the rollout phase became Complete
+func (e *Event) isRolloutComplete() bool {
+	return e.RolloutPhase == datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete &&
+		e.PreviousRolloutPhase != "" && e.PreviousRolloutPhase != datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete
+}
+
+// Summary returns a human readable description of the transition
+func (e *Event) Summary() string {
+	switch {
+	case e.isRolloutComplete():
+		return fmt.Sprintf("rollout of %s complete", e.RolloutReplicaSet)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused:
+		return fmt.Sprintf("canary deployment of %s paused, reason: %s", e.CanaryReplicaSet, e.Reason)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed:
+		return fmt.Sprintf("canary deployment of %s failed", e.RolloutReplicaSet)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanary && e.PreviousState == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused:
+		return fmt.Sprintf("canary deployment of %s resumed", e.CanaryReplicaSet)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanary:
+		return fmt.Sprintf("canary deployment of %s started", e.CanaryReplicaSet)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed:
+		return fmt.Sprintf("rolling update of %s failed, reason: %s", e.RolloutReplicaSet, e.Reason)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdatePaused:
+		return fmt.Sprintf("rolling update of %s paused, reason: %s", e.RolloutReplicaSet, e.Reason)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning && e.PreviousState == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdatePaused:
+		return fmt.Sprintf("rolling update of %s resumed", e.RolloutReplicaSet)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning && e.PreviousState == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed:
+		return fmt.Sprintf("rollback to %s started", e.ActiveReplicaSet)
+	case e.State == datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning && e.RolloutPhase == datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling:
+		return fmt.Sprintf("canary deployment of %s validated, rolling update started", e.ActiveReplicaSet)
+	default:
+		return fmt.Sprintf("state changed from %q to %q", e.PreviousState, e.State)
+	}
+}
+
+// dedupKey returns the key used to detect the duplicated notifications
+func (e *Event) dedupKey() string {
+	return fmt.Sprintf("%s/%s: %s", e.Namespace, e.Name, e.Summary())
+}
+
+// render executes the template with the event. If the template is empty, DefaultTemplate is used.
+func (e *Event) render(tpl string) (string, error) {
+	if tpl == "" {
+		tpl = DefaultTemplate
+	}
+	t, err := template.New("notification").Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the notification template, err: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err = t.Execute(buf, e); err != nil {
+		return "", fmt.Errorf("unable to execute the notification template, err: %v", err)
+	}
+	return buf.String(), nil
+}
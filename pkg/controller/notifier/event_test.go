@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+)
+
+func TestNewEvent(t *testing.T) {
+	newStatus := func(state datadoghqv1alpha1.ExtendedDaemonSetStatusState, canaryRS string, phase datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhase) *datadoghqv1alpha1.ExtendedDaemonSetStatus {
+		status := &datadoghqv1alpha1.ExtendedDaemonSetStatus{
+			ActiveReplicaSet: "foo-1",
+			State:            state,
+			Rollout:          &datadoghqv1alpha1.ExtendedDaemonSetStatusRollout{ReplicaSet: "foo-2", Phase: phase},
+		}
+		if canaryRS != "" {
+			status.Canary = &datadoghqv1alpha1.ExtendedDaemonSetStatusCanary{ReplicaSet: canaryRS}
+		}
+		switch state {
+		case datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused:
+			status.Reason = datadoghqv1alpha1.ExtendedDaemonSetStatusReasonCLB
+		case datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed:
+			status.Reason = datadoghqv1alpha1.ExtendedDaemonSetStatusReasonPodsRestarting
+		}
+		return status
+	}
+	const (
+		running       = datadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning
+		canary        = datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanary
+		canaryPaused  = datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused
+		canaryFailed  = datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed
+		rollingFailed = datadoghqv1alpha1.ExtendedDaemonSetStatusStateRollingUpdateFailed
+		phaseCanary   = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseCanary
+		rolling       = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseRolling
+		complete      = datadoghqv1alpha1.ExtendedDaemonSetStatusRolloutPhaseComplete
+	)
+
+	tests := []struct {
+		name        string
+		oldStatus   *datadoghqv1alpha1.ExtendedDaemonSetStatus
+		newStatus   *datadoghqv1alpha1.ExtendedDaemonSetStatus
+		wantSummary string
+	}{
+		{
+			name:      "first status",
+			oldStatus: &datadoghqv1alpha1.ExtendedDaemonSetStatus{},
+			newStatus: newStatus(running, "", complete),
+		},
+		{
+			name:      "no transition",
+			oldStatus: newStatus(canary, "foo-2", phaseCanary),
+			newStatus: newStatus(canary, "foo-2", phaseCanary),
+		},
+		{
+			name:        "canary started",
+			oldStatus:   newStatus(running, "", complete),
+			newStatus:   newStatus(canary, "foo-2", phaseCanary),
+			wantSummary: "canary deployment of foo-2 started",
+		},
+		{
+			name:        "new canary replicaset",
+			oldStatus:   newStatus(canary, "foo-2", phaseCanary),
+			newStatus:   newStatus(canary, "foo-3", phaseCanary),
+			wantSummary: "canary deployment of foo-3 started",
+		},
+		{
+			name:        "canary paused",
+			oldStatus:   newStatus(canary, "foo-2", phaseCanary),
+			newStatus:   newStatus(canaryPaused, "foo-2", phaseCanary),
+			wantSummary: "canary deployment of foo-2 paused, reason: CrashLoopBackOff",
+		},
+		{
+			name:        "canary resumed",
+			oldStatus:   newStatus(canaryPaused, "foo-2", phaseCanary),
+			newStatus:   newStatus(canary, "foo-2", phaseCanary),
+			wantSummary: "canary deployment of foo-2 resumed",
+		},
+		{
+			name:        "canary failed",
+			oldStatus:   newStatus(canary, "foo-2", phaseCanary),
+			newStatus:   newStatus(canaryFailed, "", phaseCanary),
+			wantSummary: "canary deployment of foo-2 failed",
+		},
+		{
+			name:        "canary validated",
+			oldStatus:   newStatus(canary, "foo-2", phaseCanary),
+			newStatus:   newStatus(running, "", rolling),
+			wantSummary: "canary deployment of foo-1 validated, rolling update started",
+		},
+		{
+			name:        "rollout complete",
+			oldStatus:   newStatus(running, "", rolling),
+			newStatus:   newStatus(running, "", complete),
+			wantSummary: "rollout of foo-2 complete",
+		},
+		{
+			name:        "rolling update failed",
+			oldStatus:   newStatus(running, "", rolling),
+			newStatus:   newStatus(rollingFailed, "", rolling),
+			wantSummary: "rolling update of foo-2 failed, reason: PodsRestarting",
+		},
+		{
+			name:        "rollback started",
+			oldStatus:   newStatus(rollingFailed, "", rolling),
+			newStatus:   newStatus(running, "", rolling),
+			wantSummary: "rollback to foo-1 started",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemonset := test.NewExtendedDaemonSet("bar", "foo", &test.NewExtendedDaemonSetOptions{Status: tt.oldStatus})
+			got := NewEvent(daemonset, tt.newStatus, time.Now())
+			switch {
+			case got == nil && tt.wantSummary != "":
+				t.Errorf("NewEvent() = nil, want summary %q", tt.wantSummary)
+			case got != nil && got.Summary() != tt.wantSummary:
+				t.Errorf("NewEvent().Summary() = %q, want %q", got.Summary(), tt.wantSummary)
+			}
+		})
+	}
+}
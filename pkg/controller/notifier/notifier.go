@@ -0,0 +1,329 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+var log = logf.Log.WithName("notifier")
+
+const (
+	defaultQueueSize         = 100
+	defaultDedupWindow       = 10 * time.Minute
+	defaultRateLimitInterval = time.Minute
+	defaultRateLimitBurst    = 5
+	defaultTimeout           = 10 * time.Second
+	// defaultWorkerIdleTimeout duration after which the worker of a sink without notification stops
+	defaultWorkerIdleTimeout = 10 * time.Minute
+)
+
+// Sink notification sink
+type Sink struct {
+	Type     datadoghqv1alpha1.ExtendedDaemonSetNotificationType
+	URL      string
+	Template string
+}
+
+// Options use to provides Notifier creation options
+type Options struct {
+	// Sinks notified of the state transitions of all the ExtendedDaemonSets
+	Sinks []Sink
+	// DedupWindow the duration during which an identical notification is not sent again to a sink
+	DedupWindow time.Duration
+	// RateLimitInterval and RateLimitBurst: a sink receives at most RateLimitBurst notifications
+	// for an ExtendedDaemonSet, then one notification every RateLimitInterval
+	RateLimitInterval time.Duration
+	RateLimitBurst    int
+	// Backoff used to retry the failed notifications
+	Backoff wait.Backoff
+}
+
+// DefaultOptions returns the default Notifier Options
+func DefaultOptions() Options {
+	return Options{
+		DedupWindow:       defaultDedupWindow,
+		RateLimitInterval: defaultRateLimitInterval,
+		RateLimitBurst:    defaultRateLimitBurst,
+		Backoff: wait.Backoff{
+			Duration: time.Second,
+			Factor:   2,
+			Jitter:   0.1,
+			Steps:    5,
+		},
+	}
+}
+
+type notification struct {
+	event *Event
+	sinks []Sink
+}
+
+// Notifier sends the ExtendedDaemonSet state transitions to the notification sinks.
+// The notifications are dispatched by the Start loop to one worker by sink, so a sink
+// unavailable or retrying doesn't delay the notifications of the other sinks.
+type Notifier struct {
+	options     Options
+	httpClient  *http.Client
+	queue       chan notification
+	now         func() time.Time
+	idleTimeout time.Duration
+
+	mutex        sync.Mutex
+	lastSent     map[string]time.Time
+	rateLimiters map[string]*rateLimiter
+
+	workersMutex sync.Mutex
+	workers      map[Sink]chan *Event
+	workersWg    sync.WaitGroup
+}
+
+// rateLimiter rate limits the notifications of a sink for an ExtendedDaemonSet
+type rateLimiter struct {
+	flowcontrol.RateLimiter
+	lastUsed time.Time
+}
+
+var defaultNotifier *Notifier
+
+// SetDefault sets the Notifier used by the controllers.
+// It should be called before the controllers are created.
+func SetDefault(n *Notifier) {
+	defaultNotifier = n
+}
+
+// Default returns the Notifier used by the controllers, nil if the notifications are disabled
+func Default() *Notifier {
+	return defaultNotifier
+}
+
+// New returns a new Notifier instance
+func New(options Options) *Notifier {
+	return &Notifier{
+		options:      options,
+		httpClient:   &http.Client{Timeout: defaultTimeout},
+		queue:        make(chan notification, defaultQueueSize),
+		now:          time.Now,
+		idleTimeout:  defaultWorkerIdleTimeout,
+		lastSent:     map[string]time.Time{},
+		rateLimiters: map[string]*rateLimiter{},
+		workers:      map[Sink]chan *Event{},
+	}
+}
+
+// Notify queues the event for the sinks and the globally configured sinks.
+// The event is dropped if the queue is full. It is safe to call on a nil Notifier.
+func (n *Notifier) Notify(event *Event, sinks []Sink) {
+	if n == nil || event == nil {
+		return
+	}
+	allSinks := append(append([]Sink{}, n.options.Sinks...), sinks...)
+	if len(allSinks) == 0 {
+		return
+	}
+	select {
+	case n.queue <- notification{event: event, sinks: allSinks}:
+	default:
+		log.Info("Notification queue full, notification dropped", "namespace", event.Namespace, "name", event.Name, "summary", event.Summary())
+	}
+}
+
+// Start dispatches the queued notifications to the sink workers until the stop channel is closed,
+// then waits for the workers to stop. Implements the manager.Runnable interface.
+func (n *Notifier) Start(stop <-chan struct{}) error {
+	defer n.workersWg.Wait()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case notif := <-n.queue:
+			for _, sink := range notif.sinks {
+				n.dispatch(notif.event, sink, stop)
+			}
+		}
+	}
+}
+
+// dispatch queues the event for the worker of the sink, started if needed.
+// The event is dropped if the worker queue is full.
+func (n *Notifier) dispatch(event *Event, sink Sink, stop <-chan struct{}) {
+	n.workersMutex.Lock()
+	defer n.workersMutex.Unlock()
+
+	queue, found := n.workers[sink]
+	if !found {
+		queue = make(chan *Event, defaultQueueSize)
+		n.workers[sink] = queue
+		n.workersWg.Add(1)
+		go n.runWorker(sink, queue, stop)
+	}
+	select {
+	case queue <- event:
+	default:
+		log.Info("Notification sink queue full, notification dropped", "namespace", event.Namespace, "name", event.Name, "type", sink.Type, "summary", event.Summary())
+	}
+}
+
+// runWorker sends the events queued for the sink until the stop channel is closed,
+// or until the sink doesn't receive any notification during the idle timeout.
+func (n *Notifier) runWorker(sink Sink, queue chan *Event, stop <-chan struct{}) {
+	defer n.workersWg.Done()
+	idle := time.NewTimer(n.idleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-queue:
+			if err := n.send(event, sink, stop); err != nil {
+				log.Error(err, "Unable to send notification", "namespace", event.Namespace, "name", event.Name, "type", sink.Type)
+			}
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(n.idleTimeout)
+		case <-idle.C:
+			n.workersMutex.Lock()
+			// an event may have been dispatched since the timer fired
+			if len(queue) > 0 {
+				n.workersMutex.Unlock()
+				idle.Reset(n.idleTimeout)
+				continue
+			}
+			delete(n.workers, sink)
+			n.workersMutex.Unlock()
+			return
+		}
+	}
+}
+
+// send sends the event to the sink, unless it is a duplicate or the sink is rate limited.
+// The failed requests are retried with the options backoff until the stop channel is closed.
+func (n *Notifier) send(event *Event, sink Sink, stop <-chan struct{}) error {
+	if !n.accept(event, sink) {
+		return nil
+	}
+
+	message, err := event.render(sink.Template)
+	if err != nil {
+		log.Error(err, "Invalid notification template, default template used", "namespace", event.Namespace, "name", event.Name)
+		if message, err = event.render(DefaultTemplate); err != nil {
+			return err
+		}
+	}
+
+	payload, err := newPayload(event, sink, message)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.options.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, postErr := n.post(sink.URL, payload)
+		if postErr == nil || !retry {
+			return postErr
+		}
+		if attempt >= n.options.Backoff.Steps {
+			return fmt.Errorf("notification not sent after %d attempts, last error: %v", attempt, postErr)
+		}
+		delay := time.NewTimer(backoff.Step())
+		select {
+		case <-stop:
+			delay.Stop()
+			return fmt.Errorf("notification not sent, notifier stopped after %d attempts, last error: %v", attempt, postErr)
+		case <-delay.C:
+		}
+	}
+}
+
+// accept returns false if the same event was sent to the sink during the dedup window,
+// or if the sink is rate limited for the ExtendedDaemonSet
+func (n *Notifier) accept(event *Event, sink Sink) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	now := n.now()
+	for key, sent := range n.lastSent {
+		if now.Sub(sent) >= n.options.DedupWindow {
+			delete(n.lastSent, key)
+		}
+	}
+	// a rate limiter idle for longer than the dedup window and the refill time of its burst is full again, so it is evicted
+	rateLimiterTTL := n.options.DedupWindow
+	if refill := time.Duration(n.options.RateLimitBurst) * n.options.RateLimitInterval; refill > rateLimiterTTL {
+		rateLimiterTTL = refill
+	}
+	for key, limiter := range n.rateLimiters {
+		if now.Sub(limiter.lastUsed) > rateLimiterTTL {
+			delete(n.rateLimiters, key)
+		}
+	}
+	dedupKey := fmt.Sprintf("%s %s", sink.URL, event.dedupKey())
+	if _, found := n.lastSent[dedupKey]; found {
+		log.V(1).Info("Duplicated notification dropped", "namespace", event.Namespace, "name", event.Name, "summary", event.Summary())
+		return false
+	}
+
+	rateLimitKey := fmt.Sprintf("%s %s/%s", sink.URL, event.Namespace, event.Name)
+	limiter, found := n.rateLimiters[rateLimitKey]
+	if !found {
+		limiter = &rateLimiter{
+			RateLimiter: flowcontrol.NewTokenBucketRateLimiter(float32(time.Second)/float32(n.options.RateLimitInterval), n.options.RateLimitBurst),
+		}
+		n.rateLimiters[rateLimitKey] = limiter
+	}
+	limiter.lastUsed = now
+	if !limiter.TryAccept() {
+		log.Info("Notification rate limited, notification dropped", "namespace", event.Namespace, "name", event.Name, "summary", event.Summary())
+		return false
+	}
+
+	n.lastSent[dedupKey] = now
+	return true
+}
+
+// post posts the payload to the url. Returns true if the request can be retried.
+func (n *Notifier) post(url string, payload []byte) (bool, error) {
+	resp, err := n.httpClient.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook responded with status code %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// newPayload returns the JSON payload of the notification for the sink type
+func newPayload(event *Event, sink Sink, message string) ([]byte, error) {
+	switch sink.Type {
+	case datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack:
+		return json.Marshal(struct {
+			Text string `json:"text"`
+		}{Text: message})
+	case datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeWebhook:
+		return json.Marshal(struct {
+			Text  string `json:"text"`
+			Event *Event `json:"event"`
+		}{Text: message, Event: event})
+	default:
+		return nil, fmt.Errorf("unknown notification sink type: %q", sink.Type)
+	}
+}
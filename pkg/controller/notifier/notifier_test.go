@@ -0,0 +1,294 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package notifier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+type fakeWebhook struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	statuses []int
+	bodies   []string
+}
+
+func newFakeWebhook(statuses ...int) *fakeWebhook {
+	w := &fakeWebhook{statuses: statuses}
+	w.server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.mutex.Lock()
+		w.bodies = append(w.bodies, string(body))
+		status := http.StatusOK
+		if len(w.statuses) > 0 {
+			status, w.statuses = w.statuses[0], w.statuses[1:]
+		}
+		w.mutex.Unlock()
+		rw.WriteHeader(status)
+	}))
+	return w
+}
+
+func (w *fakeWebhook) received() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return append([]string(nil), w.bodies...)
+}
+
+func newTestNotifier(now time.Time) *Notifier {
+	options := DefaultOptions()
+	options.Backoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+	n := New(options)
+	n.now = func() time.Time { return now }
+	return n
+}
+
+func newPausedEvent() *Event {
+	return &Event{
+		Namespace:        "bar",
+		Name:             "foo",
+		PreviousState:    datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanary,
+		State:            datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused,
+		Reason:           datadoghqv1alpha1.ExtendedDaemonSetStatusReasonCLB,
+		CanaryReplicaSet: "foo-2",
+	}
+}
+
+func TestNotifier_send(t *testing.T) {
+	tests := []struct {
+		name       string
+		sink       Sink
+		statuses   []int
+		wantErr    bool
+		wantBodies []string
+	}{
+		{
+			name:       "slack",
+			sink:       Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack},
+			wantBodies: []string{`{"text":"ExtendedDaemonSet bar/foo: canary deployment of foo-2 paused, reason: CrashLoopBackOff"}`},
+		},
+		{
+			name: "webhook with template",
+			sink: Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeWebhook, Template: "{{ .Name }} is {{ .State }}"},
+			wantBodies: []string{
+				`{"text":"foo is Canary Paused","event":{"namespace":"bar","name":"foo","previousState":"Canary","state":"Canary Paused","reason":"CrashLoopBackOff","canaryReplicaSet":"foo-2","percentComplete":0,"time":"0001-01-01T00:00:00Z"}}`,
+			},
+		},
+		{
+			name:       "invalid template",
+			sink:       Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, Template: "{{ .Unknown }}"},
+			wantBodies: []string{`{"text":"ExtendedDaemonSet bar/foo: canary deployment of foo-2 paused, reason: CrashLoopBackOff"}`},
+		},
+		{
+			name:     "retry on server error",
+			sink:     Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, Template: "paused"},
+			statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			wantBodies: []string{
+				`{"text":"paused"}`,
+				`{"text":"paused"}`,
+				`{"text":"paused"}`,
+			},
+		},
+		{
+			name:       "no retry on client error",
+			sink:       Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, Template: "paused"},
+			statuses:   []int{http.StatusNotFound},
+			wantErr:    true,
+			wantBodies: []string{`{"text":"paused"}`},
+		},
+		{
+			name:       "too many failures",
+			sink:       Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, Template: "paused"},
+			statuses:   []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			wantErr:    true,
+			wantBodies: []string{`{"text":"paused"}`, `{"text":"paused"}`, `{"text":"paused"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := newFakeWebhook(tt.statuses...)
+			defer webhook.server.Close()
+
+			n := newTestNotifier(time.Now())
+			tt.sink.URL = webhook.server.URL
+			if err := n.send(newPausedEvent(), tt.sink, nil); (err != nil) != tt.wantErr {
+				t.Errorf("Notifier.send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantBodies, webhook.bodies); diff != "" {
+				t.Errorf("Notifier.send() bodies mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNotifier_dedupAndRateLimit(t *testing.T) {
+	webhook := newFakeWebhook()
+	defer webhook.server.Close()
+	sink := Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: webhook.server.URL, Template: "{{ .State }}"}
+
+	now := time.Now()
+	n := newTestNotifier(now)
+	n.options.RateLimitBurst = 3
+
+	resumed := newPausedEvent()
+	resumed.PreviousState, resumed.State = resumed.State, datadoghqv1alpha1.ExtendedDaemonSetStatusStateCanary
+
+	// flapping canary: the duplicated notifications are dropped
+	for _, event := range []*Event{newPausedEvent(), resumed, newPausedEvent(), resumed} {
+		if err := n.send(event, sink, nil); err != nil {
+			t.Fatalf("Notifier.send() error = %v", err)
+		}
+	}
+	// after the dedup window, the rate limit drops the notifications above the burst
+	n.now = func() time.Time { return now.Add(n.options.DedupWindow) }
+	for _, event := range []*Event{newPausedEvent(), resumed} {
+		if err := n.send(event, sink, nil); err != nil {
+			t.Fatalf("Notifier.send() error = %v", err)
+		}
+	}
+
+	want := []string{`{"text":"Canary Paused"}`, `{"text":"Canary"}`, `{"text":"Canary Paused"}`}
+	if diff := cmp.Diff(want, webhook.bodies); diff != "" {
+		t.Errorf("bodies mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNotifier_rateLimitersEviction(t *testing.T) {
+	webhook := newFakeWebhook()
+	defer webhook.server.Close()
+	sink := Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: webhook.server.URL}
+
+	now := time.Now()
+	n := newTestNotifier(now)
+	if err := n.send(newPausedEvent(), sink, nil); err != nil {
+		t.Fatalf("Notifier.send() error = %v", err)
+	}
+
+	other := newPausedEvent()
+	other.Name = "other"
+	n.now = func() time.Time { return now.Add(n.options.DedupWindow + time.Second) }
+	if err := n.send(other, sink, nil); err != nil {
+		t.Fatalf("Notifier.send() error = %v", err)
+	}
+
+	want := []string{fmt.Sprintf("%s bar/other", webhook.server.URL)}
+	var got []string
+	for key := range n.rateLimiters {
+		got = append(got, key)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("rate limiters mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNotifier_Start(t *testing.T) {
+	webhook := newFakeWebhook()
+	defer webhook.server.Close()
+	edsWebhook := newFakeWebhook()
+	defer edsWebhook.server.Close()
+
+	options := DefaultOptions()
+	options.Sinks = []Sink{{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: webhook.server.URL, Template: "global"}}
+	n := New(options)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		_ = n.Start(stop)
+		close(done)
+	}()
+	n.Notify(newPausedEvent(), []Sink{{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: edsWebhook.server.URL, Template: "eds"}})
+	n.Notify(nil, nil)
+
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return len(webhook.received()) > 0 && len(edsWebhook.received()) > 0, nil
+	}); err != nil {
+		t.Fatalf("notification not processed: %v", err)
+	}
+	close(stop)
+	<-done
+
+	if diff := cmp.Diff([]string{`{"text":"global"}`}, webhook.received()); diff != "" {
+		t.Errorf("global sink bodies mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{`{"text":"eds"}`}, edsWebhook.received()); diff != "" {
+		t.Errorf("ExtendedDaemonSet sink bodies mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNotifier_StartUnavailableSink(t *testing.T) {
+	unavailable := newFakeWebhook(http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	defer unavailable.server.Close()
+	webhook := newFakeWebhook()
+	defer webhook.server.Close()
+
+	options := DefaultOptions()
+	options.Backoff = wait.Backoff{Duration: time.Hour, Factor: 1, Steps: 3}
+	n := New(options)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		_ = n.Start(stop)
+		close(done)
+	}()
+	// the sink in backoff doesn't delay the notifications of the other sink
+	n.Notify(newPausedEvent(), []Sink{
+		{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: unavailable.server.URL, Template: "unavailable"},
+		{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: webhook.server.URL, Template: "available"},
+	})
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return len(webhook.received()) > 0 && len(unavailable.received()) > 0, nil
+	}); err != nil {
+		t.Fatalf("notification not processed: %v", err)
+	}
+
+	// the backoff wait is interrupted by the stop
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Notifier.Start() didn't return during the backoff")
+	}
+	if diff := cmp.Diff([]string{`{"text":"unavailable"}`}, unavailable.received()); diff != "" {
+		t.Errorf("unavailable sink bodies mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNotifier_idleWorker(t *testing.T) {
+	webhook := newFakeWebhook()
+	defer webhook.server.Close()
+	sink := Sink{Type: datadoghqv1alpha1.ExtendedDaemonSetNotificationTypeSlack, URL: webhook.server.URL}
+
+	n := newTestNotifier(time.Now())
+	n.idleTimeout = 10 * time.Millisecond
+	stop := make(chan struct{})
+	defer close(stop)
+
+	n.dispatch(newPausedEvent(), sink, stop)
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		n.workersMutex.Lock()
+		defer n.workersMutex.Unlock()
+		return len(n.workers) == 0, nil
+	}); err != nil {
+		t.Fatalf("idle worker not stopped: %v", err)
+	}
+	if diff := cmp.Diff([]string{`{"text":"ExtendedDaemonSet bar/foo: canary deployment of foo-2 paused, reason: CrashLoopBackOff"}`}, webhook.received()); diff != "" {
+		t.Errorf("bodies mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -18,6 +18,8 @@ type NewNodeOptions struct {
 	Labels        map[string]string
 	Conditions    []corev1.NodeCondition
 	Unschedulable bool
+	Allocatable   corev1.ResourceList
+	Capacity      corev1.ResourceList
 }
 
 // NewNode returns new node instance
@@ -48,6 +50,8 @@ func NewNode(name string, opts *NewNodeOptions) *corev1.Node {
 
 		node.Spec.Unschedulable = opts.Unschedulable
 		node.Status.Conditions = append(node.Status.Conditions, opts.Conditions...)
+		node.Status.Allocatable = opts.Allocatable
+		node.Status.Capacity = opts.Capacity
 	}
 	return node
 }
@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package comparison
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// maxOtherChanges maximum number of other changed paths listed in a change cause
+const maxOtherChanges = 5
+
+var containerPathRegexp = regexp.MustCompile(`^spec\.(initContainers|containers)\[([^\]]+)\](?:\.(image|env|resources)\b)?`)
+
+// ChangeCause returns a concise summary of the changes between the two pod templates: the images, the environment
+// variables and the resources changed by container, the containers added or removed, and the other changed paths.
+// For example "image daemon: foo:1.0 -> foo:1.1; env daemon: LOG_LEVEL; other: spec.volumes[config]".
+// It returns an empty string if the templates are equal.
+func ChangeCause(oldTpl, newTpl *corev1.PodTemplateSpec) (string, error) {
+	paths, err := DiffPodTemplateSpec(oldTpl, newTpl)
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	oldContainers := containersByName(NormalizePodTemplateSpec(oldTpl))
+	newContainers := containersByName(NormalizePodTemplateSpec(newTpl))
+
+	var causes, others []string
+	seen := map[string]bool{}
+	addCause := func(key string, cause func() string) {
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		if value := cause(); value != "" {
+			causes = append(causes, value)
+		}
+	}
+	for _, path := range paths {
+		match := containerPathRegexp.FindStringSubmatch(path)
+		if match == nil {
+			others = append(others, path)
+			continue
+		}
+		name, field := match[2], match[3]
+		oldContainer, newContainer := oldContainers[name], newContainers[name]
+		switch {
+		case oldContainer == nil:
+			addCause("added:"+name, func() string { return fmt.Sprintf("container added: %s", name) })
+		case newContainer == nil:
+			addCause("removed:"+name, func() string { return fmt.Sprintf("container removed: %s", name) })
+		case field == "image":
+			addCause("image:"+name, func() string {
+				return fmt.Sprintf("image %s: %s -> %s", name, oldContainer.Image, newContainer.Image)
+			})
+		case field == "env":
+			addCause("env:"+name, func() string {
+				return fmt.Sprintf("env %s: %s", name, strings.Join(diffEnvNames(oldContainer.Env, newContainer.Env), ", "))
+			})
+		case field == "resources":
+			addCause("resources:"+name, func() string {
+				changes := diffResources(&oldContainer.Resources, &newContainer.Resources)
+				if len(changes) == 0 {
+					// only the quantities format changed
+					return ""
+				}
+				return fmt.Sprintf("resources %s: %s", name, strings.Join(changes, ", "))
+			})
+		default:
+			others = append(others, path)
+		}
+	}
+	if len(others) > maxOtherChanges {
+		others = append(others[:maxOtherChanges], "...")
+	}
+	if len(others) > 0 {
+		causes = append(causes, fmt.Sprintf("other: %s", strings.Join(others, ", ")))
+	}
+	return strings.Join(causes, "; "), nil
+}
+
+func containersByName(tpl *corev1.PodTemplateSpec) map[string]*corev1.Container {
+	containers := make(map[string]*corev1.Container, len(tpl.Spec.InitContainers)+len(tpl.Spec.Containers))
+	for i := range tpl.Spec.InitContainers {
+		containers[tpl.Spec.InitContainers[i].Name] = &tpl.Spec.InitContainers[i]
+	}
+	for i := range tpl.Spec.Containers {
+		containers[tpl.Spec.Containers[i].Name] = &tpl.Spec.Containers[i]
+	}
+	return containers
+}
+
+// diffEnvNames returns the sorted names of the environment variables added, removed or changed
+func diffEnvNames(oldEnv, newEnv []corev1.EnvVar) []string {
+	oldByName := make(map[string]corev1.EnvVar, len(oldEnv))
+	for _, env := range oldEnv {
+		oldByName[env.Name] = env
+	}
+	changed := map[string]bool{}
+	for _, env := range newEnv {
+		if oldValue, found := oldByName[env.Name]; !found || !reflect.DeepEqual(oldValue, env) {
+			changed[env.Name] = true
+		}
+		delete(oldByName, env.Name)
+	}
+	for name := range oldByName {
+		changed[name] = true
+	}
+	names := make([]string, 0, len(changed))
+	for name := range changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffResources returns the changed resources requests and limits, for example "limits.cpu 100m -> 200m".
+// A missing value is displayed as "none".
+func diffResources(oldResources, newResources *corev1.ResourceRequirements) []string {
+	var changes []string
+	for _, kind := range []struct {
+		name     string
+		old, new corev1.ResourceList
+	}{
+		{"requests", oldResources.Requests, newResources.Requests},
+		{"limits", oldResources.Limits, newResources.Limits},
+	} {
+		names := map[string]bool{}
+		for name := range kind.old {
+			names[string(name)] = true
+		}
+		for name := range kind.new {
+			names[string(name)] = true
+		}
+		sortedNames := make([]string, 0, len(names))
+		for name := range names {
+			sortedNames = append(sortedNames, name)
+		}
+		sort.Strings(sortedNames)
+		for _, name := range sortedNames {
+			oldValue, oldFound := kind.old[corev1.ResourceName(name)]
+			newValue, newFound := kind.new[corev1.ResourceName(name)]
+			if oldFound == newFound && oldValue.Cmp(newValue) == 0 {
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("%s.%s %s -> %s", kind.name, name, quantityString(oldValue, oldFound), quantityString(newValue, newFound)))
+		}
+	}
+	return changes
+}
+
+func quantityString(value resource.Quantity, found bool) string {
+	if !found {
+		return "none"
+	}
+	return value.String()
+}
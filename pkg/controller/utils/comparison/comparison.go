@@ -8,26 +8,36 @@ package comparison
 import (
 	"bytes"
 	"crypto/md5" // #nosec
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/rand"
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 )
 
 // IsReplicaSetUpToDate returns true if the ExtendedDaemonSetReplicaSet is up to date with the ExtendedDaemonSet pod template.
+// The templates are compared once normalised, so the fields defaulted by the API server or by a mutating webhook
+// don't trigger a new ExtendedDaemonSetReplicaSet.
 func IsReplicaSetUpToDate(rs *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, daemonset *datadoghqv1alpha1.ExtendedDaemonSet) bool {
-	hash, err := GenerateMD5PodTemplateSpec(&daemonset.Spec.Template)
-	if err != nil {
-		return false
+	// the ExtendedDaemonSetReplicaSets created by the previous versions of the controller are identified by the MD5 hash of the template
+	if hash, err := GenerateMD5PodTemplateSpec(&daemonset.Spec.Template); err == nil && ComparePodTemplateSpecMD5Hash(hash, rs) {
+		return true
 	}
+	return IsPodTemplateSpecEqual(&rs.Spec.Template, &daemonset.Spec.Template)
+}
 
-	return ComparePodTemplateSpecMD5Hash(hash, rs)
+// IsPodTemplateSpecEqual returns true if the two pod templates are semantically equal once normalised
+func IsPodTemplateSpecEqual(tpl1, tpl2 *corev1.PodTemplateSpec) bool {
+	return apiequality.Semantic.DeepEqual(NormalizePodTemplateSpec(tpl1), NormalizePodTemplateSpec(tpl2))
 }
 
 // ComparePodTemplateSpecMD5Hash used to compare a md5 hash with the one setted in Deployment annotation
@@ -38,7 +48,8 @@ func ComparePodTemplateSpecMD5Hash(hash string, rs *datadoghqv1alpha1.ExtendedDa
 	return false
 }
 
-// GenerateMD5PodTemplateSpec used to generate the DeploymentSpec MD5 hash
+// GenerateMD5PodTemplateSpec used to generate the DeploymentSpec MD5 hash.
+// It is the template hash of the ExtendedDaemonSetReplicaSets created by the previous versions of the controller.
 func GenerateMD5PodTemplateSpec(tpl *corev1.PodTemplateSpec) (string, error) {
 	b, err := json.Marshal(tpl)
 	if err != nil {
@@ -53,17 +64,34 @@ func GenerateMD5PodTemplateSpec(tpl *corev1.PodTemplateSpec) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// SetMD5PodTemplateSpecAnnotation used to set the md5 annotation key/value from the ExtendedDaemonSetReplicaSet.Spec.Template
-func SetMD5PodTemplateSpecAnnotation(rs *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, daemonset *datadoghqv1alpha1.ExtendedDaemonSet) (string, error) {
-	md5Spec, err := GenerateMD5PodTemplateSpec(&daemonset.Spec.Template)
+// GeneratePodTemplateSpecHash used to generate the FNV hash of the normalised pod template.
+// The collision count is added to the hash, to get a new hash if it collides with the hash of another template.
+func GeneratePodTemplateSpecHash(tpl *corev1.PodTemplateSpec, collisionCount *int32) (string, error) {
+	b, err := json.Marshal(NormalizePodTemplateSpec(tpl))
+	if err != nil {
+		return "", err
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write(b)
+	if collisionCount != nil {
+		collisionCountBytes := make([]byte, 8)
+		binary.LittleEndian.PutUint32(collisionCountBytes, uint32(*collisionCount))
+		_, _ = hash.Write(collisionCountBytes)
+	}
+	return rand.SafeEncodeString(fmt.Sprint(hash.Sum32())), nil
+}
+
+// SetPodTemplateSpecHashAnnotation used to set the template hash annotation key/value from the ExtendedDaemonSetReplicaSet.Spec.Template
+func SetPodTemplateSpecHashAnnotation(rs *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, daemonset *datadoghqv1alpha1.ExtendedDaemonSet) (string, error) {
+	hash, err := GeneratePodTemplateSpecHash(&daemonset.Spec.Template, daemonset.Status.CollisionCount)
 	if err != nil {
-		return "", fmt.Errorf("unable to generates the JobSpec MD5, %v", err)
+		return "", fmt.Errorf("unable to generates the PodTemplateSpec hash, %v", err)
 	}
 	if rs.Annotations == nil {
 		rs.SetAnnotations(map[string]string{})
 	}
-	rs.Annotations[string(datadoghqv1alpha1.MD5ExtendedDaemonSetAnnotationKey)] = md5Spec
-	return md5Spec, nil
+	rs.Annotations[string(datadoghqv1alpha1.MD5ExtendedDaemonSetAnnotationKey)] = hash
+	return hash, nil
 }
 
 // StringsContains contains tells whether a contains x.
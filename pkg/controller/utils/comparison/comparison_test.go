@@ -5,7 +5,16 @@
 
 package comparison
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+)
 
 func TestGenerateHashFromEDSResourceNodeAnnotation(t *testing.T) {
 	type args struct {
@@ -66,3 +75,193 @@ func TestGenerateHashFromEDSResourceNodeAnnotation(t *testing.T) {
 		})
 	}
 }
+
+func newPodTemplate(image string) *corev1.PodTemplateSpec {
+	return &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "daemon",
+					Image: image,
+					Ports: []corev1.ContainerPort{{ContainerPort: 8125}},
+					ReadinessProbe: &corev1.Probe{
+						Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/ready"}},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+			},
+		},
+	}
+}
+
+// newDefaultedPodTemplate returns the template as defaulted by the API server
+func newDefaultedPodTemplate(image string) *corev1.PodTemplateSpec {
+	tpl := newPodTemplate(image)
+	period := int64(30)
+	mode := int32(0644)
+	tpl.Spec.TerminationGracePeriodSeconds = &period
+	tpl.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	tpl.Spec.DNSPolicy = corev1.DNSClusterFirst
+	tpl.Spec.SchedulerName = corev1.DefaultSchedulerName
+	tpl.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	tpl.Spec.Volumes[0].ConfigMap.DefaultMode = &mode
+	container := &tpl.Spec.Containers[0]
+	container.ImagePullPolicy = corev1.PullIfNotPresent
+	container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+	container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+	container.Ports[0].Protocol = corev1.ProtocolTCP
+	container.ReadinessProbe.Handler.HTTPGet.Scheme = corev1.URISchemeHTTP
+	container.ReadinessProbe.TimeoutSeconds = 1
+	container.ReadinessProbe.PeriodSeconds = 10
+	container.ReadinessProbe.SuccessThreshold = 1
+	container.ReadinessProbe.FailureThreshold = 3
+	return tpl
+}
+
+func TestIsReplicaSetUpToDate(t *testing.T) {
+	daemonset := test.NewExtendedDaemonSet("bar", "foo", nil)
+	daemonset.Spec.Template = *newPodTemplate("pause:3.1")
+	legacyHash, _ := GenerateMD5PodTemplateSpec(&daemonset.Spec.Template)
+
+	newRS := func(tpl *corev1.PodTemplateSpec, hash string) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet {
+		rs := test.NewExtendedDaemonSetReplicaSet("bar", "foo-1", &test.NewExtendedDaemonSetReplicaSetOptions{
+			Annotations: map[string]string{datadoghqv1alpha1.MD5ExtendedDaemonSetAnnotationKey: hash},
+		})
+		rs.Spec.Template = *tpl
+		return rs
+	}
+
+	tests := []struct {
+		name string
+		rs   *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet
+		want bool
+	}{
+		{
+			name: "same template",
+			rs:   newRS(newPodTemplate("pause:3.1"), "other"),
+			want: true,
+		},
+		{
+			name: "replicaset created with the legacy MD5 hash",
+			rs:   newRS(&corev1.PodTemplateSpec{}, legacyHash),
+			want: true,
+		},
+		{
+			name: "template only differs by defaulted fields",
+			rs:   newRS(newDefaultedPodTemplate("pause:3.1"), "other"),
+			want: true,
+		},
+		{
+			name: "template changed",
+			rs:   newRS(newDefaultedPodTemplate("pause:3.2"), "other"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsReplicaSetUpToDate(tt.rs, daemonset); got != tt.want {
+				t.Errorf("IsReplicaSetUpToDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePodTemplateSpecHash(t *testing.T) {
+	hash, _ := GeneratePodTemplateSpecHash(newPodTemplate("pause:3.1"), nil)
+	if defaultedHash, _ := GeneratePodTemplateSpecHash(newDefaultedPodTemplate("pause:3.1"), nil); defaultedHash != hash {
+		t.Errorf("GeneratePodTemplateSpecHash() of the defaulted template = %s, want %s", defaultedHash, hash)
+	}
+	if changedHash, _ := GeneratePodTemplateSpecHash(newPodTemplate("pause:3.2"), nil); changedHash == hash {
+		t.Errorf("GeneratePodTemplateSpecHash() of a changed template = %s, want a different hash", changedHash)
+	}
+	collisionCount := int32(1)
+	if collisionHash, _ := GeneratePodTemplateSpecHash(newPodTemplate("pause:3.1"), &collisionCount); collisionHash == hash {
+		t.Errorf("GeneratePodTemplateSpecHash() with a collision count = %s, want a different hash", collisionHash)
+	}
+}
+
+func TestDiffPodTemplateSpec(t *testing.T) {
+	withEnv := newPodTemplate("pause:3.2")
+	withEnv.Labels = map[string]string{"app": "foo"}
+	withEnv.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "FOO", Value: "bar"}}
+
+	tests := []struct {
+		name   string
+		oldTpl *corev1.PodTemplateSpec
+		newTpl *corev1.PodTemplateSpec
+		want   []string
+	}{
+		{
+			name:   "only defaulted fields",
+			oldTpl: newPodTemplate("pause:3.1"),
+			newTpl: newDefaultedPodTemplate("pause:3.1"),
+		},
+		{
+			name:   "image and env changed, label added",
+			oldTpl: newDefaultedPodTemplate("pause:3.1"),
+			newTpl: withEnv,
+			want:   []string{"metadata.labels", "spec.containers[daemon].env", "spec.containers[daemon].image"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffPodTemplateSpec(tt.oldTpl, tt.newTpl)
+			if err != nil {
+				t.Fatalf("DiffPodTemplateSpec() error = %v", err)
+			}
+			if len(got)+len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffPodTemplateSpec() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeCause(t *testing.T) {
+	withChanges := newPodTemplate("pause:3.2")
+	withChanges.Labels = map[string]string{"app": "foo"}
+	withChanges.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "FOO", Value: "bar"}, {Name: "BAR", Value: "foo"}}
+	withChanges.Spec.Containers[0].Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}
+	withChanges.Spec.Containers = append(withChanges.Spec.Containers, corev1.Container{Name: "sidecar", Image: "sidecar:1.0"})
+
+	withResources := newPodTemplate("pause:3.1")
+	withResources.Spec.Containers[0].Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}
+	withResources.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}
+
+	tests := []struct {
+		name   string
+		oldTpl *corev1.PodTemplateSpec
+		newTpl *corev1.PodTemplateSpec
+		want   string
+	}{
+		{
+			name:   "only defaulted fields",
+			oldTpl: newPodTemplate("pause:3.1"),
+			newTpl: newDefaultedPodTemplate("pause:3.1"),
+		},
+		{
+			name:   "image, env and resources changed, container and label added",
+			oldTpl: newDefaultedPodTemplate("pause:3.1"),
+			newTpl: withChanges,
+			want:   "env daemon: BAR, FOO; image daemon: pause:3.1 -> pause:3.2; resources daemon: limits.cpu none -> 200m; container added: sidecar; other: metadata.labels",
+		},
+		{
+			name:   "resources changed",
+			oldTpl: withResources,
+			newTpl: withChanges,
+			want:   "env daemon: BAR, FOO; image daemon: pause:3.1 -> pause:3.2; resources daemon: requests.memory 64Mi -> none, limits.cpu 100m -> 200m; container added: sidecar; other: metadata.labels",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ChangeCause(tt.oldTpl, tt.newTpl)
+			if err != nil {
+				t.Fatalf("ChangeCause() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ChangeCause() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package comparison
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DiffPodTemplateSpec returns the paths of the fields that differ between the two normalised pod templates,
+// for example "spec.containers[daemon].image". The list elements with a name are matched by name.
+func DiffPodTemplateSpec(oldTpl, newTpl *corev1.PodTemplateSpec) ([]string, error) {
+	oldValue, err := toUnstructured(NormalizePodTemplateSpec(oldTpl))
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := toUnstructured(NormalizePodTemplateSpec(newTpl))
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	diffValues("", oldValue, newValue, &paths)
+	return paths, nil
+}
+
+func toUnstructured(tpl *corev1.PodTemplateSpec) (interface{}, error) {
+	b, err := json.Marshal(tpl)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(b, &value)
+	return value, err
+}
+
+func diffValues(path string, oldValue, newValue interface{}, paths *[]string) {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		for _, key := range mergedKeys(oldMap, newMap) {
+			diffValues(joinPath(path, key), oldMap[key], newMap[key], paths)
+		}
+		return
+	}
+
+	oldList, oldIsList := oldValue.([]interface{})
+	newList, newIsList := newValue.([]interface{})
+	if oldIsList && newIsList {
+		oldByName, oldNamed := indexByName(oldList)
+		newByName, newNamed := indexByName(newList)
+		switch {
+		case oldNamed && newNamed:
+			for _, name := range mergedKeys(oldByName, newByName) {
+				diffValues(fmt.Sprintf("%s[%s]", path, name), oldByName[name], newByName[name], paths)
+			}
+		case len(oldList) == len(newList):
+			for i := range oldList {
+				diffValues(fmt.Sprintf("%s[%d]", path, i), oldList[i], newList[i], paths)
+			}
+		default:
+			*paths = append(*paths, path)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*paths = append(*paths, path)
+	}
+}
+
+// indexByName returns the list elements by name, if all the elements have a name
+func indexByName(list []interface{}) (map[string]interface{}, bool) {
+	byName := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, ok := obj["name"].(string)
+		if !ok {
+			return nil, false
+		}
+		byName[name] = item
+	}
+	return byName, true
+}
+
+func mergedKeys(m1, m2 map[string]interface{}) []string {
+	keys := make([]string, 0, len(m1)+len(m2))
+	for key := range m1 {
+		keys = append(keys, key)
+	}
+	for key := range m2 {
+		if _, found := m1[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
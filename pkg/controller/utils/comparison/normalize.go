@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package comparison
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultTerminationGracePeriodSeconds = int64(corev1.DefaultTerminationGracePeriodSeconds)
+	defaultVolumeMode                    = corev1.ConfigMapVolumeSourceDefaultMode
+	defaultProbeTimeoutSeconds           = int32(1)
+	defaultProbePeriodSeconds            = int32(10)
+	defaultProbeSuccessThreshold         = int32(1)
+	defaultProbeFailureThreshold         = int32(3)
+)
+
+// NormalizePodTemplateSpec returns a copy of the pod template with the defaulted fields set, as the API server
+// would set them. Two templates that only differ by defaulted fields are equal once normalised.
+func NormalizePodTemplateSpec(tpl *corev1.PodTemplateSpec) *corev1.PodTemplateSpec {
+	normalized := tpl.DeepCopy()
+	spec := &normalized.Spec
+	if spec.DNSPolicy == "" {
+		spec.DNSPolicy = corev1.DNSClusterFirst
+	}
+	if spec.RestartPolicy == "" {
+		spec.RestartPolicy = corev1.RestartPolicyAlways
+	}
+	if spec.SchedulerName == "" {
+		spec.SchedulerName = corev1.DefaultSchedulerName
+	}
+	if spec.SecurityContext == nil {
+		spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	if spec.TerminationGracePeriodSeconds == nil {
+		period := defaultTerminationGracePeriodSeconds
+		spec.TerminationGracePeriodSeconds = &period
+	}
+	if spec.EnableServiceLinks == nil {
+		enableServiceLinks := corev1.DefaultEnableServiceLinks
+		spec.EnableServiceLinks = &enableServiceLinks
+	}
+	for i := range spec.InitContainers {
+		normalizeContainer(&spec.InitContainers[i], spec.HostNetwork)
+	}
+	for i := range spec.Containers {
+		normalizeContainer(&spec.Containers[i], spec.HostNetwork)
+	}
+	for i := range spec.Volumes {
+		normalizeVolume(&spec.Volumes[i])
+	}
+	return normalized
+}
+
+func normalizeContainer(container *corev1.Container, hostNetwork bool) {
+	if container.TerminationMessagePath == "" {
+		container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+	}
+	if container.TerminationMessagePolicy == "" {
+		container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+	}
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = defaultImagePullPolicy(container.Image)
+	}
+	for i := range container.Ports {
+		port := &container.Ports[i]
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if hostNetwork && port.HostPort == 0 {
+			port.HostPort = port.ContainerPort
+		}
+	}
+	for i := range container.Env {
+		if container.Env[i].ValueFrom != nil {
+			normalizeFieldRef(container.Env[i].ValueFrom.FieldRef)
+		}
+	}
+	normalizeProbe(container.LivenessProbe)
+	normalizeProbe(container.ReadinessProbe)
+	normalizeProbe(container.StartupProbe)
+	if container.Lifecycle != nil {
+		normalizeHandler(container.Lifecycle.PostStart)
+		normalizeHandler(container.Lifecycle.PreStop)
+	}
+}
+
+// defaultImagePullPolicy returns Always for the images without tag or with the latest tag, else IfNotPresent
+func defaultImagePullPolicy(image string) corev1.PullPolicy {
+	if strings.Contains(image, "@") {
+		return corev1.PullIfNotPresent
+	}
+	name := image
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		name = image[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i < 0 || name[i+1:] == "latest" {
+		return corev1.PullAlways
+	}
+	return corev1.PullIfNotPresent
+}
+
+func normalizeProbe(probe *corev1.Probe) {
+	if probe == nil {
+		return
+	}
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = defaultProbeTimeoutSeconds
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = defaultProbePeriodSeconds
+	}
+	if probe.SuccessThreshold == 0 {
+		probe.SuccessThreshold = defaultProbeSuccessThreshold
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = defaultProbeFailureThreshold
+	}
+	normalizeHandler(&probe.Handler)
+}
+
+func normalizeHandler(handler *corev1.Handler) {
+	if handler == nil || handler.HTTPGet == nil {
+		return
+	}
+	if handler.HTTPGet.Path == "" {
+		handler.HTTPGet.Path = "/"
+	}
+	if handler.HTTPGet.Scheme == "" {
+		handler.HTTPGet.Scheme = corev1.URISchemeHTTP
+	}
+}
+
+func normalizeFieldRef(fieldRef *corev1.ObjectFieldSelector) {
+	if fieldRef != nil && fieldRef.APIVersion == "" {
+		fieldRef.APIVersion = "v1"
+	}
+}
+
+func normalizeVolume(volume *corev1.Volume) {
+	defaultMode := func(mode **int32) {
+		if *mode == nil {
+			value := defaultVolumeMode
+			*mode = &value
+		}
+	}
+	switch {
+	case volume.ConfigMap != nil:
+		defaultMode(&volume.ConfigMap.DefaultMode)
+	case volume.Secret != nil:
+		defaultMode(&volume.Secret.DefaultMode)
+	case volume.Projected != nil:
+		defaultMode(&volume.Projected.DefaultMode)
+		for _, source := range volume.Projected.Sources {
+			if source.DownwardAPI != nil {
+				for i := range source.DownwardAPI.Items {
+					normalizeFieldRef(source.DownwardAPI.Items[i].FieldRef)
+				}
+			}
+		}
+	case volume.DownwardAPI != nil:
+		defaultMode(&volume.DownwardAPI.DefaultMode)
+		for i := range volume.DownwardAPI.Items {
+			normalizeFieldRef(volume.DownwardAPI.Items[i].FieldRef)
+		}
+	case volume.HostPath != nil:
+		if volume.HostPath.Type == nil {
+			hostPathType := corev1.HostPathUnset
+			volume.HostPath.Type = &hostPathType
+		}
+	case volume.VolumeSource == corev1.VolumeSource{}:
+		volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+	}
+}
@@ -9,8 +9,12 @@ import (
 	"context"
 
 	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/controller/utils"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
@@ -147,55 +151,102 @@ func (e RequestForExtendedDaemonSetStatus) add(obj runtime.Object, q workqueue.R
 	}
 }
 
-// NewRequestForAllReplicaSetFromNodeEvent returns new instance of RequestForAllReplicaSetFromNodeEvent
-func NewRequestForAllReplicaSetFromNodeEvent(c client.Client) handler.EventHandler {
-	return &RequestForAllReplicaSetFromNodeEvent{
-		client: c,
+// NewRequestForReplicaSetFromNodeEvent returns new instance of RequestForReplicaSetFromNodeEvent
+func NewRequestForReplicaSetFromNodeEvent(c client.Client) handler.EventHandler {
+	return &RequestForReplicaSetFromNodeEvent{
+		client:        c,
+		selectorCache: utils.NewSelectorCache(),
 	}
 }
 
-var _ handler.EventHandler = &RequestForAllReplicaSetFromNodeEvent{}
+var _ handler.EventHandler = &RequestForReplicaSetFromNodeEvent{}
 
-// RequestForAllReplicaSetFromNodeEvent enqueues Requests for the ExtendedDaemonSet corresponding to the label value.
-type RequestForAllReplicaSetFromNodeEvent struct {
-	client client.Client
+// RequestForReplicaSetFromNodeEvent enqueues Requests for the ExtendedDaemonSetReplicaSets that can schedule a pod on the Node,
+// and for the ExtendedDaemonSetReplicaSets owning a pod on the Node.
+type RequestForReplicaSetFromNodeEvent struct {
+	client        client.Client
+	selectorCache *utils.SelectorCache
 }
 
 // Create implements EventHandler
-func (e *RequestForAllReplicaSetFromNodeEvent) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
-	e.add(q)
+func (e *RequestForReplicaSetFromNodeEvent) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.add(q, evt.Object)
 }
 
 // Update implements EventHandler
-func (e *RequestForAllReplicaSetFromNodeEvent) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
-	//e.add(q)
+func (e *RequestForReplicaSetFromNodeEvent) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.add(q, evt.ObjectOld, evt.ObjectNew)
 }
 
 // Delete implements EventHandler
-func (e *RequestForAllReplicaSetFromNodeEvent) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
-	e.add(q)
+func (e *RequestForReplicaSetFromNodeEvent) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.add(q, evt.Object)
 }
 
 // Generic implements EventHandler
-func (e *RequestForAllReplicaSetFromNodeEvent) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+func (e *RequestForReplicaSetFromNodeEvent) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
 	//e.add(q)
 }
 
-func (e RequestForAllReplicaSetFromNodeEvent) add(q workqueue.RateLimitingInterface) {
+func (e *RequestForReplicaSetFromNodeEvent) add(q workqueue.RateLimitingInterface, objs ...runtime.Object) {
+	var nodes []*corev1.Node
+	for _, obj := range objs {
+		if node, ok := obj.(*corev1.Node); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return
+	}
+
 	rsList := &v1alpha1.ExtendedDaemonSetReplicaSetList{}
 	err := e.client.List(context.TODO(), rsList)
 	if err != nil {
 		return
 	}
 
-	for _, rs := range rsList.Items {
-		req := reconcile.Request{
-			NamespacedName: types.NamespacedName{
-				Namespace: rs.Namespace,
-				Name:      rs.Name,
-			},
+	uids := make(map[types.UID]bool, len(rsList.Items))
+	for id, rs := range rsList.Items {
+		uids[rs.UID] = true
+		for _, node := range nodes {
+			if e.isReplicaSetMatchingNode(&rsList.Items[id], node) {
+				q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: rs.Namespace, Name: rs.Name}})
+				break
+			}
 		}
+	}
+	e.selectorCache.Retain(uids)
 
-		q.Add(req)
+	// the pods already running on the Node should be handled even if the Node doesn't match anymore
+	for _, node := range nodes {
+		podList := &corev1.PodList{}
+		if err := e.client.List(context.TODO(), podList, client.MatchingFields{podutils.NodeNameIndexField: node.Name}); err != nil {
+			continue
+		}
+		for _, pod := range podList.Items {
+			if pod.Spec.NodeName != node.Name {
+				continue
+			}
+			owner := metav1.GetControllerOf(&pod)
+			if owner == nil || owner.Kind != "ExtendedDaemonSetReplicaSet" {
+				continue
+			}
+			q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}})
+		}
+	}
+}
+
+// isReplicaSetMatchingNode returns true if the replicaset selector and the pod template Node selector match the Node labels.
+// The Node affinity is checked during the reconcile.
+func (e *RequestForReplicaSetFromNodeEvent) isReplicaSetMatchingNode(rs *v1alpha1.ExtendedDaemonSetReplicaSet, node *corev1.Node) bool {
+	selector, err := e.selectorCache.Get(rs, rs.Spec.Selector)
+	if err != nil {
+		// let the reconcile report the invalid selector
+		return true
+	}
+	nodeLabels := labels.Set(node.Labels)
+	if !selector.Matches(nodeLabels) {
+		return false
 	}
+	return labels.SelectorFromSet(rs.Spec.Template.Spec.NodeSelector).Matches(nodeLabels)
 }
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package enqueue
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+	commontest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func TestRequestForReplicaSetFromNodeEvent(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(datadoghqv1alpha1.SchemeGroupVersion, &datadoghqv1alpha1.ExtendedDaemonSetReplicaSet{}, &datadoghqv1alpha1.ExtendedDaemonSetReplicaSetList{})
+
+	newRS := func(name string, selector *metav1.LabelSelector, nodeSelector map[string]string) *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet {
+		rs := test.NewExtendedDaemonSetReplicaSet("bar", name, nil)
+		rs.UID = types.UID(name)
+		rs.Spec.Selector = selector
+		rs.Spec.Template.Spec.NodeSelector = nodeSelector
+		return rs
+	}
+	allNodesRS := newRS("all-nodes", nil, nil)
+	gpuRS := newRS("gpu", &metav1.LabelSelector{MatchLabels: map[string]string{"gpu": "true"}}, nil)
+	linuxRS := newRS("linux", nil, map[string]string{"os": "linux"})
+
+	// the pod of the gpu replicaset is still running on the Node that lost the gpu label
+	gpuPod := commontest.NewPod("bar", "gpu-pod", "node1", nil)
+	gpuPod.OwnerReferences = []metav1.OwnerReference{{Kind: "ExtendedDaemonSetReplicaSet", Name: "gpu", Controller: &[]bool{true}[0]}}
+
+	tests := []struct {
+		name    string
+		trigger func(h *RequestForReplicaSetFromNodeEvent, q workqueue.RateLimitingInterface)
+		want    []string
+	}{
+		{
+			name: "node created, only the matching replicasets",
+			trigger: func(h *RequestForReplicaSetFromNodeEvent, q workqueue.RateLimitingInterface) {
+				node := commontest.NewNode("node2", &commontest.NewNodeOptions{Labels: map[string]string{"os": "linux"}})
+				h.Create(event.CreateEvent{Meta: node, Object: node}, q)
+			},
+			want: []string{"all-nodes", "linux"},
+		},
+		{
+			name: "node labels updated, replicasets matching the old or the new node",
+			trigger: func(h *RequestForReplicaSetFromNodeEvent, q workqueue.RateLimitingInterface) {
+				oldNode := commontest.NewNode("node2", &commontest.NewNodeOptions{Labels: map[string]string{"gpu": "true"}})
+				newNode := commontest.NewNode("node2", &commontest.NewNodeOptions{Labels: map[string]string{"os": "linux"}})
+				h.Update(event.UpdateEvent{MetaOld: oldNode, ObjectOld: oldNode, MetaNew: newNode, ObjectNew: newNode}, q)
+			},
+			want: []string{"all-nodes", "gpu", "linux"},
+		},
+		{
+			name: "node deleted, replicasets owning a pod on the node",
+			trigger: func(h *RequestForReplicaSetFromNodeEvent, q workqueue.RateLimitingInterface) {
+				node := commontest.NewNode("node1", nil)
+				h.Delete(event.DeleteEvent{Meta: node, Object: node}, q)
+			},
+			want: []string{"all-nodes", "gpu"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(s, allNodesRS, gpuRS, linuxRS, gpuPod)
+			h, _ := NewRequestForReplicaSetFromNodeEvent(c).(*RequestForReplicaSetFromNodeEvent)
+			q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			defer q.ShutDown()
+
+			tt.trigger(h, q)
+
+			var got []string
+			for q.Len() > 0 {
+				item, _ := q.Get()
+				got = append(got, item.(reconcile.Request).Name)
+				q.Done(item)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("enqueued requests = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeSchedulingChangedPredicate(t *testing.T) {
+	readyNode := func(status corev1.ConditionStatus) *corev1.Node {
+		return commontest.NewNode("node1", &commontest.NewNodeOptions{
+			Labels:     map[string]string{"os": "linux"},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		})
+	}
+	heartbeat := readyNode(corev1.ConditionTrue)
+	heartbeat.Status.Conditions[0].LastHeartbeatTime = metav1.Now()
+	tainted := readyNode(corev1.ConditionTrue)
+	tainted.Spec.Taints = []corev1.Taint{{Key: "foo", Effect: corev1.TaintEffectNoSchedule}}
+	unschedulable := readyNode(corev1.ConditionTrue)
+	unschedulable.Spec.Unschedulable = true
+	relabeled := readyNode(corev1.ConditionTrue)
+	relabeled.Labels = map[string]string{"os": "windows"}
+	otherAnnotation := readyNode(corev1.ConditionTrue)
+	otherAnnotation.Annotations = map[string]string{"foo": "bar"}
+	resourcesOverwritten := readyNode(corev1.ConditionTrue)
+	resourcesOverwritten.Annotations = map[string]string{"resources.extendeddaemonset.datadoghq.com/bar.foo.main": `{"limits":{"memory":"1G"}}`}
+	resized := readyNode(corev1.ConditionTrue)
+	resized.Status.Allocatable = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")}
+
+	tests := []struct {
+		name    string
+		newNode *corev1.Node
+		want    bool
+	}{
+		{
+			name:    "status heartbeat",
+			newNode: heartbeat,
+			want:    false,
+		},
+		{
+			name:    "labels changed",
+			newNode: relabeled,
+			want:    true,
+		},
+		{
+			name:    "taint added",
+			newNode: tainted,
+			want:    true,
+		},
+		{
+			name:    "cordoned",
+			newNode: unschedulable,
+			want:    true,
+		},
+		{
+			name:    "not ready anymore",
+			newNode: readyNode(corev1.ConditionFalse),
+			want:    true,
+		},
+		{
+			name:    "unrelated annotation",
+			newNode: otherAnnotation,
+			want:    false,
+		},
+		{
+			name:    "resources overwrite annotation",
+			newNode: resourcesOverwritten,
+			want:    true,
+		},
+		{
+			name:    "allocatable changed",
+			newNode: resized,
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldNode := readyNode(corev1.ConditionTrue)
+			evt := event.UpdateEvent{MetaOld: oldNode, ObjectOld: oldNode, MetaNew: tt.newNode, ObjectNew: tt.newNode}
+			if got := NodeSchedulingChangedPredicate().Update(evt); got != tt.want {
+				t.Errorf("NodeSchedulingChangedPredicate().Update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
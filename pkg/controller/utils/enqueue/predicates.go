@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package enqueue
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+// nodeResourcesAnnotationPrefix prefix of the Node annotations overwriting the resources of the ExtendedDaemonSets containers
+var nodeResourcesAnnotationPrefix = strings.SplitN(datadoghqv1alpha1.ExtendedDaemonSetRessourceNodeAnnotationKey, "/", 2)[0] + "/"
+
+// NodeSchedulingChangedPredicate filters out the Node update events that can't change the pods scheduling,
+// like the status heartbeats. Only the labels, taints, unschedulable, readiness, capacity and resources
+// overwrite annotations changes are kept.
+func NodeSchedulingChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(evt event.UpdateEvent) bool {
+			oldNode, okOld := evt.ObjectOld.(*corev1.Node)
+			newNode, okNew := evt.ObjectNew.(*corev1.Node)
+			if !okOld || !okNew {
+				return true
+			}
+			return isNodeSchedulingChanged(oldNode, newNode)
+		},
+	}
+}
+
+func isNodeSchedulingChanged(oldNode, newNode *corev1.Node) bool {
+	if !apiequality.Semantic.DeepEqual(oldNode.Labels, newNode.Labels) {
+		return true
+	}
+	if !apiequality.Semantic.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints) {
+		return true
+	}
+	if oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable {
+		return true
+	}
+	if getNodeReadyStatus(oldNode) != getNodeReadyStatus(newNode) {
+		return true
+	}
+	if !apiequality.Semantic.DeepEqual(oldNode.Status.Capacity, newNode.Status.Capacity) || !apiequality.Semantic.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable) {
+		return true
+	}
+	return !apiequality.Semantic.DeepEqual(getNodeResourcesAnnotations(oldNode), getNodeResourcesAnnotations(newNode))
+}
+
+// getNodeResourcesAnnotations returns the Node annotations overwriting the ExtendedDaemonSets containers resources
+func getNodeResourcesAnnotations(node *corev1.Node) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range node.Annotations {
+		if strings.HasPrefix(key, nodeResourcesAnnotationPrefix) {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+func getNodeReadyStatus(node *corev1.Node) corev1.ConditionStatus {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status
+		}
+	}
+	return corev1.ConditionUnknown
+}
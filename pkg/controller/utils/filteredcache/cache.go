@@ -0,0 +1,319 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package filteredcache provides a controller-runtime cache that only keeps the Pods and the Nodes
+// fields needed by the controllers, to reduce the controller memory on large clusters.
+package filteredcache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	defaultResyncTime       = 10 * time.Hour
+	fieldIndexPrefix        = "field:"
+	allNamespacesIndexValue = "__all_namespaces"
+)
+
+// Options defines the objects filtered by the cache
+type Options struct {
+	// PodLabelSelector only the Pods matching this selector are cached. All the Pods are cached if nil.
+	PodLabelSelector labels.Selector
+	// TrimNodes removes from the cached Nodes the fields not used by the controllers, see TrimNode
+	TrimNodes bool
+	// Namespaces if more than one, the namespaced objects are only cached in these namespaces,
+	// instead of the single namespace of the manager options
+	Namespaces []string
+}
+
+// NewCacheFunc returns a cache.NewCacheFunc building a cache filtering the Pods and the Nodes.
+// The other objects are cached by the default controller-runtime cache.
+func NewCacheFunc(filterOptions Options) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		newDefaultCache := cache.New
+		if len(filterOptions.Namespaces) > 1 {
+			newDefaultCache = cache.MultiNamespacedCacheBuilder(filterOptions.Namespaces)
+		}
+		defaultCache, err := newDefaultCache(config, opts)
+		if err != nil {
+			return nil, err
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		resync := defaultResyncTime
+		if opts.Resync != nil {
+			resync = *opts.Resync
+		}
+
+		var podLW toolscache.ListerWatcher
+		if len(filterOptions.Namespaces) > 1 {
+			podLWs := make(map[string]toolscache.ListerWatcher, len(filterOptions.Namespaces))
+			for _, namespace := range filterOptions.Namespaces {
+				podLWs[namespace] = newPodListWatch(clientset, namespace, filterOptions.PodLabelSelector)
+			}
+			podLW = newMultiNamespaceListWatch(podLWs)
+		} else {
+			podLW = newPodListWatch(clientset, opts.Namespace, filterOptions.PodLabelSelector)
+		}
+		nodeLW := newNodeListWatch(clientset)
+		if filterOptions.TrimNodes {
+			nodeLW = NewTrimNodeListWatch(nodeLW)
+		}
+		return newFilteredCache(defaultCache, opts.Scheme, resync, podLW, nodeLW), nil
+	}
+}
+
+func newFilteredCache(defaultCache cache.Cache, scheme *runtime.Scheme, resync time.Duration, podLW, nodeLW toolscache.ListerWatcher) *filteredCache {
+	return &filteredCache{
+		Cache:  defaultCache,
+		scheme: scheme,
+		informers: map[schema.GroupVersionKind]toolscache.SharedIndexInformer{
+			corev1.SchemeGroupVersion.WithKind("Pod"):  newInformer(podLW, &corev1.Pod{}, resync),
+			corev1.SchemeGroupVersion.WithKind("Node"): newInformer(nodeLW, &corev1.Node{}, resync),
+		},
+	}
+}
+
+func newInformer(lw toolscache.ListerWatcher, objType runtime.Object, resync time.Duration) toolscache.SharedIndexInformer {
+	return toolscache.NewSharedIndexInformer(lw, objType, resync, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+}
+
+func newPodListWatch(clientset kubernetes.Interface, namespace string, selector labels.Selector) toolscache.ListerWatcher {
+	tweak := func(opts *metav1.ListOptions) {
+		if selector != nil {
+			opts.LabelSelector = selector.String()
+		}
+	}
+	return &toolscache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			tweak(&opts)
+			return clientset.CoreV1().Pods(namespace).List(opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			tweak(&opts)
+			return clientset.CoreV1().Pods(namespace).Watch(opts)
+		},
+	}
+}
+
+func newNodeListWatch(clientset kubernetes.Interface) toolscache.ListerWatcher {
+	return &toolscache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return clientset.CoreV1().Nodes().List(opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return clientset.CoreV1().Nodes().Watch(opts)
+		},
+	}
+}
+
+// filteredCache serves the Pods and the Nodes from its own informers, and the other objects from the default cache
+type filteredCache struct {
+	cache.Cache
+	scheme    *runtime.Scheme
+	informers map[schema.GroupVersionKind]toolscache.SharedIndexInformer
+}
+
+var _ cache.Cache = &filteredCache{}
+
+// Get implements client.Reader
+func (c *filteredCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	informer, found := c.informers[gvk]
+	if !found {
+		return c.Cache.Get(ctx, key, obj)
+	}
+
+	storeKey := key.Name
+	if key.Namespace != "" {
+		storeKey = key.Namespace + "/" + key.Name
+	}
+	item, exists, err := informer.GetIndexer().GetByKey(storeKey)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind) + "s"}, key.Name)
+	}
+	cached, ok := item.(runtime.Object)
+	if !ok {
+		return fmt.Errorf("cache contained %T, which is not an Object", item)
+	}
+	outVal := reflect.ValueOf(obj)
+	objVal := reflect.ValueOf(cached.DeepCopyObject())
+	if !objVal.Type().AssignableTo(outVal.Type()) {
+		return fmt.Errorf("cache had type %s, but %s was asked for", objVal.Type(), outVal.Type())
+	}
+	reflect.Indirect(outVal).Set(reflect.Indirect(objVal))
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return nil
+}
+
+// List implements client.Reader
+func (c *filteredCache) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	gvk, err := apiutil.GVKForObject(list, c.scheme)
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	informer, found := c.informers[gvk]
+	if !found {
+		return c.Cache.List(ctx, list, opts...)
+	}
+
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	var items []interface{}
+	switch {
+	case listOpts.FieldSelector != nil:
+		field, value, exact := requiresExactMatch(listOpts.FieldSelector)
+		if !exact {
+			return fmt.Errorf("non-exact field matches are not supported by the cache")
+		}
+		items, err = informer.GetIndexer().ByIndex(fieldIndexPrefix+field, namespacedIndexValue(listOpts.Namespace, value))
+	case listOpts.Namespace != "":
+		items, err = informer.GetIndexer().ByIndex(toolscache.NamespaceIndex, listOpts.Namespace)
+	default:
+		items = informer.GetIndexer().List()
+	}
+	if err != nil {
+		return err
+	}
+
+	objs := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(runtime.Object)
+		if !ok {
+			return fmt.Errorf("cache contained %T, which is not an Object", item)
+		}
+		if listOpts.LabelSelector != nil {
+			meta, err := apimeta.Accessor(obj)
+			if err != nil {
+				return err
+			}
+			if !listOpts.LabelSelector.Matches(labels.Set(meta.GetLabels())) {
+				continue
+			}
+		}
+		outObj := obj.DeepCopyObject()
+		outObj.GetObjectKind().SetGroupVersionKind(gvk)
+		objs = append(objs, outObj)
+	}
+	return apimeta.SetList(list, objs)
+}
+
+// GetInformer implements cache.Informers
+func (c *filteredCache) GetInformer(obj runtime.Object) (cache.Informer, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetInformerForKind(gvk)
+}
+
+// GetInformerForKind implements cache.Informers
+func (c *filteredCache) GetInformerForKind(gvk schema.GroupVersionKind) (cache.Informer, error) {
+	if informer, found := c.informers[gvk]; found {
+		return informer, nil
+	}
+	return c.Cache.GetInformerForKind(gvk)
+}
+
+// IndexField implements client.FieldIndexer
+func (c *filteredCache) IndexField(obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	informer, found := c.informers[gvk]
+	if !found {
+		return c.Cache.IndexField(obj, field, extractValue)
+	}
+	return informer.AddIndexers(toolscache.Indexers{
+		fieldIndexPrefix + field: func(item interface{}) ([]string, error) {
+			obj, ok := item.(runtime.Object)
+			if !ok {
+				return nil, fmt.Errorf("object of type %T is not an Object", item)
+			}
+			meta, err := apimeta.Accessor(obj)
+			if err != nil {
+				return nil, err
+			}
+			var values []string
+			for _, value := range extractValue(obj) {
+				values = append(values, namespacedIndexValue("", value))
+				if meta.GetNamespace() != "" {
+					values = append(values, namespacedIndexValue(meta.GetNamespace(), value))
+				}
+			}
+			return values, nil
+		},
+	})
+}
+
+// Start implements cache.Informers, it blocks until stop is closed
+func (c *filteredCache) Start(stop <-chan struct{}) error {
+	for _, informer := range c.informers {
+		go informer.Run(stop)
+	}
+	return c.Cache.Start(stop)
+}
+
+// WaitForCacheSync implements cache.Informers
+func (c *filteredCache) WaitForCacheSync(stop <-chan struct{}) bool {
+	synced := make([]toolscache.InformerSynced, 0, len(c.informers))
+	for _, informer := range c.informers {
+		synced = append(synced, informer.HasSynced)
+	}
+	if !toolscache.WaitForCacheSync(stop, synced...) {
+		return false
+	}
+	return c.Cache.WaitForCacheSync(stop)
+}
+
+func namespacedIndexValue(namespace, value string) string {
+	if namespace == "" {
+		namespace = allNamespacesIndexValue
+	}
+	return namespace + "/" + value
+}
+
+func requiresExactMatch(selector fields.Selector) (string, string, bool) {
+	reqs := selector.Requirements()
+	if len(reqs) != 1 {
+		return "", "", false
+	}
+	if reqs[0].Operator != selection.Equals && reqs[0].Operator != selection.DoubleEquals {
+		return "", "", false
+	}
+	return reqs[0].Field, reqs[0].Value, true
+}
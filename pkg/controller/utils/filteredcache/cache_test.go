@@ -0,0 +1,232 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package filteredcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	podutils "github.com/datadog/extendeddaemonset/pkg/controller/utils/pod"
+)
+
+func TestFilteredCache(t *testing.T) {
+	objects := []k8sruntime.Object{newFixtureNode(1), newFixtureNode(2)}
+	objects = append(objects, newFixturePods(1, 1)...)
+	objects = append(objects, newFixturePods(2, 1)...)
+	clientset := fake.NewSimpleClientset(objects...)
+
+	podSelector, _ := labels.Parse(datadoghqv1alpha1.ExtendedDaemonSetNameLabelKey)
+	c := newFilteredCache(&informertest.FakeInformers{}, scheme.Scheme, 0, newPodListWatch(clientset, "", podSelector), NewTrimNodeListWatch(newNodeListWatch(clientset)))
+	if err := podutils.AddFieldIndexes(c); err != nil {
+		t.Fatalf("AddFieldIndexes() error = %v", err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		_ = c.Start(stop)
+	}()
+	if !c.WaitForCacheSync(stop) {
+		t.Fatalf("WaitForCacheSync() = false")
+	}
+
+	podList := &corev1.PodList{}
+	if err := c.List(context.TODO(), podList); err != nil || len(podList.Items) != 2 {
+		t.Errorf("List() pods = %d, %v, want the 2 ExtendedDaemonSet pods", len(podList.Items), err)
+	}
+	podList = &corev1.PodList{}
+	if err := c.List(context.TODO(), podList, client.InNamespace("bar"), client.MatchingFields{podutils.NodeNameIndexField: "node1"}); err != nil || len(podList.Items) != 1 || podList.Items[0].Spec.NodeName != "node1" {
+		t.Errorf("List() pods on node1 = %v, %v, want 1 pod", podList.Items, err)
+	}
+
+	pod := &corev1.Pod{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "bar", Name: "node1-other-0"}, pod); !apierrors.IsNotFound(err) {
+		t.Errorf("Get() pod without the ExtendedDaemonSet label, error = %v, want not found", err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "bar", Name: "node1-eds"}, pod); err != nil {
+		t.Errorf("Get() ExtendedDaemonSet pod, error = %v", err)
+	}
+
+	node := &corev1.Node{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "node1"}, node); err != nil {
+		t.Fatalf("Get() node error = %v", err)
+	}
+	if len(node.Status.Images) != 0 || len(node.Labels) == 0 || len(node.Status.Allocatable) == 0 || len(node.Status.Conditions) == 0 {
+		t.Errorf("Get() node not trimmed as expected: %v", node)
+	}
+}
+
+// BenchmarkCacheMemory reports the heap used by the Pods and Nodes caches on a synthetic 5k Nodes cluster,
+// with 1 ExtendedDaemonSet pod and 3 other pods by Node.
+func BenchmarkCacheMemory(b *testing.B) {
+	const nbNodes, nbOtherPodsByNode = 5000, 3
+	objects := make([]k8sruntime.Object, 0, nbNodes*(2+nbOtherPodsByNode))
+	for i := 0; i < nbNodes; i++ {
+		objects = append(objects, newFixtureNode(i))
+		objects = append(objects, newFixturePods(i, nbOtherPodsByNode)...)
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+	podSelector, _ := labels.Parse(datadoghqv1alpha1.ExtendedDaemonSetNameLabelKey)
+
+	for _, bc := range []struct {
+		name        string
+		podSelector labels.Selector
+		trimNodes   bool
+	}{
+		{name: "all pods and nodes"},
+		{name: "filtered pods and trimmed nodes", podSelector: podSelector, trimNodes: true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var heapSize uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				goroutines := runtime.NumGoroutine()
+
+				podLW := newDecodingListWatch(newPodListWatch(clientset, "", bc.podSelector))
+				nodeLW := newDecodingListWatch(newNodeListWatch(clientset))
+				if bc.trimNodes {
+					nodeLW = NewTrimNodeListWatch(nodeLW)
+				}
+				stop := make(chan struct{})
+				c := newFilteredCache(&informertest.FakeInformers{}, scheme.Scheme, 0, podLW, nodeLW)
+				go func() {
+					_ = c.Start(stop)
+				}()
+				if !c.WaitForCacheSync(stop) {
+					b.Fatalf("WaitForCacheSync() = false")
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(c)
+				close(stop)
+				waitInformersStopped(goroutines)
+				if after.HeapAlloc > before.HeapAlloc {
+					heapSize += after.HeapAlloc - before.HeapAlloc
+				}
+			}
+			b.ReportMetric(float64(heapSize)/float64(b.N)/(1<<20), "heap-MiB")
+		})
+	}
+}
+
+// waitInformersStopped waits until the informers goroutines return, so the next run
+// doesn't measure the previous cache being released
+func waitInformersStopped(goroutines int) {
+	for i := 0; i < 100 && runtime.NumGoroutine() > goroutines; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// newDecodingListWatch returns a ListerWatcher decoding again the lists returned by lw, like the objects received
+// from the API server. Else the fixture strings would be shared between the fake clientset and the cache.
+func newDecodingListWatch(lw toolscache.ListerWatcher) toolscache.ListerWatcher {
+	return &toolscache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (k8sruntime.Object, error) {
+			obj, err := lw.List(opts)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+			decoded := obj.DeepCopyObject()
+			if err = apimeta.SetList(decoded, nil); err != nil {
+				return nil, err
+			}
+			return decoded, json.Unmarshal(data, decoded)
+		},
+		WatchFunc: lw.Watch,
+	}
+}
+
+func newFixtureNode(id int) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("node%d", id),
+			Labels: map[string]string{
+				"kubernetes.io/hostname":           fmt.Sprintf("node%d", id),
+				"kubernetes.io/os":                 "linux",
+				"node.kubernetes.io/instance-type": "m5.2xlarge",
+				"topology.kubernetes.io/zone":      fmt.Sprintf("zone-%d", id%3),
+			},
+			Annotations: map[string]string{
+				"node.alpha.kubernetes.io/ttl": "0",
+			},
+		},
+		Spec: corev1.NodeSpec{
+			PodCIDR: "10.0.0.0/24",
+		},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8"), corev1.ResourceMemory: resource.MustParse("32Gi")},
+			Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8"), corev1.ResourceMemory: resource.MustParse("32Gi")},
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady", Message: "kubelet is posting ready status"},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse, Reason: "KubeletHasSufficientMemory", Message: "kubelet has sufficient memory available"},
+				{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse, Reason: "KubeletHasNoDiskPressure", Message: "kubelet has no disk pressure"},
+			},
+			NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.17.4", OSImage: "Ubuntu 18.04", ContainerRuntimeVersion: "containerd://1.3.3"},
+		},
+	}
+	for i := 0; i < 50; i++ {
+		node.Status.Images = append(node.Status.Images, corev1.ContainerImage{
+			Names: []string{
+				fmt.Sprintf("registry.example.com/team/image-%d@sha256:%064d", i, i),
+				fmt.Sprintf("registry.example.com/team/image-%d:1.%d.0", i, i),
+			},
+			SizeBytes: 100000000,
+		})
+	}
+	for i := 0; i < 5; i++ {
+		volume := corev1.UniqueVolumeName(fmt.Sprintf("kubernetes.io/csi/ebs.csi.aws.com^vol-%017d", i))
+		node.Status.VolumesInUse = append(node.Status.VolumesInUse, volume)
+		node.Status.VolumesAttached = append(node.Status.VolumesAttached, corev1.AttachedVolume{Name: volume, DevicePath: ""})
+	}
+	return node
+}
+
+// newFixturePods returns the ExtendedDaemonSet pod and the other pods of the Node
+func newFixturePods(nodeID, nbOtherPods int) []k8sruntime.Object {
+	newPod := func(name string, podLabels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: name, Labels: podLabels},
+			Spec: corev1.PodSpec{
+				NodeName: fmt.Sprintf("node%d", nodeID),
+				Containers: []corev1.Container{{
+					Name:  "main",
+					Image: "registry.example.com/team/app:1.0.0",
+					Env:   []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}, {Name: "PORT", Value: "8080"}},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	pods := []k8sruntime.Object{newPod(fmt.Sprintf("node%d-eds", nodeID), map[string]string{datadoghqv1alpha1.ExtendedDaemonSetNameLabelKey: "foo"})}
+	for i := 0; i < nbOtherPods; i++ {
+		pods = append(pods, newPod(fmt.Sprintf("node%d-other-%d", nodeID, i), map[string]string{"app": "other"}))
+	}
+	return pods
+}
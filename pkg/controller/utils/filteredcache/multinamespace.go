@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package filteredcache
+
+import (
+	"fmt"
+	"sync"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// multiNamespaceListWatch merges the ListWatches of several namespaces, so a single informer caches the objects
+// of these namespaces only, without requiring the permission to list them cluster-wide.
+// The resource version of each namespace is tracked internally: the merged list has no resource version
+// and the resource version given to Watch is ignored.
+type multiNamespaceListWatch struct {
+	listWatches map[string]toolscache.ListerWatcher
+
+	mutex            sync.Mutex
+	resourceVersions map[string]string
+}
+
+var _ toolscache.ListerWatcher = &multiNamespaceListWatch{}
+
+func newMultiNamespaceListWatch(listWatches map[string]toolscache.ListerWatcher) *multiNamespaceListWatch {
+	return &multiNamespaceListWatch{
+		listWatches:      listWatches,
+		resourceVersions: map[string]string{},
+	}
+}
+
+// List implements cache.Lister
+func (lw *multiNamespaceListWatch) List(opts metav1.ListOptions) (runtime.Object, error) {
+	var merged runtime.Object
+	var items []runtime.Object
+	resourceVersions := map[string]string{}
+	for namespace, nsLW := range lw.listWatches {
+		list, err := nsLW.List(opts)
+		if err != nil {
+			return nil, err
+		}
+		listMeta, err := apimeta.ListAccessor(list)
+		if err != nil {
+			return nil, err
+		}
+		resourceVersions[namespace] = listMeta.GetResourceVersion()
+		nsItems, err := apimeta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, nsItems...)
+		if merged == nil {
+			merged = list
+		}
+	}
+	if merged == nil {
+		return nil, fmt.Errorf("no namespace to list")
+	}
+	if err := apimeta.SetList(merged, items); err != nil {
+		return nil, err
+	}
+	listMeta, err := apimeta.ListAccessor(merged)
+	if err != nil {
+		return nil, err
+	}
+	listMeta.SetResourceVersion("")
+
+	lw.mutex.Lock()
+	lw.resourceVersions = resourceVersions
+	lw.mutex.Unlock()
+	return merged, nil
+}
+
+// Watch implements cache.Watcher. The merged watch stops as soon as one of the namespace watches stops,
+// so the informer watches again all the namespaces.
+func (lw *multiNamespaceListWatch) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	merged := &multiNamespaceWatch{
+		result: make(chan watch.Event),
+		stop:   make(chan struct{}),
+	}
+	namespaces := make([]string, 0, len(lw.listWatches))
+	for namespace, nsLW := range lw.listWatches {
+		nsOpts := opts
+		lw.mutex.Lock()
+		nsOpts.ResourceVersion = lw.resourceVersions[namespace]
+		lw.mutex.Unlock()
+		w, err := nsLW.Watch(nsOpts)
+		if err != nil {
+			merged.Stop()
+			return nil, err
+		}
+		namespaces = append(namespaces, namespace)
+		merged.watches = append(merged.watches, w)
+	}
+	for i, w := range merged.watches {
+		merged.wg.Add(1)
+		go lw.forward(namespaces[i], w, merged)
+	}
+	go func() {
+		merged.wg.Wait()
+		close(merged.result)
+	}()
+	return merged, nil
+}
+
+// forward sends the events of a namespace watch to the merged watch, and records the namespace resource version
+func (lw *multiNamespaceListWatch) forward(namespace string, w watch.Interface, merged *multiNamespaceWatch) {
+	defer merged.wg.Done()
+	defer merged.Stop()
+	for {
+		select {
+		case <-merged.stop:
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			if event.Type != watch.Error {
+				if meta, err := apimeta.Accessor(event.Object); err == nil {
+					lw.mutex.Lock()
+					lw.resourceVersions[namespace] = meta.GetResourceVersion()
+					lw.mutex.Unlock()
+				}
+			}
+			select {
+			case merged.result <- event:
+			case <-merged.stop:
+				return
+			}
+		}
+	}
+}
+
+// multiNamespaceWatch merges the watches of several namespaces
+type multiNamespaceWatch struct {
+	watches []watch.Interface
+	result  chan watch.Event
+
+	wg       sync.WaitGroup
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Stop implements watch.Interface
+func (w *multiNamespaceWatch) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		for _, nsWatch := range w.watches {
+			nsWatch.Stop()
+		}
+	})
+}
+
+// ResultChan implements watch.Interface
+func (w *multiNamespaceWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
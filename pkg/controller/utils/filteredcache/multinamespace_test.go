@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package filteredcache
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+func TestMultiNamespaceListWatch(t *testing.T) {
+	newPod := func(namespace, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	clientset := fake.NewSimpleClientset(newPod("foo", "pod-foo"), newPod("bar", "pod-bar"), newPod("other", "pod-other"))
+	lw := newMultiNamespaceListWatch(map[string]toolscache.ListerWatcher{
+		"foo": newPodListWatch(clientset, "foo", labels.Everything()),
+		"bar": newPodListWatch(clientset, "bar", labels.Everything()),
+	})
+
+	obj, err := lw.List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var names []string
+	for _, pod := range obj.(*corev1.PodList).Items {
+		names = append(names, pod.Name)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "pod-bar" || names[1] != "pod-foo" {
+		t.Errorf("List() pods = %v, want [pod-bar pod-foo]", names)
+	}
+
+	w, err := lw.Watch(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer w.Stop()
+	for _, pod := range []k8sruntime.Object{newPod("other", "pod-other-2"), newPod("foo", "pod-foo-2"), newPod("bar", "pod-bar-2")} {
+		if err = clientset.Tracker().Create(corev1.SchemeGroupVersion.WithResource("pods"), pod, pod.(*corev1.Pod).Namespace); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	received := map[string]bool{}
+	timeout := time.After(5 * time.Second)
+	for len(received) < 2 {
+		select {
+		case event := <-w.ResultChan():
+			if event.Type != watch.Added {
+				t.Fatalf("Watch() event type = %s, want %s", event.Type, watch.Added)
+			}
+			received[event.Object.(*corev1.Pod).Name] = true
+		case <-timeout:
+			t.Fatalf("Watch() received %v, want the events of pod-foo-2 and pod-bar-2", received)
+		}
+	}
+	if received["pod-other-2"] {
+		t.Errorf("Watch() received an event from a namespace not watched")
+	}
+
+	w.Stop()
+	select {
+	case _, ok := <-w.ResultChan():
+		if ok {
+			t.Errorf("ResultChan() not closed after Stop()")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("ResultChan() not closed after Stop()")
+	}
+}
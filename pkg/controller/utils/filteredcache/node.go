@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package filteredcache
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// TrimNode removes in place the Node fields not used by the controllers: the images present on the Node,
+// the volumes, the managed fields and the daemon endpoints. The images list is usually the largest part of a Node.
+// The labels, annotations, spec, conditions, capacity and allocatable resources are kept.
+func TrimNode(node *corev1.Node) {
+	node.ManagedFields = nil
+	node.Status.Images = nil
+	node.Status.VolumesInUse = nil
+	node.Status.VolumesAttached = nil
+	node.Status.DaemonEndpoints = corev1.NodeDaemonEndpoints{}
+	node.Status.Config = nil
+}
+
+// NewTrimNodeListWatch returns a ListerWatcher trimming the Nodes returned by lw
+func NewTrimNodeListWatch(lw toolscache.ListerWatcher) toolscache.ListerWatcher {
+	return &toolscache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(opts)
+			if err != nil {
+				return nil, err
+			}
+			if nodeList, ok := obj.(*corev1.NodeList); ok {
+				for i := range nodeList.Items {
+					TrimNode(&nodeList.Items[i])
+				}
+			}
+			return obj, nil
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(opts)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(evt watch.Event) (watch.Event, bool) {
+				if node, ok := evt.Object.(*corev1.Node); ok {
+					TrimNode(node)
+				}
+				return evt, true
+			}), nil
+		},
+	}
+}
@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"sync"
+
 	"github.com/go-logr/logr"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // ConvertLabelSelector converts a "k8s.io/apimachinery/pkg/apis/meta/v1".LabelSelector as found in manifests spec section into a "k8s.io/apimachinery/pkg/labels".Selector to be used to filter list operations.
@@ -46,3 +49,53 @@ func ConvertLabelSelector(logger logr.Logger, inSelector *metav1.LabelSelector)
 	}
 	return outSelector, nil
 }
+
+// SelectorCache caches the parsed label selectors of objects, by object UID and generation.
+// It avoids parsing again the same selectors on each event.
+type SelectorCache struct {
+	mutex     sync.Mutex
+	selectors map[types.UID]cachedSelector
+}
+
+type cachedSelector struct {
+	generation int64
+	selector   labels.Selector
+}
+
+// NewSelectorCache returns a new SelectorCache instance
+func NewSelectorCache() *SelectorCache {
+	return &SelectorCache{
+		selectors: make(map[types.UID]cachedSelector),
+	}
+}
+
+// Get returns the parsed selector of the object, parsing it only if the object generation changed.
+// A nil selector matches everything.
+func (c *SelectorCache) Get(obj metav1.Object, inSelector *metav1.LabelSelector) (labels.Selector, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if cached, found := c.selectors[obj.GetUID()]; found && cached.generation == obj.GetGeneration() {
+		return cached.selector, nil
+	}
+
+	selector := labels.Everything()
+	if inSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(inSelector); err != nil {
+			return nil, err
+		}
+	}
+	c.selectors[obj.GetUID()] = cachedSelector{generation: obj.GetGeneration(), selector: selector}
+	return selector, nil
+}
+
+// Retain removes from the cache the selectors of the objects not in uids
+func (c *SelectorCache) Retain(uids map[types.UID]bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for uid := range c.selectors {
+		if !uids[uid] {
+			delete(c.selectors, uid)
+		}
+	}
+}
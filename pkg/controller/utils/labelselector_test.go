@@ -7,6 +7,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
@@ -68,3 +69,33 @@ func TestConvertLabelSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectorCache(t *testing.T) {
+	cache := NewSelectorCache()
+	obj := &metav1.ObjectMeta{UID: "foo", Generation: 1}
+
+	selector, err := cache.Get(obj, nil)
+	if err != nil || !selector.Empty() {
+		t.Fatalf("Get() nil selector = %v, %v, want everything", selector, err)
+	}
+
+	// the selector is only parsed again when the generation changes
+	selector, _ = cache.Get(obj, &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}})
+	if !selector.Empty() {
+		t.Errorf("Get() same generation = %v, want the cached selector", selector)
+	}
+	obj.Generation = 2
+	selector, _ = cache.Get(obj, &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}})
+	if !selector.Matches(labels.Set{"foo": "bar"}) || selector.Matches(labels.Set{}) {
+		t.Errorf("Get() new generation = %v, want foo=bar", selector)
+	}
+
+	if _, err = cache.Get(&metav1.ObjectMeta{UID: "invalid"}, &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "b@r"}}); err == nil {
+		t.Errorf("Get() invalid selector, want an error")
+	}
+
+	cache.Retain(map[types.UID]bool{})
+	if len(cache.selectors) != 0 {
+		t.Errorf("Retain() kept %d selectors, want 0", len(cache.selectors))
+	}
+}
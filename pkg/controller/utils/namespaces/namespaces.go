@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package namespaces selects the namespaces watched by the operator: a list of namespaces,
+// the namespaces matching a label selector, or all the namespaces.
+package namespaces
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("namespaces")
+
+const defaultWatchInterval = 30 * time.Second
+
+// ErrNamespacesChanged returned by the Watcher when the namespaces matching the label selector changed
+var ErrNamespacesChanged = errors.New("the namespaces matching the watch namespace selector changed")
+
+// Parse returns the namespaces of a comma-separated list, sorted and without duplicates.
+// It returns nil if the list is empty, meaning all the namespaces.
+func Parse(value string) []string {
+	set := map[string]struct{}{}
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			set[namespace] = struct{}{}
+		}
+	}
+	return sortedKeys(set)
+}
+
+// Select returns the namespaces matching the label selector. If namespaces is not empty, only these namespaces
+// are kept. It returns the namespaces unchanged if the selector is nil or empty.
+func Select(client kubernetes.Interface, namespaces []string, selector labels.Selector) ([]string, error) {
+	if selector == nil || selector.Empty() {
+		return namespaces, nil
+	}
+	nsList, err := client.CoreV1().Namespaces().List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	allowed := map[string]struct{}{}
+	for _, namespace := range namespaces {
+		allowed[namespace] = struct{}{}
+	}
+	set := map[string]struct{}{}
+	for _, ns := range nsList.Items {
+		if _, found := allowed[ns.Name]; len(allowed) == 0 || found {
+			set[ns.Name] = struct{}{}
+		}
+	}
+	return sortedKeys(set), nil
+}
+
+// Watcher detects the changes of the namespaces matching a label selector. The caches can't add or remove
+// a namespace, so the Watcher returns ErrNamespacesChanged to stop the manager, and the operator is restarted.
+type Watcher struct {
+	client     kubernetes.Interface
+	namespaces []string
+	selector   labels.Selector
+	current    []string
+	interval   time.Duration
+}
+
+// NewWatcher returns a new Watcher, current are the namespaces selected at startup
+func NewWatcher(client kubernetes.Interface, namespaces []string, selector labels.Selector, current []string) *Watcher {
+	return &Watcher{
+		client:     client,
+		namespaces: namespaces,
+		selector:   selector,
+		current:    current,
+		interval:   defaultWatchInterval,
+	}
+}
+
+// Start polls the namespaces until stop is closed, it implements the manager.Runnable interface
+func (w *Watcher) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			if w.changed() {
+				return ErrNamespacesChanged
+			}
+		}
+	}
+}
+
+func (w *Watcher) changed() bool {
+	selected, err := Select(w.client, w.namespaces, w.selector)
+	if err != nil {
+		log.Error(err, "Unable to list the namespaces", "selector", w.selector.String())
+		return false
+	}
+	if reflect.DeepEqual(selected, w.current) {
+		return false
+	}
+	log.Info("Watched namespaces changed", "selector", w.selector.String(), "current", w.current, "new", selected)
+	return true
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
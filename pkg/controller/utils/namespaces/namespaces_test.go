@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package namespaces
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  nil,
+		},
+		{
+			name:  "single namespace",
+			value: "foo",
+			want:  []string{"foo"},
+		},
+		{
+			name:  "list with spaces and duplicates",
+			value: "foo, bar,,foo ",
+			want:  []string{"bar", "foo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	newNamespace := func(name string, nsLabels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nsLabels}}
+	}
+	client := fake.NewSimpleClientset(
+		newNamespace("foo", map[string]string{"eds": "enabled"}),
+		newNamespace("bar", map[string]string{"eds": "enabled"}),
+		newNamespace("other", nil),
+	)
+	selector, _ := labels.Parse("eds=enabled")
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		selector   labels.Selector
+		want       []string
+	}{
+		{
+			name:       "no selector",
+			namespaces: []string{"other"},
+			selector:   labels.Everything(),
+			want:       []string{"other"},
+		},
+		{
+			name:     "selector only",
+			selector: selector,
+			want:     []string{"bar", "foo"},
+		},
+		{
+			name:       "selector and namespaces",
+			namespaces: []string{"foo", "other"},
+			selector:   selector,
+			want:       []string{"foo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Select(client, tt.namespaces, tt.selector)
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcher_changed(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"eds": "enabled"}}})
+	selector, _ := labels.Parse("eds=enabled")
+	w := NewWatcher(client, nil, selector, []string{"foo"})
+	if w.changed() {
+		t.Errorf("changed() = true, want false")
+	}
+
+	if _, err := client.CoreV1().Namespaces().Create(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "bar", Labels: map[string]string{"eds": "enabled"}}}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !w.changed() {
+		t.Errorf("changed() = false, want true after a new namespace matches the selector")
+	}
+}
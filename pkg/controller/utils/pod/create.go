@@ -22,7 +22,8 @@ import (
 )
 
 // CreatePodFromDaemonSetReplicaSet use to create a Pod from a ReplicaSet instance and a specific Node name.
-func CreatePodFromDaemonSetReplicaSet(scheme *runtime.Scheme, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, node *corev1.Node, edsNode *datadoghqv1alpha1.ExtendedDaemonsetSetting, addNodeAffinity bool) (*corev1.Pod, error) {
+// If a resources profile is provided, its resources take precedence over the ExtendedDaemonsetSetting ones.
+func CreatePodFromDaemonSetReplicaSet(scheme *runtime.Scheme, replicaset *datadoghqv1alpha1.ExtendedDaemonSetReplicaSet, node *corev1.Node, edsNode *datadoghqv1alpha1.ExtendedDaemonsetSetting, profile *datadoghqv1alpha1.ExtendedDaemonSetResourcesProfile, addNodeAffinity bool) (*corev1.Pod, error) {
 	var err error
 	templateCopy := replicaset.Spec.Template.DeepCopy()
 	{
@@ -43,10 +44,22 @@ func CreatePodFromDaemonSetReplicaSet(scheme *runtime.Scheme, replicaset *datado
 	templateCopy.ObjectMeta.Annotations[datadoghqv1alpha1.MD5ExtendedDaemonSetAnnotationKey] = replicaset.Spec.TemplateGeneration
 	templateCopy.ObjectMeta.Annotations[DaemonsetClusterAutoscalerPodAnnotationKey] = "true"
 
-	overwriteResourcesFromEdsNode(templateCopy, edsNode)
+	var errs []error
+	if profile != nil {
+		templateCopy.ObjectMeta.Annotations[datadoghqv1alpha1.ResourcesProfileAnnotationKey] = profile.Name
+		if err = overwriteResourcesFromContainersSpec(templateCopy, profile.Containers, node); err != nil {
+			errs = append(errs, err)
+		}
+	} else if edsNode != nil {
+		if err = overwriteResourcesFromContainersSpec(templateCopy, edsNode.Spec.Containers, node); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
 	if node != nil {
-		err = overwriteResourcesFromNode(templateCopy, replicaset.Namespace, edsName, node)
+		if err = overwriteResourcesFromNode(templateCopy, replicaset.Namespace, edsName, node); err != nil {
+			errs = append(errs, err)
+		}
 		hash := comparison.GenerateHashFromEDSResourceNodeAnnotation(replicaset.Namespace, edsName, node.Annotations)
 		if hash != "" {
 			templateCopy.ObjectMeta.Annotations[datadoghqv1alpha1.MD5NodeExtendedDaemonSetAnnotationKey] = hash
@@ -66,24 +79,28 @@ func CreatePodFromDaemonSetReplicaSet(scheme *runtime.Scheme, replicaset *datado
 	}
 
 	if scheme != nil {
-		err = controllerutil.SetControllerReference(replicaset, pod, scheme)
+		if err = controllerutil.SetControllerReference(replicaset, pod, scheme); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	return pod, err
+	return pod, errors.NewAggregate(errs)
 }
 
-func overwriteResourcesFromEdsNode(template *corev1.PodTemplateSpec, edsNode *datadoghqv1alpha1.ExtendedDaemonsetSetting) {
-	if edsNode == nil {
-		return
-	}
-	for _, extraConfig := range edsNode.Spec.Containers {
+func overwriteResourcesFromContainersSpec(template *corev1.PodTemplateSpec, containers []datadoghqv1alpha1.ExtendedDaemonsetSettingContainerSpec, node *corev1.Node) error {
+	var errs []error
+	for _, extraConfig := range containers {
 		for id, container := range template.Spec.Containers {
 			if extraConfig.Name == container.Name {
-				template.Spec.Containers[id].Resources = extraConfig.Resources
+				template.Spec.Containers[id].Resources = *extraConfig.Resources.DeepCopy()
+				if err := ApplyResourcesFormulas(&template.Spec.Containers[id].Resources, extraConfig.ResourcesFormulas, node); err != nil {
+					errs = append(errs, fmt.Errorf("unable to compute the container %s resources, err: %v", container.Name, err))
+				}
 				break
 			}
 		}
 	}
+	return errors.NewAggregate(errs)
 }
 
 func overwriteResourcesFromNode(template *corev1.PodTemplateSpec, edsNamespace, edsName string, node *corev1.Node) error {
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package pod
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+// DefaultEvictionBackoff delay before retrying an eviction rejected by a PodDisruptionBudget,
+// if the apiserver doesn't suggest one
+const DefaultEvictionBackoff = 10 * time.Second
+
+// RemovePod removes a pod of the previous version or a pod to clean up, with the deletion mode and the termination grace period
+// configured in the rolling update strategy.
+func RemovePod(c client.Client, kubeClient kubernetes.Interface, rollingUpdate *datadoghqv1alpha1.ExtendedDaemonSetSpecStrategyRollingUpdate, pod *corev1.Pod) error {
+	if rollingUpdate.DeletionMode == datadoghqv1alpha1.ExtendedDaemonSetDeletionModeEvict {
+		eviction := &policyv1beta1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+			},
+			DeleteOptions: &metav1.DeleteOptions{
+				GracePeriodSeconds: rollingUpdate.TerminationGracePeriodSeconds,
+			},
+		}
+		return kubeClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(eviction)
+	}
+
+	var opts []client.DeleteOption
+	if rollingUpdate.TerminationGracePeriodSeconds != nil {
+		opts = append(opts, client.GracePeriodSeconds(*rollingUpdate.TerminationGracePeriodSeconds))
+	}
+	return c.Delete(context.TODO(), pod, opts...)
+}
+
+// IsEvictionBlocked returns true if the eviction was rejected because of a PodDisruptionBudget,
+// with the delay before retrying it
+func IsEvictionBlocked(err error) (bool, time.Duration) {
+	if !errors.IsTooManyRequests(err) {
+		return false, 0
+	}
+	if seconds, ok := errors.SuggestsClientDelay(err); ok && seconds > 0 {
+		return true, time.Duration(seconds) * time.Second
+	}
+	return true, DefaultEvictionBackoff
+}
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package pod
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/errors"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+// ApplyResourcesFormulas computes the resources defined by the formulas for a specific Node
+// and sets them in the ResourceRequirements.
+func ApplyResourcesFormulas(resources *corev1.ResourceRequirements, formulas *datadoghqv1alpha1.ExtendedDaemonsetSettingResourcesFormulas, node *corev1.Node) error {
+	if formulas == nil || node == nil {
+		return nil
+	}
+
+	var errs []error
+	if len(formulas.Requests) > 0 {
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		errs = append(errs, applyResourceFormulaList(resources.Requests, formulas.Requests, node)...)
+	}
+	if len(formulas.Limits) > 0 {
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		errs = append(errs, applyResourceFormulaList(resources.Limits, formulas.Limits, node)...)
+	}
+	return errors.NewAggregate(errs)
+}
+
+func applyResourceFormulaList(list corev1.ResourceList, formulas []datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula, node *corev1.Node) []error {
+	var errs []error
+	for id := range formulas {
+		quantity, err := ComputeResourceFormula(&formulas[id], node)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		list[formulas[id].Name] = quantity
+	}
+	return errs
+}
+
+// ComputeResourceFormula returns the resource quantity computed by the formula for a specific Node.
+func ComputeResourceFormula(formula *datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula, node *corev1.Node) (resource.Quantity, error) {
+	if err := ValidateResourceFormula(formula); err != nil {
+		return resource.Quantity{}, err
+	}
+
+	nodeResources := node.Status.Allocatable
+	if formula.NodeResourceSource == datadoghqv1alpha1.NodeResourceSourceCapacity {
+		nodeResources = node.Status.Capacity
+	}
+
+	format := resource.DecimalSI
+	var milliValue int64
+	if formula.Base != nil {
+		milliValue += formula.Base.MilliValue()
+		format = formula.Base.Format
+	}
+	if formula.PerCPU != nil {
+		nodeCPU := nodeResources[corev1.ResourceCPU]
+		milliValue += formula.PerCPU.MilliValue() * nodeCPU.MilliValue() / 1000
+		format = formula.PerCPU.Format
+	}
+	if formula.PerMaxPods != nil {
+		nodePods := nodeResources[corev1.ResourcePods]
+		milliValue += formula.PerMaxPods.MilliValue() * nodePods.Value()
+		format = formula.PerMaxPods.Format
+	}
+	if formula.Min != nil && milliValue < formula.Min.MilliValue() {
+		milliValue = formula.Min.MilliValue()
+	}
+	if formula.Max != nil && milliValue > formula.Max.MilliValue() {
+		milliValue = formula.Max.MilliValue()
+	}
+
+	if formula.Name == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(milliValue, format), nil
+	}
+	// other resources are expressed in whole units, round up
+	value := milliValue / 1000
+	if milliValue%1000 > 0 {
+		value++
+	}
+	return *resource.NewQuantity(value, format), nil
+}
+
+// ValidateResourceFormula returns an error if the formula is not valid.
+func ValidateResourceFormula(formula *datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula) error {
+	if formula.Name == "" {
+		return fmt.Errorf("resource formula without resource name")
+	}
+	if formula.Min != nil && formula.Max != nil && formula.Min.Cmp(*formula.Max) > 0 {
+		return fmt.Errorf("resource formula %s: min (%s) is greater than max (%s)", formula.Name, formula.Min.String(), formula.Max.String())
+	}
+	switch formula.NodeResourceSource {
+	case "", datadoghqv1alpha1.NodeResourceSourceAllocatable, datadoghqv1alpha1.NodeResourceSourceCapacity:
+	default:
+		return fmt.Errorf("resource formula %s: unknown node resource source %s", formula.Name, formula.NodeResourceSource)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package pod
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	ctrltest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func newQuantity(value string) *resource.Quantity {
+	q := resource.MustParse(value)
+	return &q
+}
+
+func TestComputeResourceFormula(t *testing.T) {
+	node := ctrltest.NewNode("node1", &ctrltest.NewNodeOptions{
+		Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:  resource.MustParse("3500m"),
+			corev1.ResourcePods: resource.MustParse("100"),
+		},
+		Capacity: corev1.ResourceList{
+			corev1.ResourceCPU:  resource.MustParse("4"),
+			corev1.ResourcePods: resource.MustParse("110"),
+		},
+	})
+
+	tests := []struct {
+		name    string
+		formula *datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula
+		want    resource.Quantity
+		wantErr bool
+	}{
+		{
+			name: "base only",
+			formula: &datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+				Name: corev1.ResourceCPU,
+				Base: newQuantity("100m"),
+			},
+			want: resource.MustParse("100m"),
+		},
+		{
+			name: "cpu per cpu from allocatable",
+			formula: &datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+				Name:   corev1.ResourceCPU,
+				Base:   newQuantity("100m"),
+				PerCPU: newQuantity("20m"),
+			},
+			want: resource.MustParse("170m"),
+		},
+		{
+			name: "cpu per cpu from capacity",
+			formula: &datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+				Name:               corev1.ResourceCPU,
+				Base:               newQuantity("100m"),
+				PerCPU:             newQuantity("20m"),
+				NodeResourceSource: datadoghqv1alpha1.NodeResourceSourceCapacity,
+			},
+			want: resource.MustParse("180m"),
+		},
+		{
+			name: "memory per max pods",
+			formula: &datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+				Name:       corev1.ResourceMemory,
+				Base:       newQuantity("200Mi"),
+				PerMaxPods: newQuantity("1Mi"),
+			},
+			want: resource.MustParse("300Mi"),
+		},
+		{
+			name: "clamped to max",
+			formula: &datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+				Name:       corev1.ResourceMemory,
+				Base:       newQuantity("200Mi"),
+				PerMaxPods: newQuantity("1Mi"),
+				Max:        newQuantity("256Mi"),
+			},
+			want: resource.MustParse("256Mi"),
+		},
+		{
+			name: "clamped to min",
+			formula: &datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+				Name:   corev1.ResourceCPU,
+				PerCPU: newQuantity("10m"),
+				Min:    newQuantity("50m"),
+			},
+			want: resource.MustParse("50m"),
+		},
+		{
+			name: "min greater than max",
+			formula: &datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+				Name: corev1.ResourceCPU,
+				Min:  newQuantity("2"),
+				Max:  newQuantity("1"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown node resource source",
+			formula: &datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+				Name:               corev1.ResourceCPU,
+				NodeResourceSource: "foo",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ComputeResourceFormula(tt.formula, node)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ComputeResourceFormula() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.Cmp(tt.want) != 0 {
+				t.Errorf("ComputeResourceFormula() = %s, want %s", got.String(), tt.want.String())
+			}
+		})
+	}
+}
+
+func TestApplyResourcesFormulas(t *testing.T) {
+	node := ctrltest.NewNode("node1", &ctrltest.NewNodeOptions{
+		Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("8"),
+		},
+	})
+	resources := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("100Mi"),
+		},
+	}
+	formulas := &datadoghqv1alpha1.ExtendedDaemonsetSettingResourcesFormulas{
+		Requests: []datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+			{Name: corev1.ResourceCPU, PerCPU: newQuantity("25m")},
+		},
+		Limits: []datadoghqv1alpha1.ExtendedDaemonsetSettingResourceFormula{
+			{Name: corev1.ResourceCPU, PerCPU: newQuantity("50m")},
+		},
+	}
+
+	if err := ApplyResourcesFormulas(resources, formulas, node); err != nil {
+		t.Fatalf("ApplyResourcesFormulas() error = %v", err)
+	}
+
+	if got := resources.Requests[corev1.ResourceCPU]; got.Cmp(resource.MustParse("200m")) != 0 {
+		t.Errorf("requests.cpu = %s, want 200m", got.String())
+	}
+	if got := resources.Requests[corev1.ResourceMemory]; got.Cmp(resource.MustParse("100Mi")) != 0 {
+		t.Errorf("requests.memory = %s, want 100Mi", got.String())
+	}
+	if got := resources.Limits[corev1.ResourceCPU]; got.Cmp(resource.MustParse("400m")) != 0 {
+		t.Errorf("limits.cpu = %s, want 400m", got.String())
+	}
+}
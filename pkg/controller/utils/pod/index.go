@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package pod
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// NodeNameIndexField field index of the pods by Node name
+	NodeNameIndexField = "spec.nodeName"
+	// ControllerIndexField field index of the pods by controller owner, the values are built with ControllerIndexValue
+	ControllerIndexField = "metadata.controller"
+)
+
+// ControllerIndexValue returns the ControllerIndexField value of the pods controlled by the kind/name owner
+func ControllerIndexValue(kind, name string) string {
+	return kind + "/" + name
+}
+
+// AddFieldIndexes registers the pods field indexes in the manager cache
+func AddFieldIndexes(indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(&corev1.Pod{}, NodeNameIndexField, indexPodByNodeName); err != nil {
+		return err
+	}
+	return indexer.IndexField(&corev1.Pod{}, ControllerIndexField, indexPodByController)
+}
+
+func indexPodByNodeName(obj runtime.Object) []string {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return nil
+	}
+	return []string{pod.Spec.NodeName}
+}
+
+func indexPodByController(obj runtime.Object) []string {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil
+	}
+	return []string{ControllerIndexValue(owner.Kind, owner.Name)}
+}
@@ -23,12 +23,14 @@ package pod
 
 import (
 	"sort"
+	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/config"
 	"github.com/datadog/extendeddaemonset/pkg/controller/utils/affinity"
 )
 
@@ -140,11 +142,35 @@ func IsPodRestarting(pod *v1.Pod) (bool, datadoghqv1alpha1.ExtendedDaemonSetStat
 	return false, ""
 }
 
-// HasPodSchedulerIssue returns true if a pod remained unscheduled for more than 10 minutes
+// IsPodInCrashLoopBackOff returns true if a container of the pod is waiting to restart after crashing
+func IsPodInCrashLoopBackOff(pod *v1.Pod) bool {
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.State.Waiting != nil && s.State.Waiting.Reason == string(datadoghqv1alpha1.ExtendedDaemonSetStatusReasonCLB) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMaxContainerRestartCount returns the highest restart count of the pod containers
+func GetMaxContainerRestartCount(pod *v1.Pod) int32 {
+	var maxRestartCount int32
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.RestartCount > maxRestartCount {
+			maxRestartCount = s.RestartCount
+		}
+	}
+	return maxRestartCount
+}
+
+// HasPodSchedulerIssue returns true if a pod remained unscheduled for more than timeout (10 minutes if not set)
 // or if it stayed in `Terminating` state for longer than its grace period.
-func HasPodSchedulerIssue(pod *v1.Pod) bool {
+func HasPodSchedulerIssue(pod *v1.Pod, timeout time.Duration) bool {
+	if timeout <= 0 {
+		timeout = config.DefaultSchedulerIssueTimeout
+	}
 	_, isScheduled := IsPodScheduled(pod)
-	if !isScheduled && pod.CreationTimestamp.Add(10*time.Minute).Before(time.Now()) {
+	if !isScheduled && pod.CreationTimestamp.Add(timeout).Before(time.Now()) {
 		return true
 	}
 
@@ -156,6 +182,20 @@ func HasPodSchedulerIssue(pod *v1.Pod) bool {
 	return false
 }
 
+// IsPodBlockedByResourcesConstraints returns true if a pod can't run on its Node due to resource constraints:
+// the pod is unschedulable, or it has been rejected by the kubelet (OutOfcpu, OutOfmemory, ...).
+// It also returns since when the pod is blocked.
+func IsPodBlockedByResourcesConstraints(pod *v1.Pod) (bool, time.Time) {
+	if pod.Status.Phase == v1.PodFailed && strings.HasPrefix(pod.Status.Reason, "OutOf") {
+		return true, pod.CreationTimestamp.Time
+	}
+	_, condition := GetPodCondition(&pod.Status, v1.PodScheduled)
+	if condition != nil && condition.Status == v1.ConditionFalse && condition.Reason == v1.PodReasonUnschedulable {
+		return true, condition.LastTransitionTime.Time
+	}
+	return false, time.Time{}
+}
+
 // UpdatePodCondition updates existing pod condition or creates a new one. Sets LastTransitionTime to now if the
 // status has changed.
 // Returns true if pod condition has changed or has been added.
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package shard spreads the ExtendedDaemonSets between several operator replicas,
+// each replica reconciling only the ExtendedDaemonSets of its shard.
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Shard selects the ExtendedDaemonSets reconciled by an operator replica.
+// A nil Shard contains all the ExtendedDaemonSets.
+type Shard struct {
+	// Count number of shards, the ExtendedDaemonSets are spread by the hash of their namespace and name.
+	// The hash sharding is disabled if Count <= 1.
+	Count int
+	// Index of the shard of this replica, between 0 and Count-1
+	Index int
+	// LabelSelector only the ExtendedDaemonSets matching this selector are in the shard, if not nil
+	LabelSelector labels.Selector
+}
+
+// Validate returns an error if the shard configuration is invalid
+func (s *Shard) Validate() error {
+	if s == nil || s.Count <= 1 {
+		return nil
+	}
+	if s.Index < 0 || s.Index >= s.Count {
+		return fmt.Errorf("invalid shard index %d, must be between 0 and %d", s.Index, s.Count-1)
+	}
+	return nil
+}
+
+// IsEnabled returns true if the replica only reconciles a subset of the ExtendedDaemonSets
+func (s *Shard) IsEnabled() bool {
+	return s != nil && (s.Count > 1 || s.LabelSelector != nil && !s.LabelSelector.Empty())
+}
+
+// ID returns a name identifying the shard, used to hold a lock per shard. It is empty if the sharding is disabled.
+func (s *Shard) ID() string {
+	if !s.IsEnabled() {
+		return ""
+	}
+	var id string
+	if s.Count > 1 {
+		id = fmt.Sprintf("shard-%d-of-%d", s.Index, s.Count)
+	}
+	if s.LabelSelector != nil && !s.LabelSelector.Empty() {
+		if id != "" {
+			id += "-"
+		}
+		id += fmt.Sprintf("selector-%08x", hash(s.LabelSelector.String()))
+	}
+	return id
+}
+
+// Contains returns true if the ExtendedDaemonSet is reconciled by this replica
+func (s *Shard) Contains(eds metav1.Object) bool {
+	if !s.IsEnabled() {
+		return true
+	}
+	if s.Count > 1 && int(hash(eds.GetNamespace()+"/"+eds.GetName())%uint32(s.Count)) != s.Index {
+		return false
+	}
+	if s.LabelSelector != nil && !s.LabelSelector.Matches(labels.Set(eds.GetLabels())) {
+		return false
+	}
+	return true
+}
+
+// Predicate filters out the events of the ExtendedDaemonSets not in the shard.
+// On update, the event is kept if the old or the new ExtendedDaemonSet is in the shard, so a relabeled
+// ExtendedDaemonSet leaving the shard is seen one last time.
+func (s *Shard) Predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(evt event.CreateEvent) bool {
+			return s.Contains(evt.Meta)
+		},
+		UpdateFunc: func(evt event.UpdateEvent) bool {
+			return s.Contains(evt.MetaOld) || s.Contains(evt.MetaNew)
+		},
+		DeleteFunc: func(evt event.DeleteEvent) bool {
+			return s.Contains(evt.Meta)
+		},
+		GenericFunc: func(evt event.GenericEvent) bool {
+			return s.Contains(evt.Meta)
+		},
+	}
+}
+
+func hash(value string) uint32 {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(value))
+	return hasher.Sum32()
+}
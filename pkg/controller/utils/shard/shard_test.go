@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestShard_Contains(t *testing.T) {
+	teamA, _ := labels.Parse("team=a")
+	eds := func(name string, edsLabels map[string]string) metav1.Object {
+		return &metav1.ObjectMeta{Namespace: "bar", Name: name, Labels: edsLabels}
+	}
+
+	tests := []struct {
+		name  string
+		shard *Shard
+		eds   metav1.Object
+		want  bool
+	}{
+		{
+			name:  "nil shard",
+			shard: nil,
+			eds:   eds("foo", nil),
+			want:  true,
+		},
+		{
+			name:  "single shard",
+			shard: &Shard{Count: 1},
+			eds:   eds("foo", nil),
+			want:  true,
+		},
+		{
+			name:  "label selector match",
+			shard: &Shard{LabelSelector: teamA},
+			eds:   eds("foo", map[string]string{"team": "a"}),
+			want:  true,
+		},
+		{
+			name:  "label selector mismatch",
+			shard: &Shard{LabelSelector: teamA},
+			eds:   eds("foo", map[string]string{"team": "b"}),
+			want:  false,
+		},
+		{
+			name:  "hash in the shard, label selector mismatch",
+			shard: &Shard{Count: 2, Index: int(hash("bar/foo") % 2), LabelSelector: teamA},
+			eds:   eds("foo", nil),
+			want:  false,
+		},
+		{
+			name:  "hash in another shard",
+			shard: &Shard{Count: 2, Index: int(hash("bar/foo")%2+1) % 2},
+			eds:   eds("foo", nil),
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.shard.Contains(tt.eds); got != tt.want {
+				t.Errorf("Shard.Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShard_ContainsSpread(t *testing.T) {
+	// each ExtendedDaemonSet belongs to exactly one of the shards
+	const count, nbEDS = 3, 300
+	nbByShard := make([]int, count)
+	for i := 0; i < nbEDS; i++ {
+		eds := &metav1.ObjectMeta{Namespace: "bar", Name: fmt.Sprintf("foo-%d", i)}
+		nbShards := 0
+		for index := 0; index < count; index++ {
+			if (&Shard{Count: count, Index: index}).Contains(eds) {
+				nbShards++
+				nbByShard[index]++
+			}
+		}
+		if nbShards != 1 {
+			t.Fatalf("ExtendedDaemonSet %s is in %d shards, want 1", eds.Name, nbShards)
+		}
+	}
+	for index, nb := range nbByShard {
+		if nb < nbEDS/count/2 {
+			t.Errorf("shard %d contains %d ExtendedDaemonSets, want about %d", index, nb, nbEDS/count)
+		}
+	}
+}
+
+func TestShard_ValidateAndID(t *testing.T) {
+	teamA, _ := labels.Parse("team=a")
+	tests := []struct {
+		name    string
+		shard   *Shard
+		wantErr bool
+		wantID  string
+	}{
+		{
+			name:   "disabled",
+			shard:  &Shard{},
+			wantID: "",
+		},
+		{
+			name:   "hash",
+			shard:  &Shard{Count: 3, Index: 2},
+			wantID: "shard-2-of-3",
+		},
+		{
+			name:   "hash and label selector",
+			shard:  &Shard{Count: 3, Index: 0, LabelSelector: teamA},
+			wantID: fmt.Sprintf("shard-0-of-3-selector-%08x", hash("team=a")),
+		},
+		{
+			name:    "invalid index",
+			shard:   &Shard{Count: 3, Index: 3},
+			wantErr: true,
+			wantID:  "shard-3-of-3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.shard.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Shard.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := tt.shard.ID(); got != tt.wantID {
+				t.Errorf("Shard.ID() = %q, want %q", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestShard_Predicate(t *testing.T) {
+	teamA, _ := labels.Parse("team=a")
+	p := (&Shard{LabelSelector: teamA}).Predicate()
+	inShard := &metav1.ObjectMeta{Namespace: "bar", Name: "foo", Labels: map[string]string{"team": "a"}}
+	outShard := &metav1.ObjectMeta{Namespace: "bar", Name: "foo", Labels: map[string]string{"team": "b"}}
+
+	if p.Create(event.CreateEvent{Meta: outShard}) {
+		t.Errorf("Predicate().Create() = true for an ExtendedDaemonSet out of the shard")
+	}
+	if !p.Update(event.UpdateEvent{MetaOld: inShard, MetaNew: outShard}) {
+		t.Errorf("Predicate().Update() = false for an ExtendedDaemonSet leaving the shard")
+	}
+	if p.Delete(event.DeleteEvent{Meta: outShard}) {
+		t.Errorf("Predicate().Delete() = true for an ExtendedDaemonSet out of the shard")
+	}
+}
@@ -7,10 +7,12 @@ package plugin
 
 import (
 	"fmt"
+	"os/user"
 	"time"
 
 	"github.com/hako/durafmt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
 )
@@ -29,3 +31,24 @@ func getCanaryRS(eds *v1alpha1.ExtendedDaemonSet) string {
 func getDuration(obj *metav1.ObjectMeta) string {
 	return durafmt.ParseShort(time.Since(obj.CreationTimestamp.Time)).String()
 }
+
+// getActor returns the user running the command: the kubeconfig user of the current context, or the OS user.
+// It is informative only, it is not the identity authenticated by the API server.
+func getActor(configFlags *genericclioptions.ConfigFlags) string {
+	if configFlags.AuthInfoName != nil && *configFlags.AuthInfoName != "" {
+		return *configFlags.AuthInfoName
+	}
+	if rawConfig, err := configFlags.ToRawKubeConfigLoader().RawConfig(); err == nil {
+		contextName := rawConfig.CurrentContext
+		if configFlags.Context != nil && *configFlags.Context != "" {
+			contextName = *configFlags.Context
+		}
+		if context, ok := rawConfig.Contexts[contextName]; ok && context.AuthInfo != "" {
+			return context.AuthInfo
+		}
+	}
+	if osUser, err := user.Current(); err == nil {
+		return osUser.Username
+	}
+	return ""
+}
@@ -37,6 +37,8 @@ func NewCmdExtendedDaemonset(streams genericclioptions.IOStreams) *cobra.Command
 	cmd.AddCommand(NewCmdCanary(streams))
 	cmd.AddCommand(NewCmdGet(streams))
 	cmd.AddCommand(NewCmdGetERS(streams))
+	cmd.AddCommand(NewCmdHistory(streams))
+	cmd.AddCommand(NewCmdRecommend(streams))
 
 	o.configFlags.AddFlags(cmd.Flags())
 
@@ -27,6 +27,9 @@ var (
 	failExample = `
     # %[1]s a canary deployment
     kubectl eds %[1]s foo
+
+    # %[1]s a canary deployment, the reason is recorded in the ExtendedDaemonSet status history
+    kubectl eds %[1]s foo --reason "latency regression"
 `
 )
 
@@ -42,6 +45,7 @@ type FailOptions struct {
 	userNamespace             string
 	userExtendedDaemonSetName string
 	failStatus                bool
+	reason                    string
 }
 
 // NewFailOptions provides an instance of GetOptions with default values
@@ -76,6 +80,7 @@ func NewCmdFail(streams genericclioptions.IOStreams) *cobra.Command {
 	}
 
 	o.configFlags.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.reason, "reason", "", "reason of the action, recorded in the ExtendedDaemonSet status history")
 
 	return cmd
 }
@@ -101,6 +106,7 @@ func NewCmdReset(streams genericclioptions.IOStreams) *cobra.Command {
 	}
 
 	o.configFlags.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.reason, "reason", "", "reason of the action, recorded in the ExtendedDaemonSet status history")
 
 	return cmd
 }
@@ -172,6 +178,11 @@ func (o *FailOptions) Run() error {
 		}
 	}
 	newEds.Annotations[v1alpha1.ExtendedDaemonSetCanaryFailedAnnotationKey] = fmt.Sprintf("%v", o.failStatus)
+	historyAction := v1alpha1.ExtendedDaemonSetHistoryActionCanaryFailed
+	if !o.failStatus {
+		historyAction = v1alpha1.ExtendedDaemonSetHistoryActionCanaryReset
+	}
+	v1alpha1.SetExtendedDaemonSetActionAnnotations(newEds, historyAction, getActor(o.configFlags), o.reason)
 
 	if err = o.client.Update(context.TODO(), newEds); err != nil {
 		return fmt.Errorf("unable to fail or reset ExtendedDaemonset deployment, err: %v", err)
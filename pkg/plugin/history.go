@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/hako/durafmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+var (
+	historyExample = `
+	# view the extendeddaemonsetreplicasets of the extendeddaemonset foo, with the cause of each rollout,
+	# including the deleted ones recorded in the extendeddaemonset status history
+	%[1]s history foo
+`
+)
+
+// HistoryOptions provides information required to display the ExtendedDaemonSet history
+type HistoryOptions struct {
+	configFlags *genericclioptions.ConfigFlags
+	args        []string
+
+	client client.Client
+
+	genericclioptions.IOStreams
+
+	userNamespace             string
+	userExtendedDaemonSetName string
+}
+
+// NewHistoryOptions provides an instance of HistoryOptions with default values
+func NewHistoryOptions(streams genericclioptions.IOStreams) *HistoryOptions {
+	return &HistoryOptions{
+		configFlags: genericclioptions.NewConfigFlags(false),
+
+		IOStreams: streams,
+	}
+}
+
+// NewCmdHistory provides a cobra command wrapping HistoryOptions
+func NewCmdHistory(streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewHistoryOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:          "history [ExtendedDaemonSet name]",
+		Short:        "view the ExtendedDaemonSetReplicaSets of an ExtendedDaemonSet and their change cause",
+		Example:      fmt.Sprintf(historyExample, "kubectl"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(c, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	o.configFlags.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Complete sets all information required for processing the command
+func (o *HistoryOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.args = args
+	var err error
+
+	clientConfig := o.configFlags.ToRawKubeConfigLoader()
+	// Create the Client for Read/Write operations.
+	o.client, err = NewClient(clientConfig)
+	if err != nil {
+		return fmt.Errorf("unable to instantiate client, err: %v", err)
+	}
+
+	o.userNamespace, _, err = clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+
+	ns, err2 := cmd.Flags().GetString("namespace")
+	if err2 != nil {
+		return err
+	}
+	if ns != "" {
+		o.userNamespace = ns
+	}
+
+	if len(args) > 0 {
+		o.userExtendedDaemonSetName = args[0]
+	}
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (o *HistoryOptions) Validate() error {
+	if len(o.args) != 1 {
+		return fmt.Errorf("the extendeddaemonset name is required")
+	}
+
+	return nil
+}
+
+// Run use to run the command
+func (o *HistoryOptions) Run() error {
+	eds := &v1alpha1.ExtendedDaemonSet{}
+	err := o.client.Get(context.TODO(), client.ObjectKey{Namespace: o.userNamespace, Name: o.userExtendedDaemonSetName}, eds)
+	if err != nil && errors.IsNotFound(err) {
+		return fmt.Errorf("ExtendedDaemonSet %s/%s not found", o.userNamespace, o.userExtendedDaemonSetName)
+	} else if err != nil {
+		return fmt.Errorf("unable to get ExtendedDaemonSet, err: %v", err)
+	}
+
+	ersList := &v1alpha1.ExtendedDaemonSetReplicaSetList{}
+	err = o.client.List(context.TODO(), ersList, client.InNamespace(o.userNamespace), client.MatchingLabels{v1alpha1.ExtendedDaemonSetNameLabelKey: eds.Name})
+	if err != nil {
+		return fmt.Errorf("unable to list ExtendedDaemonSetReplicaset, err: %v", err)
+	}
+
+	table := newHistoryTable(o.Out)
+	for _, rollout := range getRollouts(eds, ersList.Items) {
+		status, changeCause := "deleted", rollout.changeCause
+		if rollout.ers != nil {
+			status = rollout.ers.Status.Status
+			if changeCause == "" {
+				changeCause = rollout.ers.Annotations[v1alpha1.ExtendedDaemonSetReplicaSetChangeCauseAnnotationKey]
+			}
+		}
+		if changeCause == "" {
+			changeCause = "-"
+		}
+		data := []string{rollout.name, getReplicaSetRole(eds, rollout.name), status, durafmt.ParseShort(time.Since(rollout.time.Time)).String(), changeCause}
+		table.Append(data)
+	}
+
+	table.Render() // Send output
+
+	return nil
+}
+
+// rolloutItem a replicaset deployed by the ExtendedDaemonSet, nil ers if it was deleted
+type rolloutItem struct {
+	name        string
+	time        metav1.Time
+	changeCause string
+	ers         *v1alpha1.ExtendedDaemonSetReplicaSet
+}
+
+// getRollouts returns the replicasets recorded by the RolloutStarted entries of the ExtendedDaemonSet history,
+// including the deleted ones, and the live replicasets, from the oldest to the newest.
+func getRollouts(eds *v1alpha1.ExtendedDaemonSet, ersList []v1alpha1.ExtendedDaemonSetReplicaSet) []*rolloutItem {
+	var rollouts []*rolloutItem
+	rolloutByName := map[string]*rolloutItem{}
+	for _, entry := range eds.Status.History {
+		if entry.Action != v1alpha1.ExtendedDaemonSetHistoryActionRolloutStarted || entry.ReplicaSet == "" {
+			continue
+		}
+		if _, found := rolloutByName[entry.ReplicaSet]; found {
+			continue
+		}
+		rollout := &rolloutItem{name: entry.ReplicaSet, time: entry.Time, changeCause: entry.ChangeCause}
+		rolloutByName[rollout.name] = rollout
+		rollouts = append(rollouts, rollout)
+	}
+	for i := range ersList {
+		ers := &ersList[i]
+		if rollout, found := rolloutByName[ers.Name]; found {
+			rollout.ers = ers
+			continue
+		}
+		rollouts = append(rollouts, &rolloutItem{name: ers.Name, time: ers.CreationTimestamp, ers: ers})
+	}
+	sort.SliceStable(rollouts, func(i, j int) bool {
+		return rollouts[i].time.Before(&rollouts[j].time)
+	})
+	return rollouts
+}
+
+// getReplicaSetRole returns "active" or "canary" if the replicaset is the active or the canary replicaset of the ExtendedDaemonSet
+func getReplicaSetRole(eds *v1alpha1.ExtendedDaemonSet, name string) string {
+	switch name {
+	case eds.Status.ActiveReplicaSet:
+		return "active"
+	case getCanaryRS(eds):
+		return "canary"
+	}
+	return "-"
+}
+
+func newHistoryTable(out io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Name", "Role", "Status", "Age", "Change Cause"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(false)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderLine(false)
+	table.SetAutoWrapText(false)
+
+	return table
+}
@@ -29,6 +29,9 @@ var (
 	pauseExample = `
 	# %[1]s a canary deployment
 	kubectl eds %[1]s foo
+
+	# %[1]s a canary deployment, the reason is recorded in the ExtendedDaemonSet status history
+	kubectl eds %[1]s foo --reason "investigating memory usage"
 `
 )
 
@@ -44,6 +47,7 @@ type PauseOptions struct {
 	userNamespace             string
 	userExtendedDaemonSetName string
 	pauseStatus               bool
+	reason                    string
 }
 
 // NewPauseOptions provides an instance of GetOptions with default values
@@ -78,6 +82,7 @@ func NewCmdPause(streams genericclioptions.IOStreams) *cobra.Command {
 	}
 
 	o.configFlags.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.reason, "reason", "", "reason of the action, recorded in the ExtendedDaemonSet status history")
 
 	return cmd
 }
@@ -103,6 +108,7 @@ func NewCmdUnpause(streams genericclioptions.IOStreams) *cobra.Command {
 	}
 
 	o.configFlags.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.reason, "reason", "", "reason of the action, recorded in the ExtendedDaemonSet status history")
 
 	return cmd
 }
@@ -175,6 +181,11 @@ func (o *PauseOptions) Run() error {
 		}
 	}
 	newEds.Annotations[v1alpha1.ExtendedDaemonSetCanaryPausedAnnotationKey] = fmt.Sprintf("%v", o.pauseStatus)
+	action := v1alpha1.ExtendedDaemonSetHistoryActionCanaryPaused
+	if !o.pauseStatus {
+		action = v1alpha1.ExtendedDaemonSetHistoryActionCanaryUnpaused
+	}
+	v1alpha1.SetExtendedDaemonSetActionAnnotations(newEds, action, getActor(o.configFlags), o.reason)
 
 	if err = o.client.Update(context.TODO(), newEds); err != nil {
 		return fmt.Errorf("unable to %s ExtendedDaemonset deployment, err: %v", fmt.Sprintf("%v", o.pauseStatus), err)
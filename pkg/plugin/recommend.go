@@ -0,0 +1,285 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/recommender"
+)
+
+var (
+	recommendExample = `
+	# suggest resources for the ExtendedDaemonSet foo, grouped by its ExtendedDaemonsetSettings
+	kubectl eds recommend foo
+	# suggest resources for the ExtendedDaemonSet foo, grouped by node pool
+	kubectl eds recommend foo --group-by-label=cloud.google.com/gke-nodepool
+	# create or update the ExtendedDaemonsetSettings with the recommended resources
+	kubectl eds recommend foo --group-by-label=cloud.google.com/gke-nodepool --apply
+	# compute the recommendations from the usage sampled every minute during one hour
+	kubectl eds recommend foo --samples=60 --sample-interval=1m
+`
+)
+
+// RecommendOptions provides information required to compute ExtendedDaemonSet resources recommendations
+type RecommendOptions struct {
+	configFlags *genericclioptions.ConfigFlags
+	args        []string
+
+	client        client.Client
+	metricsSource recommender.UsageSource
+
+	genericclioptions.IOStreams
+
+	userNamespace             string
+	userExtendedDaemonSetName string
+	groupByLabel              string
+	apply                     bool
+	samples                   int
+	sampleInterval            time.Duration
+	recommenderOptions        recommender.Options
+}
+
+// NewRecommendOptions provides an instance of RecommendOptions with default values
+func NewRecommendOptions(streams genericclioptions.IOStreams) *RecommendOptions {
+	return &RecommendOptions{
+		configFlags: genericclioptions.NewConfigFlags(false),
+
+		IOStreams: streams,
+
+		recommenderOptions: recommender.DefaultOptions(),
+	}
+}
+
+// NewCmdRecommend provides a cobra command wrapping RecommendOptions
+func NewCmdRecommend(streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewRecommendOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:          "recommend [ExtendedDaemonSet name]",
+		Short:        "recommend ExtendedDaemonSet pod resources from the pods usage",
+		Example:      recommendExample,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(c, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&o.groupByLabel, "group-by-label", "", "group the nodes by the value of this label instead of the ExtendedDaemonsetSettings node selectors")
+	cmd.Flags().BoolVar(&o.apply, "apply", false, "create or update the ExtendedDaemonsetSettings instead of printing them")
+	cmd.Flags().Float64Var(&o.recommenderOptions.Percentile, "percentile", recommender.DefaultPercentile, "usage percentile used for the requests, between 0 and 1")
+	cmd.Flags().Float64Var(&o.recommenderOptions.RequestsMargin, "requests-margin", recommender.DefaultRequestsMargin, "ratio added to the requests")
+	cmd.Flags().Float64Var(&o.recommenderOptions.LimitsMargin, "limits-margin", recommender.DefaultLimitsMargin, "ratio added to the highest memory usage for the memory limit")
+	cmd.Flags().IntVar(&o.recommenderOptions.MinSamples, "min-samples", 1, "minimum number of pods in a node group to produce a recommendation")
+	cmd.Flags().IntVar(&o.samples, "samples", 1, "number of usage samples taken for each pod")
+	cmd.Flags().DurationVar(&o.sampleInterval, "sample-interval", time.Minute, "interval between two usage samples")
+	cmd.Flags().BoolVar(&o.recommenderOptions.AllowLimitDecrease, "allow-limit-decrease", false, "allow a memory limit lower than the current limit of the pods")
+	o.configFlags.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Complete sets all information required for processing the command
+func (o *RecommendOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.args = args
+	var err error
+
+	clientConfig := o.configFlags.ToRawKubeConfigLoader()
+	// Create the Client for Read/Write operations.
+	o.client, err = NewClient(clientConfig)
+	if err != nil {
+		return fmt.Errorf("unable to instantiate client, err: %v", err)
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("unable to get rest client config, err: %v", err)
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("unable to instantiate metrics client, err: %v", err)
+	}
+	o.metricsSource = recommender.NewMetricsServerSource(clientset.Discovery().RESTClient())
+
+	o.userNamespace, _, err = clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+
+	ns, err2 := cmd.Flags().GetString("namespace")
+	if err2 != nil {
+		return err
+	}
+	if ns != "" {
+		o.userNamespace = ns
+	}
+
+	if len(args) > 0 {
+		o.userExtendedDaemonSetName = args[0]
+	}
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (o *RecommendOptions) Validate() error {
+	if len(o.args) < 1 {
+		return fmt.Errorf("the extendeddaemonset name is required")
+	}
+	if o.samples < 1 {
+		return fmt.Errorf("samples should be greater than 0, current value: %d", o.samples)
+	}
+	if o.samples > 1 && o.sampleInterval <= 0 {
+		return fmt.Errorf("sample-interval should be positive, current value: %v", o.sampleInterval)
+	}
+
+	return o.recommenderOptions.Validate()
+}
+
+// Run use to run the command
+func (o *RecommendOptions) Run() error {
+	eds := &v1alpha1.ExtendedDaemonSet{}
+	err := o.client.Get(context.TODO(), client.ObjectKey{Namespace: o.userNamespace, Name: o.userExtendedDaemonSetName}, eds)
+	if err != nil && errors.IsNotFound(err) {
+		return fmt.Errorf("ExtendedDaemonSet %s/%s not found", o.userNamespace, o.userExtendedDaemonSetName)
+	} else if err != nil {
+		return fmt.Errorf("unable to get ExtendedDaemonSet, err: %v", err)
+	}
+
+	podSelector := labels.Set{v1alpha1.ExtendedDaemonSetNameLabelKey: eds.Name}.AsSelectorPreValidated()
+	podList := &corev1.PodList{}
+	if err = o.client.List(context.TODO(), podList, &client.ListOptions{Namespace: eds.Namespace, LabelSelector: podSelector}); err != nil {
+		return fmt.Errorf("unable to list pods, err: %v", err)
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err = o.client.List(context.TODO(), nodeList); err != nil {
+		return fmt.Errorf("unable to list nodes, err: %v", err)
+	}
+
+	usage, err := o.sampleUsage(eds, podSelector)
+	if err != nil {
+		return err
+	}
+
+	settings, err := o.getExtendedDaemonsetSettings(eds)
+	if err != nil {
+		return err
+	}
+
+	var groups []recommender.NodeGroup
+	if o.groupByLabel != "" {
+		groups = recommender.NodeGroupsFromLabel(nodeList.Items, o.groupByLabel)
+		// reuse the ExtendedDaemonsetSettings generated by a previous run
+		for id := range groups {
+			name := recommender.SettingName(eds.Name, groups[id].Name)
+			for settingID := range settings {
+				if settings[settingID].Name == name {
+					groups[id].Setting = &settings[settingID]
+				}
+			}
+		}
+	} else {
+		groups = recommender.NodeGroupsFromSettings(settings)
+	}
+
+	recommendations, err := recommender.Recommend(groups, nodeList.Items, podList.Items, usage, o.recommenderOptions)
+	if err != nil {
+		return err
+	}
+	if len(recommendations) == 0 {
+		fmt.Fprintf(o.ErrOut, "No recommendation for ExtendedDaemonset '%s/%s': no usage found for the node groups\n", eds.Namespace, eds.Name)
+		return nil
+	}
+
+	for id := range recommendations {
+		setting := recommender.BuildExtendedDaemonsetSetting(eds, &recommendations[id])
+		if o.apply {
+			if err = o.applyExtendedDaemonsetSetting(setting); err != nil {
+				return err
+			}
+			continue
+		}
+
+		out, err := yaml.Marshal(setting)
+		if err != nil {
+			return fmt.Errorf("unable to marshal ExtendedDaemonsetSetting, err: %v", err)
+		}
+		fmt.Fprintf(o.Out, "---\n%s", string(out))
+	}
+
+	return nil
+}
+
+// sampleUsage returns the usage samples of the pods, taken every sampleInterval
+func (o *RecommendOptions) sampleUsage(eds *v1alpha1.ExtendedDaemonSet, podSelector labels.Selector) ([]recommender.PodMetrics, error) {
+	var usage []recommender.PodMetrics
+	for i := 0; i < o.samples; i++ {
+		if i > 0 {
+			time.Sleep(o.sampleInterval)
+		}
+		sample, err := o.metricsSource.ListPodMetrics(eds.Namespace, podSelector)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, sample...)
+		if o.samples > 1 {
+			fmt.Fprintf(o.ErrOut, "Usage sample %d/%d collected\n", i+1, o.samples)
+		}
+	}
+	return usage, nil
+}
+
+func (o *RecommendOptions) getExtendedDaemonsetSettings(eds *v1alpha1.ExtendedDaemonSet) ([]v1alpha1.ExtendedDaemonsetSetting, error) {
+	settingList := &v1alpha1.ExtendedDaemonsetSettingList{}
+	if err := o.client.List(context.TODO(), settingList, &client.ListOptions{Namespace: eds.Namespace}); err != nil {
+		return nil, fmt.Errorf("unable to list ExtendedDaemonsetSettings, err: %v", err)
+	}
+
+	var settings []v1alpha1.ExtendedDaemonsetSetting
+	for _, setting := range settingList.Items {
+		if setting.Spec.Reference != nil && setting.Spec.Reference.Name == eds.Name {
+			settings = append(settings, setting)
+		}
+	}
+	return settings, nil
+}
+
+func (o *RecommendOptions) applyExtendedDaemonsetSetting(setting *v1alpha1.ExtendedDaemonsetSetting) error {
+	var err error
+	if setting.ResourceVersion == "" {
+		err = o.client.Create(context.TODO(), setting)
+	} else {
+		err = o.client.Update(context.TODO(), setting)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to apply ExtendedDaemonsetSetting %s/%s, err: %v", setting.Namespace, setting.Name, err)
+	}
+
+	fmt.Fprintf(o.Out, "ExtendedDaemonsetSetting '%s/%s' updated with the recommended resources\n", setting.Namespace, setting.Name)
+	return nil
+}
@@ -38,6 +38,7 @@ type ValidateOptions struct {
 
 	userNamespace             string
 	userExtendedDaemonSetName string
+	reason                    string
 }
 
 // NewValidateOptions provides an instance of GetOptions with default values
@@ -70,6 +71,7 @@ func NewCmdValidate(streams genericclioptions.IOStreams) *cobra.Command {
 	}
 
 	o.configFlags.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.reason, "reason", "", "reason of the action, recorded in the ExtendedDaemonSet status history")
 
 	return cmd
 }
@@ -128,7 +130,7 @@ func (o *ValidateOptions) Run() error {
 	}
 
 	if eds.Status.Canary == nil {
-		return fmt.Errorf("the ExtendedDaemonset is not currently running a canary replicaset")
+		return o.validateSettingsCanary(eds)
 	}
 	rsName := eds.Status.Canary.ReplicaSet
 	newEds := eds.DeepCopy()
@@ -142,6 +144,7 @@ func (o *ValidateOptions) Run() error {
 		}
 	}
 	newEds.Annotations[v1alpha1.ExtendedDaemonSetCanaryValidAnnotationKey] = rsName
+	v1alpha1.SetExtendedDaemonSetActionAnnotations(newEds, v1alpha1.ExtendedDaemonSetHistoryActionCanaryValidated, getActor(o.configFlags), o.reason)
 	if err = o.client.Update(context.TODO(), newEds); err != nil {
 		return fmt.Errorf("unable to valide the canary replicaset, err: %v", err)
 	}
@@ -150,3 +153,30 @@ func (o *ValidateOptions) Run() error {
 
 	return nil
 }
+
+// validateSettingsCanary validates the canary deployment of a settings change on the active replicaset
+func (o *ValidateOptions) validateSettingsCanary(eds *v1alpha1.ExtendedDaemonSet) error {
+	rs := &v1alpha1.ExtendedDaemonSetReplicaSet{}
+	err := o.client.Get(context.TODO(), client.ObjectKey{Namespace: o.userNamespace, Name: eds.Status.ActiveReplicaSet}, rs)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("unable to get ExtendedDaemonSetReplicaSet, err: %v", err)
+	}
+	settingsCanary := rs.Status.SettingsCanary
+	if err != nil || settingsCanary == nil || settingsCanary.State == v1alpha1.ExtendedDaemonSetReplicaSetSettingsCanaryStateValidated {
+		return fmt.Errorf("the ExtendedDaemonset is not currently running a canary replicaset or settings canary")
+	}
+
+	newEds := eds.DeepCopy()
+	if newEds.Annotations == nil {
+		newEds.Annotations = make(map[string]string)
+	}
+	newEds.Annotations[v1alpha1.ExtendedDaemonSetCanaryValidAnnotationKey] = settingsCanary.Hash
+	v1alpha1.SetExtendedDaemonSetActionAnnotations(newEds, v1alpha1.ExtendedDaemonSetHistoryActionCanaryValidated, getActor(o.configFlags), o.reason)
+	if err = o.client.Update(context.TODO(), newEds); err != nil {
+		return fmt.Errorf("unable to valide the settings canary, err: %v", err)
+	}
+
+	fmt.Fprintf(o.Out, "Settings canary '%s' was validated properly for extendeddaemonset %s/%s.\n", settingsCanary.Hash, o.userNamespace, o.userExtendedDaemonSetName)
+
+	return nil
+}
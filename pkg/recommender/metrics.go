@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package recommender
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// metricsAPIPath path of the metrics.k8s.io API served by the metrics-server
+	metricsAPIPath = "/apis/metrics.k8s.io/v1beta1"
+)
+
+// PodMetrics contains the resource usage of a Pod.
+// It mirrors the metrics.k8s.io/v1beta1 PodMetrics resource.
+type PodMetrics struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Timestamp  metav1.Time        `json:"timestamp"`
+	Window     metav1.Duration    `json:"window"`
+	Containers []ContainerMetrics `json:"containers"`
+}
+
+// ContainerMetrics contains the resource usage of a container.
+type ContainerMetrics struct {
+	Name  string              `json:"name"`
+	Usage corev1.ResourceList `json:"usage"`
+}
+
+// PodMetricsList is a list of PodMetrics.
+type PodMetricsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PodMetrics `json:"items"`
+}
+
+// UsageSource is the interface used to retrieve the Pods resource usage.
+type UsageSource interface {
+	ListPodMetrics(namespace string, selector labels.Selector) ([]PodMetrics, error)
+}
+
+// MetricsServerSource retrieves the Pods resource usage from the metrics.k8s.io API.
+type MetricsServerSource struct {
+	restClient rest.Interface
+}
+
+// NewMetricsServerSource returns new MetricsServerSource instance.
+func NewMetricsServerSource(restClient rest.Interface) *MetricsServerSource {
+	return &MetricsServerSource{restClient: restClient}
+}
+
+// ListPodMetrics returns the resource usage of the Pods matching the selector.
+func (s *MetricsServerSource) ListPodMetrics(namespace string, selector labels.Selector) ([]PodMetrics, error) {
+	req := s.restClient.Get().AbsPath(metricsAPIPath, "namespaces", namespace, "pods")
+	if selector != nil && !selector.Empty() {
+		req = req.Param("labelSelector", selector.String())
+	}
+	body, err := req.DoRaw()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pods metrics, err: %v", err)
+	}
+
+	list := &PodMetricsList{}
+	if err = json.Unmarshal(body, list); err != nil {
+		return nil, fmt.Errorf("unable to decode pods metrics, err: %v", err)
+	}
+	return list.Items, nil
+}
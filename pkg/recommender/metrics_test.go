@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package recommender
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const podMetricsListJSON = `{
+  "kind": "PodMetricsList",
+  "apiVersion": "metrics.k8s.io/v1beta1",
+  "metadata": {},
+  "items": [
+    {
+      "metadata": {"name": "foo-abcde", "namespace": "bar"},
+      "timestamp": "2020-04-01T10:00:00Z",
+      "window": "30s",
+      "containers": [{"name": "agent", "usage": {"cpu": "12m", "memory": "150Mi"}}]
+    }
+  ]
+}`
+
+func TestMetricsServerSource_ListPodMetrics(t *testing.T) {
+	var gotPath, gotSelector string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotSelector = r.URL.Query().Get("labelSelector")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, podMetricsListJSON)
+	}))
+	defer server.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("unable to create client, err: %v", err)
+	}
+
+	source := NewMetricsServerSource(clientset.Discovery().RESTClient())
+	metrics, err := source.ListPodMetrics("bar", labels.SelectorFromSet(labels.Set{"app": "foo"}))
+	if err != nil {
+		t.Fatalf("ListPodMetrics() error = %v", err)
+	}
+
+	if gotPath != "/apis/metrics.k8s.io/v1beta1/namespaces/bar/pods" {
+		t.Errorf("unexpected request path: %s", gotPath)
+	}
+	if gotSelector != "app=foo" {
+		t.Errorf("unexpected label selector: %s", gotSelector)
+	}
+	if len(metrics) != 1 || metrics[0].Name != "foo-abcde" || len(metrics[0].Containers) != 1 {
+		t.Fatalf("unexpected pod metrics: %v", metrics)
+	}
+	if got := metrics[0].Containers[0].Usage[corev1.ResourceMemory]; got.Cmp(resource.MustParse("150Mi")) != 0 {
+		t.Errorf("memory usage = %s, want 150Mi", got.String())
+	}
+}
@@ -0,0 +1,367 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package recommender
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+)
+
+const (
+	// DefaultPercentile default usage percentile used to compute the requests
+	DefaultPercentile = 0.95
+	// DefaultRequestsMargin default margin added to the requests
+	DefaultRequestsMargin = 0.15
+	// DefaultLimitsMargin default margin added to the memory limit
+	DefaultLimitsMargin = 0.5
+
+	mebibyte = 1024 * 1024
+)
+
+// Options contains the parameters used to compute the recommendations.
+type Options struct {
+	// Percentile of the containers usage used as requests, between 0 and 1.
+	Percentile float64
+	// RequestsMargin ratio added to the requests.
+	RequestsMargin float64
+	// LimitsMargin ratio added to the highest memory usage to compute the memory limit.
+	LimitsMargin float64
+	// MinSamples minimum number of Pods in a node group to produce a recommendation.
+	MinSamples int
+	// AllowLimitDecrease allows a memory limit lower than the current limit of the Pods. The usage is only
+	// known at the time of the samples, so by default the recommended limit never decreases.
+	AllowLimitDecrease bool
+}
+
+// DefaultOptions returns the default Options.
+func DefaultOptions() Options {
+	return Options{
+		Percentile:     DefaultPercentile,
+		RequestsMargin: DefaultRequestsMargin,
+		LimitsMargin:   DefaultLimitsMargin,
+		MinSamples:     1,
+	}
+}
+
+// Validate returns an error if the Options are not valid.
+func (o *Options) Validate() error {
+	if o.Percentile <= 0 || o.Percentile > 1 {
+		return fmt.Errorf("percentile should be in ]0,1], current value: %v", o.Percentile)
+	}
+	if o.RequestsMargin < 0 || o.LimitsMargin < 0 {
+		return fmt.Errorf("margins should be positive")
+	}
+	return nil
+}
+
+// NodeGroup represents a set of Nodes that share the same recommendation.
+type NodeGroup struct {
+	// Name of the node group: ExtendedDaemonsetSetting name or label value.
+	Name string
+	// NodeSelector used to select the Nodes of the group.
+	NodeSelector metav1.LabelSelector
+	// Setting is the ExtendedDaemonsetSetting the group has been built from, if any.
+	Setting *datadoghqv1alpha1.ExtendedDaemonsetSetting
+}
+
+// NodeGroupsFromSettings returns a NodeGroup for each ExtendedDaemonsetSetting.
+func NodeGroupsFromSettings(settings []datadoghqv1alpha1.ExtendedDaemonsetSetting) []NodeGroup {
+	groups := make([]NodeGroup, 0, len(settings))
+	for id := range settings {
+		groups = append(groups, NodeGroup{
+			Name:         settings[id].Name,
+			NodeSelector: settings[id].Spec.NodeSelector,
+			Setting:      &settings[id],
+		})
+	}
+	return groups
+}
+
+// NodeGroupsFromLabel returns a NodeGroup for each value of the label on the Nodes.
+func NodeGroupsFromLabel(nodes []corev1.Node, labelKey string) []NodeGroup {
+	values := map[string]bool{}
+	for _, node := range nodes {
+		if value, ok := node.Labels[labelKey]; ok {
+			values[value] = true
+		}
+	}
+
+	groups := make([]NodeGroup, 0, len(values))
+	for value := range values {
+		groups = append(groups, NodeGroup{
+			Name: value,
+			NodeSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{labelKey: value},
+			},
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// Recommendation contains the recommended resources for a NodeGroup.
+type Recommendation struct {
+	NodeGroup  NodeGroup
+	Containers []ContainerRecommendation
+}
+
+// ContainerRecommendation contains the recommended resources for a container.
+type ContainerRecommendation struct {
+	Name      string
+	Samples   int
+	Resources corev1.ResourceRequirements
+}
+
+// Recommend aggregates the Pods usage by NodeGroup and returns the recommended resources.
+// A Node is attached to the first NodeGroup that matches its labels.
+func Recommend(groups []NodeGroup, nodes []corev1.Node, pods []corev1.Pod, usage []PodMetrics, opts Options) ([]Recommendation, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	selectors := make([]labels.Selector, len(groups))
+	for id := range groups {
+		selector, err := metav1.LabelSelectorAsSelector(&groups[id].NodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node selector for group %s, err: %v", groups[id].Name, err)
+		}
+		selectors[id] = selector
+	}
+
+	groupByNode := map[string]int{}
+	for _, node := range nodes {
+		for id, selector := range selectors {
+			if selector.Matches(labels.Set(node.Labels)) {
+				groupByNode[node.Name] = id
+				break
+			}
+		}
+	}
+
+	// the usage can contain several samples of the same Pod, taken at different times
+	usageByPod := map[string][]*PodMetrics{}
+	for id := range usage {
+		key := podKey(usage[id].Namespace, usage[id].Name)
+		usageByPod[key] = append(usageByPod[key], &usage[id])
+	}
+
+	// samples[group][container]
+	samples := make([]map[string]*containerSamples, len(groups))
+	for id := range pods {
+		pod := &pods[id]
+		groupID, ok := groupByNode[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		podUsage, ok := usageByPod[podKey(pod.Namespace, pod.Name)]
+		if !ok {
+			continue
+		}
+		if samples[groupID] == nil {
+			samples[groupID] = map[string]*containerSamples{}
+		}
+		for _, podMetrics := range podUsage {
+			for containerID := range podMetrics.Containers {
+				container := &podMetrics.Containers[containerID]
+				containerUsage := samples[groupID][container.Name]
+				if containerUsage == nil {
+					containerUsage = newContainerSamples()
+					samples[groupID][container.Name] = containerUsage
+				}
+				containerUsage.add(pod, container)
+			}
+		}
+	}
+
+	var recommendations []Recommendation
+	for id := range groups {
+		rec := Recommendation{NodeGroup: groups[id]}
+		for _, containerName := range sortedKeys(samples[id]) {
+			containerRec := recommendContainer(containerName, samples[id][containerName], opts)
+			if containerRec.Samples < opts.MinSamples {
+				continue
+			}
+			rec.Containers = append(rec.Containers, containerRec)
+		}
+		if len(rec.Containers) > 0 {
+			recommendations = append(recommendations, rec)
+		}
+	}
+	return recommendations, nil
+}
+
+// containerSamples contains the usage samples of a container in a NodeGroup
+type containerSamples struct {
+	pods   map[string]bool
+	values map[corev1.ResourceName][]int64
+	// memoryLimit highest memory limit of the container in the Pods, in bytes
+	memoryLimit int64
+}
+
+func newContainerSamples() *containerSamples {
+	return &containerSamples{
+		pods:   map[string]bool{},
+		values: map[corev1.ResourceName][]int64{},
+	}
+}
+
+func (s *containerSamples) add(pod *corev1.Pod, usage *ContainerMetrics) {
+	s.pods[podKey(pod.Namespace, pod.Name)] = true
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if value, ok := usage.Usage[name]; ok {
+			s.values[name] = append(s.values[name], value.MilliValue())
+		}
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name != usage.Name {
+			continue
+		}
+		if limit, ok := container.Resources.Limits[corev1.ResourceMemory]; ok && limit.Value() > s.memoryLimit {
+			s.memoryLimit = limit.Value()
+		}
+	}
+}
+
+func recommendContainer(name string, samples *containerSamples, opts Options) ContainerRecommendation {
+	rec := ContainerRecommendation{
+		Name:    name,
+		Samples: len(samples.pods),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{},
+		},
+	}
+
+	if values := samples.values[corev1.ResourceCPU]; len(values) > 0 {
+		request := applyMargin(percentile(values, opts.Percentile), opts.RequestsMargin)
+		rec.Resources.Requests[corev1.ResourceCPU] = *resource.NewMilliQuantity(request, resource.DecimalSI)
+	}
+
+	if values := samples.values[corev1.ResourceMemory]; len(values) > 0 {
+		request := roundUpMebibyte(applyMargin(percentile(values, opts.Percentile), opts.RequestsMargin))
+		limit := roundUpMebibyte(applyMargin(percentile(values, 1), opts.LimitsMargin))
+		if !opts.AllowLimitDecrease && limit < samples.memoryLimit {
+			limit = samples.memoryLimit
+		}
+		if limit < request {
+			limit = request
+		}
+		rec.Resources.Requests[corev1.ResourceMemory] = *resource.NewQuantity(request, resource.BinarySI)
+		rec.Resources.Limits = corev1.ResourceList{
+			corev1.ResourceMemory: *resource.NewQuantity(limit, resource.BinarySI),
+		}
+	}
+
+	return rec
+}
+
+// BuildExtendedDaemonsetSetting returns the ExtendedDaemonsetSetting corresponding to the Recommendation.
+// If the NodeGroup has been built from an ExtendedDaemonsetSetting, it is updated with the recommended resources,
+// other container settings like the resources formulas are kept.
+func BuildExtendedDaemonsetSetting(eds *datadoghqv1alpha1.ExtendedDaemonSet, rec *Recommendation) *datadoghqv1alpha1.ExtendedDaemonsetSetting {
+	var setting *datadoghqv1alpha1.ExtendedDaemonsetSetting
+	if rec.NodeGroup.Setting != nil {
+		setting = rec.NodeGroup.Setting.DeepCopy()
+	} else {
+		setting = &datadoghqv1alpha1.ExtendedDaemonsetSetting{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: datadoghqv1alpha1.SchemeGroupVersion.String(),
+				Kind:       "ExtendedDaemonsetSetting",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: eds.Namespace,
+				Name:      SettingName(eds.Name, rec.NodeGroup.Name),
+			},
+			Spec: datadoghqv1alpha1.ExtendedDaemonsetSettingSpec{
+				Reference: &autoscalingv1.CrossVersionObjectReference{
+					Kind: "ExtendedDaemonset",
+					Name: eds.Name,
+				},
+				NodeSelector: rec.NodeGroup.NodeSelector,
+			},
+		}
+	}
+
+	for _, containerRec := range rec.Containers {
+		found := false
+		for id := range setting.Spec.Containers {
+			if setting.Spec.Containers[id].Name == containerRec.Name {
+				setting.Spec.Containers[id].Resources = containerRec.Resources
+				found = true
+				break
+			}
+		}
+		if !found {
+			setting.Spec.Containers = append(setting.Spec.Containers, datadoghqv1alpha1.ExtendedDaemonsetSettingContainerSpec{
+				Name:      containerRec.Name,
+				Resources: containerRec.Resources,
+			})
+		}
+	}
+	return setting
+}
+
+// SettingName returns a valid ExtendedDaemonsetSetting name for an ExtendedDaemonset and a NodeGroup name.
+func SettingName(edsName, groupName string) string {
+	name := strings.ToLower(fmt.Sprintf("%s-%s", edsName, groupName))
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, name)
+	return strings.Trim(name, "-.")
+}
+
+func percentile(values []int64, p float64) int64 {
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	// nearest-rank method
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func applyMargin(milliValue int64, margin float64) int64 {
+	return int64(math.Round(float64(milliValue) * (1 + margin)))
+}
+
+// roundUpMebibyte converts a milli value in bytes, rounded up to the next mebibyte.
+func roundUpMebibyte(milliValue int64) int64 {
+	value := milliValue / 1000
+	if milliValue%1000 > 0 {
+		value++
+	}
+	if value%mebibyte > 0 {
+		value = (value/mebibyte + 1) * mebibyte
+	}
+	return value
+}
+
+func podKey(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+func sortedKeys(m map[string]*containerSamples) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package recommender
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1"
+	"github.com/datadog/extendeddaemonset/pkg/apis/datadoghq/v1alpha1/test"
+	ctrltest "github.com/datadog/extendeddaemonset/pkg/controller/test"
+)
+
+func newPodMetrics(namespace, name, cpu, memory string) PodMetrics {
+	return PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Containers: []ContainerMetrics{
+			{
+				Name: "agent",
+				Usage: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		},
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	nodes := []corev1.Node{
+		*ctrltest.NewNode("node1", &ctrltest.NewNodeOptions{Labels: map[string]string{"pool": "small"}}),
+		*ctrltest.NewNode("node2", &ctrltest.NewNodeOptions{Labels: map[string]string{"pool": "small"}}),
+		*ctrltest.NewNode("node3", &ctrltest.NewNodeOptions{Labels: map[string]string{"pool": "large"}}),
+		*ctrltest.NewNode("node4", nil),
+	}
+	pods := []corev1.Pod{
+		*ctrltest.NewPod("bar", "pod1", "node1", nil),
+		*ctrltest.NewPod("bar", "pod2", "node2", nil),
+		*ctrltest.NewPod("bar", "pod3", "node3", nil),
+		*ctrltest.NewPod("bar", "pod4", "node4", nil),
+	}
+	usage := []PodMetrics{
+		newPodMetrics("bar", "pod1", "100m", "100Mi"),
+		newPodMetrics("bar", "pod2", "200m", "200Mi"),
+		newPodMetrics("bar", "pod3", "1", "1Gi"),
+		newPodMetrics("bar", "pod4", "2", "2Gi"),
+	}
+
+	opts := Options{Percentile: 0.5, RequestsMargin: 0.1, LimitsMargin: 0.5, MinSamples: 1}
+	recs, err := Recommend(NodeGroupsFromLabel(nodes, "pool"), nodes, pods, usage, opts)
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("Recommend() returned %d recommendations, want 2", len(recs))
+	}
+
+	large, small := recs[0], recs[1]
+	if large.NodeGroup.Name != "large" || small.NodeGroup.Name != "small" {
+		t.Fatalf("unexpected node groups %s, %s", large.NodeGroup.Name, small.NodeGroup.Name)
+	}
+
+	checkQuantity(t, "small requests.cpu", small.Containers[0].Resources.Requests[corev1.ResourceCPU], "110m")
+	checkQuantity(t, "small requests.memory", small.Containers[0].Resources.Requests[corev1.ResourceMemory], "110Mi")
+	checkQuantity(t, "small limits.memory", small.Containers[0].Resources.Limits[corev1.ResourceMemory], "300Mi")
+	if small.Containers[0].Samples != 2 {
+		t.Errorf("small samples = %d, want 2", small.Containers[0].Samples)
+	}
+	checkQuantity(t, "large requests.cpu", large.Containers[0].Resources.Requests[corev1.ResourceCPU], "1100m")
+	checkQuantity(t, "large limits.memory", large.Containers[0].Resources.Limits[corev1.ResourceMemory], "1536Mi")
+
+	opts.MinSamples = 2
+	recs, err = Recommend(NodeGroupsFromLabel(nodes, "pool"), nodes, pods, usage, opts)
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+	if len(recs) != 1 || recs[0].NodeGroup.Name != "small" {
+		t.Errorf("Recommend() with MinSamples should only return the small group, got %v", recs)
+	}
+
+	if _, err = Recommend(nil, nodes, pods, usage, Options{Percentile: 2}); err == nil {
+		t.Errorf("Recommend() should return an error with an invalid percentile")
+	}
+}
+
+func TestRecommend_Samples(t *testing.T) {
+	nodes := []corev1.Node{
+		*ctrltest.NewNode("node1", &ctrltest.NewNodeOptions{Labels: map[string]string{"pool": "small"}}),
+	}
+	pod := ctrltest.NewPod("bar", "pod1", "node1", &ctrltest.NewPodOptions{
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+		},
+	})
+	pod.Spec.Containers[0].Name = "agent"
+	// the same pod sampled at a quiet moment and during a peak
+	usage := []PodMetrics{
+		newPodMetrics("bar", "pod1", "100m", "100Mi"),
+		newPodMetrics("bar", "pod1", "500m", "300Mi"),
+	}
+	opts := Options{Percentile: 1, RequestsMargin: 0, LimitsMargin: 0.5, MinSamples: 1}
+
+	recs, err := Recommend(NodeGroupsFromLabel(nodes, "pool"), nodes, []corev1.Pod{*pod}, usage, opts)
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+	if len(recs) != 1 || len(recs[0].Containers) != 1 {
+		t.Fatalf("Recommend() = %v, want 1 container recommendation", recs)
+	}
+	container := recs[0].Containers[0]
+	if container.Samples != 1 {
+		t.Errorf("samples = %d, want 1 pod", container.Samples)
+	}
+	checkQuantity(t, "requests.cpu", container.Resources.Requests[corev1.ResourceCPU], "500m")
+	checkQuantity(t, "requests.memory", container.Resources.Requests[corev1.ResourceMemory], "300Mi")
+	// 300Mi * 1.5 is lower than the current limit
+	checkQuantity(t, "limits.memory", container.Resources.Limits[corev1.ResourceMemory], "512Mi")
+
+	opts.AllowLimitDecrease = true
+	recs, err = Recommend(NodeGroupsFromLabel(nodes, "pool"), nodes, []corev1.Pod{*pod}, usage, opts)
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+	checkQuantity(t, "limits.memory with AllowLimitDecrease", recs[0].Containers[0].Resources.Limits[corev1.ResourceMemory], "450Mi")
+}
+
+func TestBuildExtendedDaemonsetSetting(t *testing.T) {
+	eds := test.NewExtendedDaemonSet("bar", "foo", nil)
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("110m")},
+	}
+
+	rec := &Recommendation{
+		NodeGroup: NodeGroup{
+			Name:         "Pool_A",
+			NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "Pool_A"}},
+		},
+		Containers: []ContainerRecommendation{{Name: "agent", Resources: resources}},
+	}
+	setting := BuildExtendedDaemonsetSetting(eds, rec)
+	if setting.Name != "foo-pool-a" {
+		t.Errorf("setting.Name = %s, want foo-pool-a", setting.Name)
+	}
+	if setting.Spec.Reference == nil || setting.Spec.Reference.Name != "foo" {
+		t.Errorf("setting should reference the ExtendedDaemonset foo")
+	}
+	if len(setting.Spec.Containers) != 1 || setting.Spec.Containers[0].Name != "agent" {
+		t.Fatalf("unexpected setting containers: %v", setting.Spec.Containers)
+	}
+
+	existing := test.NewExtendedDaemonsetSetting("bar", "foo-small", "foo", &test.NewExtendedDaemonsetSettingOptions{
+		Resources: map[string]corev1.ResourceRequirements{
+			"agent":   {Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			"sidecar": {Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")}},
+		},
+	})
+	rec.NodeGroup = NodeGroupsFromSettings([]datadoghqv1alpha1.ExtendedDaemonsetSetting{*existing})[0]
+	setting = BuildExtendedDaemonsetSetting(eds, rec)
+	if setting.Name != "foo-small" {
+		t.Errorf("setting.Name = %s, want foo-small", setting.Name)
+	}
+	for _, container := range setting.Spec.Containers {
+		switch container.Name {
+		case "agent":
+			checkQuantity(t, "agent requests.cpu", container.Resources.Requests[corev1.ResourceCPU], "110m")
+		case "sidecar":
+			checkQuantity(t, "sidecar requests.cpu", container.Resources.Requests[corev1.ResourceCPU], "50m")
+		}
+	}
+	if len(setting.Spec.Containers) != 2 {
+		t.Errorf("unexpected setting containers: %v", setting.Spec.Containers)
+	}
+}
+
+func TestSettingName(t *testing.T) {
+	tests := []struct {
+		eds   string
+		group string
+		want  string
+	}{
+		{eds: "foo", group: "small", want: "foo-small"},
+		{eds: "foo", group: "Pool_A", want: "foo-pool-a"},
+		{eds: "foo", group: "", want: "foo"},
+	}
+	for _, tt := range tests {
+		if got := SettingName(tt.eds, tt.group); got != tt.want {
+			t.Errorf("SettingName(%s, %s) = %s, want %s", tt.eds, tt.group, got, tt.want)
+		}
+	}
+}
+
+func checkQuantity(t *testing.T, name string, got resource.Quantity, want string) {
+	t.Helper()
+	if got.Cmp(resource.MustParse(want)) != 0 {
+		t.Errorf("%s = %s, want %s", name, got.String(), want)
+	}
+}